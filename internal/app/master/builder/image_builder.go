@@ -49,6 +49,12 @@ const (
 	dsEvtPortInfo = "65502/tcp"
 )
 
+const (
+	dataDirName    = "files"
+	dataTarName    = "files.tar"
+	dockerfileName = "Dockerfile"
+)
+
 // NewImageBuilder creates a new BasicImageBuilder instances
 func NewBasicImageBuilder(client *docker.Client,
 	imageRepoNameTag string,
@@ -106,7 +112,7 @@ func NewImageBuilder(client *docker.Client,
 				Name:           imageRepoNameTag,
 				RmTmpContainer: true,
 				ContextDir:     artifactLocation,
-				Dockerfile:     "Dockerfile",
+				Dockerfile:     dockerfileName,
 				//SuppressOutput: true,
 			},
 		},
@@ -254,12 +260,12 @@ func NewImageBuilder(client *docker.Client,
 
 	builder.BuildOptions.OutputStream = &builder.BuildLog
 
-	dataTar := filepath.Join(artifactLocation, "files.tar")
+	dataTar := filepath.Join(artifactLocation, dataTarName)
 	builder.TarData = fsutil.IsRegularFile(dataTar)
 	if builder.TarData {
 		builder.HasData = true
 	} else {
-		dataDir := filepath.Join(artifactLocation, "files")
+		dataDir := filepath.Join(artifactLocation, dataDirName)
 		builder.HasData = fsutil.IsDir(dataDir)
 	}
 
@@ -277,7 +283,11 @@ func (b *ImageBuilder) Build() error {
 
 // GenerateDockerfile creates a Dockerfile file
 func (b *ImageBuilder) GenerateDockerfile() error {
-	return reverse.GenerateFromInfo(b.BuildOptions.ContextDir,
+	return b.generateDockerfile(b.BuildOptions.ContextDir, b.TarData)
+}
+
+func (b *ImageBuilder) generateDockerfile(location string, tarData bool) error {
+	return reverse.GenerateFromInfo(location,
 		b.Volumes,
 		b.WorkingDir,
 		b.Env,
@@ -287,5 +297,5 @@ func (b *ImageBuilder) GenerateDockerfile() error {
 		b.Entrypoint,
 		b.Cmd,
 		b.HasData,
-		b.TarData)
+		tarData)
 }
@@ -0,0 +1,259 @@
+package builder
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SaveOutputDir saves the minified filesystem ('files' directory) and the generated Dockerfile
+// to the output directory (instead of building the optimized image)
+func (b *ImageBuilder) SaveOutputDir(outputDir string) (*dockerimage.ExtractResult, error) {
+	filesDir := filepath.Join(outputDir, dataDirName)
+	if fsutil.Exists(filesDir) {
+		log.Debugf("ImageBuilder.SaveOutputDir: removing old output data - %v", filesDir)
+		if err := os.RemoveAll(filesDir); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &dockerimage.ExtractResult{}
+	if b.HasData {
+		var err error
+		if b.TarData {
+			result, err = dockerimage.ExtractTarFile(filepath.Join(b.BuildOptions.ContextDir, dataTarName), filesDir)
+		} else {
+			//the data directory objects are extracted the same way the data tar objects are
+			pr, pw := io.Pipe()
+			go func() {
+				tw := tar.NewWriter(pw)
+				err := copyDataDir(tw, filepath.Join(b.BuildOptions.ContextDir, dataDirName), &dockerimage.ExtractResult{})
+				if err == nil {
+					err = tw.Close()
+				}
+
+				pw.CloseWithError(err)
+			}()
+
+			result, err = dockerimage.ExtractTar(pr, filesDir)
+			pr.Close()
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := b.generateDockerfile(outputDir, false); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SaveOutputTar saves the minified filesystem (in the 'files.tar' file) and the generated Dockerfile
+// to the output tar file (instead of building the optimized image). The tar file can be used
+// as the 'docker build' context (the generated Dockerfile uses 'ADD files.tar /',
+// so the file ownership is preserved in the built image).
+func (b *ImageBuilder) SaveOutputTar(outputPath string) (*dockerimage.ExtractResult, error) {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := ioutil.TempDir("", "docker-slim-output")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		errutil.WarnOn(os.RemoveAll(tmpDir))
+	}()
+
+	if err := b.generateDockerfile(tmpDir, true); err != nil {
+		return nil, err
+	}
+
+	result := &dockerimage.ExtractResult{}
+	dataTar := filepath.Join(tmpDir, dataTarName)
+	if b.HasData {
+		if err := b.saveDataTar(dataTar, result); err != nil {
+			log.Errorf("ImageBuilder.SaveOutputTar: error saving data tar file(%v) - %v", dataTar, err)
+			return nil, err
+		}
+	}
+
+	ofile, err := os.Create(outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ofile.Close()
+
+	tw := tar.NewWriter(ofile)
+	if err := writeTarPath(tw, filepath.Join(tmpDir, dockerfileName), dockerfileName); err != nil {
+		return nil, err
+	}
+
+	if b.HasData {
+		if err := writeTarPath(tw, dataTar, dataTarName); err != nil {
+			log.Errorf("ImageBuilder.SaveOutputTar: error saving data to tar file(%v) - %v", outputPath, err)
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// saveDataTar saves the collected artifacts (the data tar file or the data directory) to the tar file
+func (b *ImageBuilder) saveDataTar(outputPath string, result *dockerimage.ExtractResult) error {
+	ofile, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+
+	defer ofile.Close()
+
+	tw := tar.NewWriter(ofile)
+	if b.TarData {
+		err = copyDataTar(tw, filepath.Join(b.BuildOptions.ContextDir, dataTarName), result)
+	} else {
+		err = copyDataDir(tw, filepath.Join(b.BuildOptions.ContextDir, dataDirName), result)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// copyDataTar copies the objects from the data tar file to the output tar file (the object names are cleaned)
+func copyDataTar(tw *tar.Writer, dataTar string, result *dockerimage.ExtractResult) error {
+	tfile, err := os.Open(dataTar)
+	if err != nil {
+		return err
+	}
+
+	defer tfile.Close()
+
+	tr := tar.NewReader(tfile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimPrefix(filepath.Clean(hdr.Name), "/")
+		if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+			continue
+		}
+
+		hdr.Name = name
+		if hdr.Typeflag == tar.TypeDir {
+			hdr.Name += "/"
+		}
+
+		if hdr.Typeflag == tar.TypeLink {
+			hdr.Linkname = strings.TrimPrefix(filepath.Clean(hdr.Linkname), "/")
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+
+		result.Objects++
+		if hdr.Typeflag == tar.TypeReg {
+			result.Size += uint64(hdr.Size)
+		}
+	}
+
+	return nil
+}
+
+// copyDataDir copies the objects from the data directory to the output tar file
+func copyDataDir(tw *tar.Writer, dataDir string, result *dockerimage.ExtractResult) error {
+	return filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dataDir, path)
+		if err != nil || relPath == "." {
+			return err
+		}
+
+		if err := writeTarPath(tw, path, filepath.ToSlash(relPath)); err != nil {
+			return err
+		}
+
+		result.Objects++
+		if info.Mode().IsRegular() {
+			result.Size += uint64(info.Size())
+		}
+
+		return nil
+	})
+}
+
+func writeTarPath(tw *tar.Writer, path, name string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	ifile, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer ifile.Close()
+
+	_, err = io.Copy(tw, ifile)
+	return err
+}
@@ -8,6 +8,7 @@ import (
 	_ "github.com/docker-slim/docker-slim/internal/app/master/commands/build"
 	_ "github.com/docker-slim/docker-slim/internal/app/master/commands/containerize"
 	_ "github.com/docker-slim/docker-slim/internal/app/master/commands/convert"
+	_ "github.com/docker-slim/docker-slim/internal/app/master/commands/diff"
 	_ "github.com/docker-slim/docker-slim/internal/app/master/commands/edit"
 	_ "github.com/docker-slim/docker-slim/internal/app/master/commands/help"
 	_ "github.com/docker-slim/docker-slim/internal/app/master/commands/lint"
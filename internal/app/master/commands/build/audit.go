@@ -0,0 +1,187 @@
+package build
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker-slim/docker-slim/internal/app/master/inspectors/container"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+// FilesAuditFileName is the removed and retained files audit artifact name
+const FilesAuditFileName = "files_audit.json"
+
+// auditFiles saves the fat image to find the files removed from the minified image
+// and it saves the audit artifact with the removed and retained files
+func auditFiles(client *docker.Client,
+	imageID string,
+	localVolumePath string,
+	artifactLocation string) (*dockerimage.FilesAudit, error) {
+	retained, err := retainedFiles(artifactLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	imageID = dockerutil.CleanImageID(imageID)
+	archivePath := filepath.Join(localVolumePath, "image", fmt.Sprintf("%s.audit.tar", imageID))
+	if err := dockerutil.SaveImage(client, imageID, archivePath, false, false); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		errutil.WarnOn(fsutil.Remove(archivePath))
+	}()
+
+	pkg, err := dockerimage.LoadPackage(archivePath, imageID, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	audit, err := pkg.AuditFiles(archivePath, retained)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(audit, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(artifactLocation, FilesAuditFileName), data, 0644); err != nil {
+		return nil, err
+	}
+
+	return audit, nil
+}
+
+// retainedFiles returns the minified filesystem objects (from the collected file artifacts)
+// with the reasons they were kept (using the container report data)
+func retainedFiles(artifactLocation string) (map[string]string, error) {
+	objects := map[string]string{} //object path -> symlink target (if it's a symlink)
+	dataTar := filepath.Join(artifactLocation, container.FileArtifactsTar)
+	dataDir := filepath.Join(artifactLocation, container.FileArtifactsDirName)
+	switch {
+	case fsutil.IsRegularFile(dataTar):
+		tfile, err := os.Open(dataTar)
+		if err != nil {
+			return nil, err
+		}
+
+		defer tfile.Close()
+
+		tr := tar.NewReader(tfile)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			if hdr.Typeflag == tar.TypeDir {
+				continue
+			}
+
+			var target string
+			if hdr.Typeflag == tar.TypeSymlink {
+				target = hdr.Linkname
+			}
+
+			objects[filepath.Join("/", hdr.Name)] = target
+		}
+	case fsutil.IsDir(dataDir):
+		err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+
+			var target string
+			if info.Mode()&os.ModeSymlink != 0 {
+				if target, err = os.Readlink(path); err != nil {
+					return err
+				}
+			}
+
+			objects[filepath.Join("/", strings.TrimPrefix(path, dataDir))] = target
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var creport report.ContainerReport
+	creportData, err := ioutil.ReadFile(filepath.Join(artifactLocation, report.DefaultContainerReportFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(creportData, &creport); err != nil {
+		return nil, err
+	}
+
+	accessed := map[string]struct{}{}
+	for _, props := range creport.Image.Files {
+		if props != nil {
+			accessed[props.FilePath] = struct{}{}
+		}
+	}
+
+	linkTargets := map[string]struct{}{}
+	for name, target := range objects {
+		if target == "" {
+			continue
+		}
+
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(name), target)
+		}
+
+		linkTargets[filepath.Clean(target)] = struct{}{}
+	}
+
+	//the keep reasons in the precedence order:
+	//observed access, include flags, symlink target and other
+	retained := map[string]string{}
+	for name := range objects {
+		reason := report.KeepReasonOther
+		if _, ok := accessed[name]; ok {
+			reason = report.KeepReasonAccess
+		} else if included := includedReason(creport.Image.Included, name); included != "" {
+			reason = included
+		} else if _, ok := linkTargets[name]; ok {
+			reason = report.KeepReasonSymlinkTarget
+		}
+
+		retained[name] = reason
+	}
+
+	return retained, nil
+}
+
+// includedReason returns the keep reason for the included artifacts
+// (empty if the object wasn't included)
+func includedReason(included []*report.IncludedArtifact, name string) string {
+	for _, info := range included {
+		if info.FilePath == name ||
+			(info.IsDir && strings.HasPrefix(name, strings.TrimSuffix(info.FilePath, "/")+"/")) {
+			return info.Reason
+		}
+	}
+
+	return ""
+}
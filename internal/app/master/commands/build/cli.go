@@ -83,37 +83,17 @@ var CLI = cli.Command{
 		commands.Cflag(commands.FlagNetwork),
 		commands.Cflag(commands.FlagHostname),
 		commands.Cflag(commands.FlagExpose),
-		cflag(FlagNewEntrypoint),
-		cflag(FlagNewCmd),
-		cflag(FlagNewExpose),
-		cflag(FlagNewWorkdir),
-		cflag(FlagNewEnv),
-		cflag(FlagNewVolume),
-		cflag(FlagNewLabel),
-		cli.StringSliceFlag{
-			Name:   FlagRemoveExpose,
-			Value:  &cli.StringSlice{},
-			Usage:  FlagRemoveExposeUsage,
-			EnvVar: "DSLIM_RM_EXPOSE",
-		},
-		cli.StringSliceFlag{
-			Name:   FlagRemoveEnv,
-			Value:  &cli.StringSlice{},
-			Usage:  FlagRemoveEnvUsage,
-			EnvVar: "DSLIM_RM_ENV",
-		},
-		cli.StringSliceFlag{
-			Name:   FlagRemoveLabel,
-			Value:  &cli.StringSlice{},
-			Usage:  FlagRemoveLabelUsage,
-			EnvVar: "DSLIM_RM_LABEL",
-		},
-		cli.StringSliceFlag{
-			Name:   FlagRemoveVolume,
-			Value:  &cli.StringSlice{},
-			Usage:  FlagRemoveVolumeUsage,
-			EnvVar: "DSLIM_RM_VOLUME",
-		},
+		commands.Cflag(commands.FlagNewEntrypoint),
+		commands.Cflag(commands.FlagNewCmd),
+		commands.Cflag(commands.FlagNewExpose),
+		commands.Cflag(commands.FlagNewWorkdir),
+		commands.Cflag(commands.FlagNewEnv),
+		commands.Cflag(commands.FlagNewVolume),
+		commands.Cflag(commands.FlagNewLabel),
+		commands.Cflag(commands.FlagRemoveExpose),
+		commands.Cflag(commands.FlagRemoveEnv),
+		commands.Cflag(commands.FlagRemoveLabel),
+		commands.Cflag(commands.FlagRemoveVolume),
 		commands.Cflag(commands.FlagExcludeMounts),
 		commands.Cflag(commands.FlagExcludePattern),
 		commands.Cflag(commands.FlagPathPerms),
@@ -140,6 +120,10 @@ var CLI = cli.Command{
 		commands.Cflag(commands.FlagUseLocalMounts),
 		commands.Cflag(commands.FlagUseSensorVolume),
 		commands.Cflag(commands.FlagKeepTmpArtifacts),
+		cflag(FlagCompressedSize),
+		cflag(FlagFilesAudit),
+		cflag(FlagOutputDir),
+		cflag(FlagOutputTar),
 	},
 	Action: func(ctx *cli.Context) error {
 		commands.ShowCommunityInfo()
@@ -252,7 +236,7 @@ var CLI = cli.Command{
 			return err
 		}
 
-		instructions, err := GetImageInstructions(ctx)
+		instructions, err := commands.GetImageInstructions(ctx)
 		if err != nil {
 			fmt.Printf("docker-slim[%s]: invalid image instructions: %v\n", Name, err)
 			return err
@@ -313,6 +297,18 @@ var CLI = cli.Command{
 
 		doKeepTmpArtifacts := ctx.Bool(commands.FlagKeepTmpArtifacts)
 
+		doCompressedSize := ctx.Bool(FlagCompressedSize)
+
+		doFilesAudit := ctx.BoolT(FlagFilesAudit)
+
+		outputDir := ctx.String(FlagOutputDir)
+		outputTar := ctx.String(FlagOutputTar)
+		if outputDir != "" && outputTar != "" {
+			fmt.Printf("docker-slim[%s]: use only one of the --%s and --%s flags...\n\n", Name, FlagOutputDir, FlagOutputTar)
+			cli.ShowCommandHelp(ctx, Name)
+			return nil
+		}
+
 		doExcludeMounts := ctx.BoolT(commands.FlagExcludeMounts)
 		if doExcludeMounts {
 			for mpath := range volumeMounts {
@@ -384,6 +380,10 @@ var CLI = cli.Command{
 			doUseLocalMounts,
 			doUseSensorVolume,
 			doKeepTmpArtifacts,
+			doCompressedSize,
+			doFilesAudit,
+			outputDir,
+			outputTar,
 			continueAfter,
 			ec)
 		commands.ShowCommunityInfo()
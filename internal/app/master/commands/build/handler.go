@@ -19,6 +19,8 @@ import (
 	"github.com/docker-slim/docker-slim/internal/app/master/inspectors/image"
 	"github.com/docker-slim/docker-slim/internal/app/master/version"
 	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
 	"github.com/docker-slim/docker-slim/pkg/report"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
@@ -36,6 +38,7 @@ const (
 	ecbOther = iota + 1
 	ecbBadCustomImageTag
 	ecbImageBuildError
+	ecbOutputSaveError
 )
 
 // OnCommand implements the 'build' docker-slim command
@@ -83,6 +86,10 @@ func OnCommand(
 	doUseLocalMounts bool,
 	doUseSensorVolume string,
 	doKeepTmpArtifacts bool,
+	doCompressedSize bool,
+	doFilesAudit bool,
+	outputDir string,
+	outputTar string,
 	continueAfter *config.ContinueAfter,
 	ec *commands.ExecutionContext) {
 	const cmdName = command.Build
@@ -391,7 +398,6 @@ func OnCommand(
 	}
 
 	fmt.Printf("%s[%s]: state=container.inspection.done\n", appName, cmdName)
-	fmt.Printf("%s[%s]: state=building message='building optimized image'\n", appName, cmdName)
 
 	builder, err := builder.NewImageBuilder(client,
 		customImageTag,
@@ -407,95 +413,152 @@ func OnCommand(
 		logger.Info("WARNING - no data artifacts")
 	}
 
-	err = builder.Build()
-
-	if doShowBuildLogs || err != nil {
-		fmt.Printf("%s[%s]: build logs (optimized image) ====================\n", appName, cmdName)
-		fmt.Println(builder.BuildLog.String())
-		fmt.Printf("%s[%s]: end of build logs (optimized image) =============\n", appName, cmdName)
-	}
+	if outputDir != "" || outputTar != "" {
+		saveOutput(builder, outputDir, outputTar, appName, cmdName, cmdReport)
+	} else {
+		fmt.Printf("%s[%s]: state=building message='building optimized image'\n", appName, cmdName)
 
-	if err != nil {
-		fmt.Printf("%s[%s]: info=build.error status=optimized.image.build.error value='%v'\n", appName, cmdName, err)
-		fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
-		commands.Exit(commands.ECTBuild | ecbImageBuildError)
-	}
+		err = builder.Build()
 
-	fmt.Printf("%s[%s]: state=completed\n", appName, cmdName)
-	cmdReport.State = command.StateCompleted
+		if doShowBuildLogs || err != nil {
+			fmt.Printf("%s[%s]: build logs (optimized image) ====================\n", appName, cmdName)
+			fmt.Println(builder.BuildLog.String())
+			fmt.Printf("%s[%s]: end of build logs (optimized image) =============\n", appName, cmdName)
+		}
 
-	/////////////////////////////
-	newImageInspector, err := image.NewInspector(client, builder.RepoName)
-	errutil.FailOn(err)
+		if err != nil {
+			fmt.Printf("%s[%s]: info=build.error status=optimized.image.build.error value='%v'\n", appName, cmdName, err)
+			fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+			commands.Exit(commands.ECTBuild | ecbImageBuildError)
+		}
 
-	if newImageInspector.NoImage() {
-		fmt.Printf("%s[%s]: info=results message='minified image not found - %s'\n", appName, cmdName, builder.RepoName)
-		fmt.Printf("%s[%s]: state=exited\n", appName, cmdName)
-		return
-	}
+		fmt.Printf("%s[%s]: state=completed\n", appName, cmdName)
+		cmdReport.State = command.StateCompleted
 
-	err = newImageInspector.Inspect()
-	errutil.WarnOn(err)
+		/////////////////////////////
+		newImageInspector, err := image.NewInspector(client, builder.RepoName)
+		errutil.FailOn(err)
 
-	if err == nil {
-		cmdReport.MinifiedBy = float64(imageInspector.ImageInfo.VirtualSize) / float64(newImageInspector.ImageInfo.VirtualSize)
-
-		cmdReport.SourceImage = report.ImageMetadata{
-			AllNames:      imageInspector.ImageRecordInfo.RepoTags,
-			ID:            imageInspector.ImageRecordInfo.ID,
-			Size:          imageInspector.ImageInfo.VirtualSize,
-			SizeHuman:     humanize.Bytes(uint64(imageInspector.ImageInfo.VirtualSize)),
-			CreateTime:    imageInspector.ImageInfo.Created.UTC().Format(time.RFC3339),
-			Author:        imageInspector.ImageInfo.Author,
-			DockerVersion: imageInspector.ImageInfo.DockerVersion,
-			Architecture:  imageInspector.ImageInfo.Architecture,
-			User:          imageInspector.ImageInfo.Config.User,
+		if newImageInspector.NoImage() {
+			fmt.Printf("%s[%s]: info=results message='minified image not found - %s'\n", appName, cmdName, builder.RepoName)
+			fmt.Printf("%s[%s]: state=exited\n", appName, cmdName)
+			return
 		}
 
-		if len(imageInspector.ImageRecordInfo.RepoTags) > 0 {
-			cmdReport.SourceImage.Name = imageInspector.ImageRecordInfo.RepoTags[0]
-		}
+		err = newImageInspector.Inspect()
+		errutil.WarnOn(err)
 
-		if len(imageInspector.ImageInfo.Config.ExposedPorts) > 0 {
-			for k := range imageInspector.ImageInfo.Config.ExposedPorts {
-				cmdReport.SourceImage.ExposedPorts = append(cmdReport.SourceImage.ExposedPorts, string(k))
+		if err == nil {
+			cmdReport.MinifiedBy = float64(imageInspector.ImageInfo.VirtualSize) / float64(newImageInspector.ImageInfo.VirtualSize)
+
+			cmdReport.SourceImage = report.ImageMetadata{
+				AllNames:      imageInspector.ImageRecordInfo.RepoTags,
+				ID:            imageInspector.ImageRecordInfo.ID,
+				Size:          imageInspector.ImageInfo.VirtualSize,
+				SizeHuman:     humanize.Bytes(uint64(imageInspector.ImageInfo.VirtualSize)),
+				CreateTime:    imageInspector.ImageInfo.Created.UTC().Format(time.RFC3339),
+				Author:        imageInspector.ImageInfo.Author,
+				DockerVersion: imageInspector.ImageInfo.DockerVersion,
+				Architecture:  imageInspector.ImageInfo.Architecture,
+				User:          imageInspector.ImageInfo.Config.User,
+			}
+
+			if len(imageInspector.ImageRecordInfo.RepoTags) > 0 {
+				cmdReport.SourceImage.Name = imageInspector.ImageRecordInfo.RepoTags[0]
+			}
+
+			if len(imageInspector.ImageInfo.Config.ExposedPorts) > 0 {
+				for k := range imageInspector.ImageInfo.Config.ExposedPorts {
+					cmdReport.SourceImage.ExposedPorts = append(cmdReport.SourceImage.ExposedPorts, string(k))
+				}
 			}
+
+			cmdReport.MinifiedImageSize = newImageInspector.ImageInfo.VirtualSize
+			cmdReport.MinifiedImageSizeHuman = humanize.Bytes(uint64(newImageInspector.ImageInfo.VirtualSize))
+
+			fmt.Printf("%s[%s]: info=results status='MINIFIED BY %.2fX [%v (%v) => %v (%v)]'\n",
+				appName, cmdName,
+				cmdReport.MinifiedBy,
+				cmdReport.SourceImage.Size,
+				cmdReport.SourceImage.SizeHuman,
+				cmdReport.MinifiedImageSize,
+				cmdReport.MinifiedImageSizeHuman)
+
+			if doCompressedSize {
+				fmt.Printf("%s[%s]: state=image.blob.size.start\n", appName, cmdName)
+
+				cmdReport.SourceImageBlobSizes, err = imageBlobSizes(client, imageInspector.ImageInfo.ID, localVolumePath)
+				if err == nil {
+					cmdReport.MinifiedImageBlobSizes, err = imageBlobSizes(client, newImageInspector.ImageInfo.ID, localVolumePath)
+				}
+
+				if err == nil {
+					fmt.Printf("%s[%s]: info=results status='COMPRESSED SIZE (GZIP) [%v (%v) => %v (%v)]'\n",
+						appName, cmdName,
+						cmdReport.SourceImageBlobSizes.GzipSize,
+						humanize.Bytes(cmdReport.SourceImageBlobSizes.GzipSize),
+						cmdReport.MinifiedImageBlobSizes.GzipSize,
+						humanize.Bytes(cmdReport.MinifiedImageBlobSizes.GzipSize))
+					fmt.Printf("%s[%s]: info=results status='COMPRESSED SIZE (ZSTD) [%v (%v) => %v (%v)]'\n",
+						appName, cmdName,
+						cmdReport.SourceImageBlobSizes.ZstdSize,
+						humanize.Bytes(cmdReport.SourceImageBlobSizes.ZstdSize),
+						cmdReport.MinifiedImageBlobSizes.ZstdSize,
+						humanize.Bytes(cmdReport.MinifiedImageBlobSizes.ZstdSize))
+				} else {
+					fmt.Printf("%s[%s]: info=image.blob.size.error message='%v'\n", appName, cmdName, err)
+				}
+
+				fmt.Printf("%s[%s]: state=image.blob.size.done\n", appName, cmdName)
+			}
+		} else {
+			cmdReport.State = command.StateError
+			cmdReport.Error = err.Error()
 		}
 
-		cmdReport.MinifiedImageSize = newImageInspector.ImageInfo.VirtualSize
-		cmdReport.MinifiedImageSizeHuman = humanize.Bytes(uint64(newImageInspector.ImageInfo.VirtualSize))
+		cmdReport.MinifiedImage = builder.RepoName
+		cmdReport.MinifiedImageHasData = builder.HasData
 
-		fmt.Printf("%s[%s]: info=results status='MINIFIED BY %.2fX [%v (%v) => %v (%v)]'\n",
+		fmt.Printf("%s[%s]: info=results  image.name=%v image.size='%v' data=%v\n",
 			appName, cmdName,
-			cmdReport.MinifiedBy,
-			cmdReport.SourceImage.Size,
-			cmdReport.SourceImage.SizeHuman,
-			cmdReport.MinifiedImageSize,
-			cmdReport.MinifiedImageSizeHuman)
-	} else {
-		cmdReport.State = command.StateError
-		cmdReport.Error = err.Error()
+			cmdReport.MinifiedImage,
+			cmdReport.MinifiedImageSizeHuman,
+			cmdReport.MinifiedImageHasData)
+	}
+
+	if doFilesAudit {
+		fmt.Printf("%s[%s]: state=files.audit.start\n", appName, cmdName)
+
+		audit, err := auditFiles(client, imageInspector.ImageInfo.ID, localVolumePath, artifactLocation)
+		if err == nil {
+			cmdReport.FilesAuditName = FilesAuditFileName
+			fmt.Printf("%s[%s]: info=files.audit removed.count=%v removed.size.human='%v' retained.count=%v retained.size.human='%v'\n",
+				appName, cmdName,
+				audit.RemovedCount,
+				humanize.Bytes(audit.RemovedSize),
+				audit.RetainedCount,
+				humanize.Bytes(audit.RetainedSize))
+		} else {
+			fmt.Printf("%s[%s]: info=files.audit.error message='%v'\n", appName, cmdName, err)
+		}
+
+		fmt.Printf("%s[%s]: state=files.audit.done\n", appName, cmdName)
 	}
 
-	cmdReport.MinifiedImage = builder.RepoName
-	cmdReport.MinifiedImageHasData = builder.HasData
 	cmdReport.ArtifactLocation = imageInspector.ArtifactLocation
 	cmdReport.ContainerReportName = report.DefaultContainerReportFileName
 	cmdReport.SeccompProfileName = imageInspector.SeccompProfileName
 	cmdReport.AppArmorProfileName = imageInspector.AppArmorProfileName
 
-	fmt.Printf("%s[%s]: info=results  image.name=%v image.size='%v' data=%v\n",
-		appName, cmdName,
-		cmdReport.MinifiedImage,
-		cmdReport.MinifiedImageSizeHuman,
-		cmdReport.MinifiedImageHasData)
-
 	fmt.Printf("%s[%s]: info=results  artifacts.location='%v'\n", appName, cmdName, cmdReport.ArtifactLocation)
 	fmt.Printf("%s[%s]: info=results  artifacts.report=%v\n", appName, cmdName, cmdReport.ContainerReportName)
 	fmt.Printf("%s[%s]: info=results  artifacts.dockerfile.original=Dockerfile.fat\n", appName, cmdName)
 	fmt.Printf("%s[%s]: info=results  artifacts.dockerfile.new=Dockerfile\n", appName, cmdName)
 	fmt.Printf("%s[%s]: info=results  artifacts.seccomp=%v\n", appName, cmdName, cmdReport.SeccompProfileName)
 	fmt.Printf("%s[%s]: info=results  artifacts.apparmor=%v\n", appName, cmdName, cmdReport.AppArmorProfileName)
+	if cmdReport.FilesAuditName != "" {
+		fmt.Printf("%s[%s]: info=results  artifacts.files_audit=%v\n", appName, cmdName, cmdReport.FilesAuditName)
+	}
 
 	if cmdReport.ArtifactLocation != "" {
 		creportPath := filepath.Join(cmdReport.ArtifactLocation, cmdReport.ContainerReportName)
@@ -552,3 +615,73 @@ func OnCommand(
 	}
 
 }
+
+// saveOutput saves the minified filesystem and the generated Dockerfile
+// to the output directory or tar file (instead of building the minified image)
+func saveOutput(builder *builder.ImageBuilder,
+	outputDir string,
+	outputTar string,
+	appName string,
+	cmdName command.Type,
+	cmdReport *report.BuildCommand) {
+	fmt.Printf("%s[%s]: state=output.saving\n", appName, cmdName)
+
+	output := outputDir
+	if outputTar != "" {
+		output = outputTar
+	}
+
+	var (
+		result *dockerimage.ExtractResult
+		err    error
+	)
+
+	output, err = filepath.Abs(output)
+	errutil.FailOn(err)
+
+	if outputTar != "" {
+		result, err = builder.SaveOutputTar(output)
+	} else {
+		result, err = builder.SaveOutputDir(output)
+	}
+
+	if err != nil {
+		fmt.Printf("%s[%s]: info=output.save.error location='%s' value='%v'\n", appName, cmdName, output, err)
+		fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+		commands.Exit(commands.ECTBuild | ecbOutputSaveError)
+	}
+
+	fmt.Printf("%s[%s]: state=completed\n", appName, cmdName)
+	cmdReport.State = command.StateCompleted
+	cmdReport.OutputLocation = output
+	cmdReport.OutputResult = result
+	cmdReport.MinifiedImageHasData = builder.HasData
+
+	fmt.Printf("%s[%s]: info=results  output.location='%s' objects=%v size.human='%v' size.bytes=%v data=%v\n",
+		appName, cmdName,
+		output,
+		result.Objects,
+		humanize.Bytes(result.Size),
+		result.Size,
+		builder.HasData)
+}
+
+// imageBlobSizes saves the image to a temporary archive to calculate its compressed layer sizes
+func imageBlobSizes(client *docker.Client, imageID string, localVolumePath string) (*dockerimage.BlobSizes, error) {
+	imageID = dockerutil.CleanImageID(imageID)
+	archivePath := filepath.Join(localVolumePath, "image", fmt.Sprintf("%s.blobs.tar", imageID))
+	if err := dockerutil.SaveImage(client, imageID, archivePath, false, false); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		errutil.WarnOn(fsutil.Remove(archivePath))
+	}()
+
+	pkg, err := dockerimage.LoadPackage(archivePath, imageID, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return pkg.CalculateBlobSizes(archivePath)
+}
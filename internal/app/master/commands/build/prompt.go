@@ -54,17 +54,17 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(commands.FlagNetwork), Description: commands.FlagNetworkUsage},
 		{Text: commands.FullFlagName(commands.FlagHostname), Description: commands.FlagHostnameUsage},
 		{Text: commands.FullFlagName(commands.FlagExpose), Description: commands.FlagExposeUsage},
-		{Text: commands.FullFlagName(FlagNewEntrypoint), Description: FlagNewEntrypointUsage},
-		{Text: commands.FullFlagName(FlagNewCmd), Description: FlagNewCmdUsage},
-		{Text: commands.FullFlagName(FlagNewExpose), Description: FlagNewExposeUsage},
-		{Text: commands.FullFlagName(FlagNewWorkdir), Description: FlagNewWorkdirUsage},
-		{Text: commands.FullFlagName(FlagNewEnv), Description: FlagNewEnvUsage},
-		{Text: commands.FullFlagName(FlagNewVolume), Description: FlagNewVolumeUsage},
-		{Text: commands.FullFlagName(FlagNewLabel), Description: FlagNewLabelUsage},
-		{Text: commands.FullFlagName(FlagRemoveExpose), Description: FlagRemoveExposeUsage},
-		{Text: commands.FullFlagName(FlagRemoveEnv), Description: FlagRemoveEnvUsage},
-		{Text: commands.FullFlagName(FlagRemoveLabel), Description: FlagRemoveLabelUsage},
-		{Text: commands.FullFlagName(FlagRemoveVolume), Description: FlagRemoveVolumeUsage},
+		{Text: commands.FullFlagName(commands.FlagNewEntrypoint), Description: commands.FlagNewEntrypointUsage},
+		{Text: commands.FullFlagName(commands.FlagNewCmd), Description: commands.FlagNewCmdUsage},
+		{Text: commands.FullFlagName(commands.FlagNewExpose), Description: commands.FlagNewExposeUsage},
+		{Text: commands.FullFlagName(commands.FlagNewWorkdir), Description: commands.FlagNewWorkdirUsage},
+		{Text: commands.FullFlagName(commands.FlagNewEnv), Description: commands.FlagNewEnvUsage},
+		{Text: commands.FullFlagName(commands.FlagNewVolume), Description: commands.FlagNewVolumeUsage},
+		{Text: commands.FullFlagName(commands.FlagNewLabel), Description: commands.FlagNewLabelUsage},
+		{Text: commands.FullFlagName(commands.FlagRemoveExpose), Description: commands.FlagRemoveExposeUsage},
+		{Text: commands.FullFlagName(commands.FlagRemoveEnv), Description: commands.FlagRemoveEnvUsage},
+		{Text: commands.FullFlagName(commands.FlagRemoveLabel), Description: commands.FlagRemoveLabelUsage},
+		{Text: commands.FullFlagName(commands.FlagRemoveVolume), Description: commands.FlagRemoveVolumeUsage},
 		{Text: commands.FullFlagName(commands.FlagExcludeMounts), Description: commands.FlagExcludeMountsUsage},
 		{Text: commands.FullFlagName(commands.FlagExcludePattern), Description: commands.FlagExcludePatternUsage},
 		{Text: commands.FullFlagName(commands.FlagPathPerms), Description: commands.FlagPathPermsUsage},
@@ -81,6 +81,10 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(commands.FlagUseLocalMounts), Description: commands.FlagUseLocalMountsUsage},
 		{Text: commands.FullFlagName(commands.FlagUseSensorVolume), Description: commands.FlagUseSensorVolumeUsage},
 		{Text: commands.FullFlagName(commands.FlagKeepTmpArtifacts), Description: commands.FlagKeepTmpArtifactsUsage},
+		{Text: commands.FullFlagName(FlagCompressedSize), Description: FlagCompressedSizeUsage},
+		{Text: commands.FullFlagName(FlagFilesAudit), Description: FlagFilesAuditUsage},
+		{Text: commands.FullFlagName(FlagOutputDir), Description: FlagOutputDirUsage},
+		{Text: commands.FullFlagName(FlagOutputTar), Description: FlagOutputTarUsage},
 	},
 	Values: map[string]commands.CompleteValue{
 		commands.FullFlagName(commands.FlagTarget):                 commands.CompleteTarget,
@@ -107,5 +111,9 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		commands.FullFlagName(commands.FlagUseLocalMounts):         commands.CompleteBool,
 		commands.FullFlagName(commands.FlagUseSensorVolume):        commands.CompleteVolume,
 		commands.FullFlagName(commands.FlagKeepTmpArtifacts):       commands.CompleteBool,
+		commands.FullFlagName(FlagCompressedSize):                  commands.CompleteBool,
+		commands.FullFlagName(FlagFilesAudit):                      commands.CompleteTBool,
+		commands.FullFlagName(FlagOutputDir):                       commands.CompleteFile,
+		commands.FullFlagName(FlagOutputTar):                       commands.CompleteFile,
 	},
 }
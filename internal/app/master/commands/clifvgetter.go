@@ -12,6 +12,7 @@ import (
 	"github.com/docker-slim/docker-slim/internal/app/master/docker/dockerclient"
 	"github.com/docker-slim/docker-slim/internal/app/master/signals"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
@@ -165,3 +166,93 @@ func GetDockerClientConfig(ctx *cli.Context) *config.DockerClient {
 
 	return config
 }
+
+func GetImageInstructions(ctx *cli.Context) (*config.ImageNewInstructions, error) {
+	entrypoint := ctx.String(FlagNewEntrypoint)
+	cmd := ctx.String(FlagNewCmd)
+	expose := ctx.StringSlice(FlagNewExpose)
+	removeExpose := ctx.StringSlice(FlagRemoveExpose)
+
+	instructions := &config.ImageNewInstructions{
+		Workdir: ctx.String(FlagNewWorkdir),
+		Env:     ctx.StringSlice(FlagNewEnv),
+	}
+
+	volumes, err := ParseTokenSet(ctx.StringSlice(FlagNewVolume))
+	if err != nil {
+		fmt.Printf("getImageInstructions(): invalid new volume options %v\n", err)
+		return nil, err
+	}
+
+	instructions.Volumes = volumes
+
+	labels, err := ParseTokenMap(ctx.StringSlice(FlagNewLabel))
+	if err != nil {
+		fmt.Printf("getImageInstructions(): invalid new label options %v\n", err)
+		return nil, err
+	}
+
+	instructions.Labels = labels
+
+	removeLabels, err := ParseTokenSet(ctx.StringSlice(FlagRemoveLabel))
+	if err != nil {
+		fmt.Printf("getImageInstructions(): invalid remove label options %v\n", err)
+		return nil, err
+	}
+
+	instructions.RemoveLabels = removeLabels
+
+	removeEnvs, err := ParseTokenSet(ctx.StringSlice(FlagRemoveEnv))
+	if err != nil {
+		fmt.Printf("getImageInstructions(): invalid remove env options %v\n", err)
+		return nil, err
+	}
+
+	instructions.RemoveEnvs = removeEnvs
+
+	removeVolumes, err := ParseTokenSet(ctx.StringSlice(FlagRemoveVolume))
+	if err != nil {
+		fmt.Printf("getImageInstructions(): invalid remove volume options %v\n", err)
+		return nil, err
+	}
+
+	instructions.RemoveVolumes = removeVolumes
+
+	//TODO(future): also load instructions from a file
+
+	if len(expose) > 0 {
+		instructions.ExposedPorts, err = ParseDockerExposeOpt(expose)
+		if err != nil {
+			log.Errorf("getImageInstructions(): invalid expose options => %v", err)
+			return nil, err
+		}
+	}
+
+	if len(removeExpose) > 0 {
+		instructions.RemoveExposedPorts, err = ParseDockerExposeOpt(removeExpose)
+		if err != nil {
+			log.Errorf("getImageInstructions(): invalid remove-expose options => %v", err)
+			return nil, err
+		}
+	}
+
+	instructions.Entrypoint, err = ParseExec(entrypoint)
+	if err != nil {
+		log.Errorf("getImageInstructions(): invalid entrypoint option => %v", err)
+		return nil, err
+	}
+
+	//one space is a hacky way to indicate that you want to remove this instruction from the image
+	instructions.ClearEntrypoint = IsOneSpace(entrypoint)
+
+	instructions.Cmd, err = ParseExec(cmd)
+	if err != nil {
+		log.Errorf("getImageInstructions(): invalid cmd option => %v", err)
+		return nil, err
+	}
+
+	//same hack to indicate you want to remove this instruction
+	instructions.ClearCmd = IsOneSpace(cmd)
+
+	return instructions, nil
+}
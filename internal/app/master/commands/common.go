@@ -97,6 +97,19 @@ const (
 	FlagVolume     = "volume"
 	FlagExpose     = "expose"
 
+	//Flags to edit (modify, add and remove) image metadata
+	FlagNewEntrypoint = "new-entrypoint"
+	FlagNewCmd        = "new-cmd"
+	FlagNewLabel      = "new-label"
+	FlagNewVolume     = "new-volume"
+	FlagNewExpose     = "new-expose"
+	FlagNewWorkdir    = "new-workdir"
+	FlagNewEnv        = "new-env"
+	FlagRemoveVolume  = "remove-volume"
+	FlagRemoveExpose  = "remove-expose"
+	FlagRemoveEnv     = "remove-env"
+	FlagRemoveLabel   = "remove-label"
+
 	FlagLink    = "link"
 	FlagNetwork = "network"
 
@@ -160,6 +173,18 @@ const (
 	FlagVolumeUsage     = "Add VOLUME analyzing image at runtime"
 	FlagExposeUsage     = "Use additional EXPOSE instructions analyzing image at runtime"
 
+	FlagNewEntrypointUsage = "New ENTRYPOINT instruction for the generated image"
+	FlagNewCmdUsage        = "New CMD instruction for the generated image"
+	FlagNewVolumeUsage     = "New VOLUME instructions for the generated image"
+	FlagNewLabelUsage      = "New LABEL instructions for the generated image"
+	FlagNewExposeUsage     = "New EXPOSE instructions for the generated image"
+	FlagNewWorkdirUsage    = "New WORKDIR instruction for the generated image"
+	FlagNewEnvUsage        = "New ENV instructions for the generated image"
+	FlagRemoveExposeUsage  = "Remove EXPOSE instructions for the generated image"
+	FlagRemoveEnvUsage     = "Remove ENV instructions for the generated image"
+	FlagRemoveLabelUsage   = "Remove LABEL instructions for the generated image"
+	FlagRemoveVolumeUsage  = "Remove VOLUME instructions for the generated image"
+
 	FlagLinkUsage    = "Add link to another container analyzing image at runtime"
 	FlagNetworkUsage = "Override default container network settings analyzing image at runtime"
 
@@ -463,6 +488,72 @@ var CommonFlags = map[string]cli.Flag{
 		Usage:  FlagExposeUsage,
 		EnvVar: "DSLIM_RC_EXPOSE",
 	},
+	FlagNewEntrypoint: cli.StringFlag{
+		Name:   FlagNewEntrypoint,
+		Value:  "",
+		Usage:  FlagNewEntrypointUsage,
+		EnvVar: "DSLIM_NEW_ENTRYPOINT",
+	},
+	FlagNewCmd: cli.StringFlag{
+		Name:   FlagNewCmd,
+		Value:  "",
+		Usage:  FlagNewCmdUsage,
+		EnvVar: "DSLIM_NEW_CMD",
+	},
+	FlagNewExpose: cli.StringSliceFlag{
+		Name:   FlagNewExpose,
+		Value:  &cli.StringSlice{},
+		Usage:  FlagNewExposeUsage,
+		EnvVar: "DSLIM_NEW_EXPOSE",
+	},
+	FlagNewWorkdir: cli.StringFlag{
+		Name:   FlagNewWorkdir,
+		Value:  "",
+		Usage:  FlagNewWorkdirUsage,
+		EnvVar: "DSLIM_NEW_WORKDIR",
+	},
+	FlagNewEnv: cli.StringSliceFlag{
+		Name:   FlagNewEnv,
+		Value:  &cli.StringSlice{},
+		Usage:  FlagNewEnvUsage,
+		EnvVar: "DSLIM_NEW_ENV",
+	},
+	FlagNewVolume: cli.StringSliceFlag{
+		Name:   FlagNewVolume,
+		Value:  &cli.StringSlice{},
+		Usage:  FlagNewVolumeUsage,
+		EnvVar: "DSLIM_NEW_VOLUME",
+	},
+	FlagNewLabel: cli.StringSliceFlag{
+		Name:   FlagNewLabel,
+		Value:  &cli.StringSlice{},
+		Usage:  FlagNewLabelUsage,
+		EnvVar: "DSLIM_NEW_LABEL",
+	},
+	FlagRemoveExpose: cli.StringSliceFlag{
+		Name:   FlagRemoveExpose,
+		Value:  &cli.StringSlice{},
+		Usage:  FlagRemoveExposeUsage,
+		EnvVar: "DSLIM_RM_EXPOSE",
+	},
+	FlagRemoveEnv: cli.StringSliceFlag{
+		Name:   FlagRemoveEnv,
+		Value:  &cli.StringSlice{},
+		Usage:  FlagRemoveEnvUsage,
+		EnvVar: "DSLIM_RM_ENV",
+	},
+	FlagRemoveLabel: cli.StringSliceFlag{
+		Name:   FlagRemoveLabel,
+		Value:  &cli.StringSlice{},
+		Usage:  FlagRemoveLabelUsage,
+		EnvVar: "DSLIM_RM_LABEL",
+	},
+	FlagRemoveVolume: cli.StringSliceFlag{
+		Name:   FlagRemoveVolume,
+		Value:  &cli.StringSlice{},
+		Usage:  FlagRemoveVolumeUsage,
+		EnvVar: "DSLIM_RM_VOLUME",
+	},
 	FlagExcludeMounts: cli.BoolTFlag{
 		Name:   FlagExcludeMounts, //true by default
 		Usage:  FlagExcludeMountsUsage,
@@ -586,12 +677,17 @@ type ExecutionContext struct {
 
 // Exit Code Types
 const (
-	ECTCommon  = 0x01000000
-	ECTBuild   = 0x02000000
-	ectProfile = 0x03000000
-	ectInfo    = 0x04000000
-	ectUpdate  = 0x05000000
-	ectVersion = 0x06000000
+	ECTCommon       = 0x01000000
+	ECTBuild        = 0x02000000
+	ectProfile      = 0x03000000
+	ectInfo         = 0x04000000
+	ectUpdate       = 0x05000000
+	ectVersion      = 0x06000000
+	ECTContainerize = 0x07000000
+	ECTConvert      = 0x08000000
+	ECTEdit         = 0x09000000
+	ECTDiff         = 0x0A000000
+	ECTXray         = 0x0B000000
 )
 
 // Build command exit codes
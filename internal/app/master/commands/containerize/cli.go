@@ -18,12 +18,25 @@ var CLI = cli.Command{
 	Name:    Name,
 	Aliases: []string{Alias},
 	Usage:   Usage,
+	Flags: []cli.Flag{
+		cflag(commands.FlagTarget),
+		cflag(FlagTag),
+		cflag(FlagCmd),
+		cflag(FlagIncludePath),
+		cflag(FlagShowBuildLogs),
+	},
 	Action: func(ctx *cli.Context) error {
 		commands.ShowCommunityInfo()
-		if len(ctx.Args()) < 1 {
-			fmt.Printf("docker-slim[%s]: missing target info...\n\n", Name)
-			cli.ShowCommandHelp(ctx, Name)
-			return nil
+		targetRef := ctx.String(commands.FlagTarget)
+
+		if targetRef == "" {
+			if len(ctx.Args()) < 1 {
+				fmt.Printf("docker-slim[%s]: missing target info...\n\n", Name)
+				cli.ShowCommandHelp(ctx, Name)
+				return nil
+			} else {
+				targetRef = ctx.Args().First()
+			}
 		}
 
 		gcvalues, err := commands.GlobalCommandFlagValues(ctx)
@@ -31,13 +44,26 @@ var CLI = cli.Command{
 			return err
 		}
 
-		targetRef := ctx.Args().First()
+		imageTag := ctx.String(FlagTag)
+
+		appCmd, err := commands.ParseExec(ctx.String(FlagCmd))
+		if err != nil {
+			fmt.Printf("docker-slim[%s]: invalid cmd: %v\n", Name, err)
+			return err
+		}
+
+		includePaths := ctx.StringSlice(FlagIncludePath)
+		doShowBuildLogs := ctx.Bool(FlagShowBuildLogs)
 
 		ec := &commands.ExecutionContext{}
 
 		OnCommand(
 			gcvalues,
 			targetRef,
+			imageTag,
+			appCmd,
+			includePaths,
+			doShowBuildLogs,
 			ec)
 		commands.ShowCommunityInfo()
 		return nil
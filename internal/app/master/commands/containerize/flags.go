@@ -0,0 +1,66 @@
+package containerize
+
+import (
+	"github.com/docker-slim/docker-slim/internal/app/master/commands"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// Containerize command flag names
+const (
+	FlagTag           = "tag"
+	FlagCmd           = "cmd"
+	FlagIncludePath   = "include-path"
+	FlagShowBuildLogs = "show-blogs"
+)
+
+// Containerize command flag usage info
+const (
+	FlagContainerizeTargetUsage = "Target application executable (path or name in PATH)"
+	FlagTagUsage                = "Custom tag for the generated image"
+	FlagCmdUsage                = "Default arguments (CMD) for the application ENTRYPOINT"
+	FlagIncludePathUsage        = "Include extra local file or directory in the generated image"
+	FlagShowBuildLogsUsage      = "Show build logs"
+)
+
+var Flags = map[string]cli.Flag{
+	commands.FlagTarget: cli.StringFlag{
+		Name:   commands.FlagTarget,
+		Value:  "",
+		Usage:  FlagContainerizeTargetUsage,
+		EnvVar: "DSLIM_TARGET",
+	},
+	FlagTag: cli.StringFlag{
+		Name:   FlagTag,
+		Value:  "",
+		Usage:  FlagTagUsage,
+		EnvVar: "DSLIM_CONTAINERIZE_TAG",
+	},
+	FlagCmd: cli.StringFlag{
+		Name:   FlagCmd,
+		Value:  "",
+		Usage:  FlagCmdUsage,
+		EnvVar: "DSLIM_CONTAINERIZE_CMD",
+	},
+	FlagIncludePath: cli.StringSliceFlag{
+		Name:   FlagIncludePath,
+		Value:  &cli.StringSlice{},
+		Usage:  FlagIncludePathUsage,
+		EnvVar: "DSLIM_CONTAINERIZE_INCLUDE_PATH",
+	},
+	FlagShowBuildLogs: cli.BoolFlag{
+		Name:   FlagShowBuildLogs,
+		Usage:  FlagShowBuildLogsUsage,
+		EnvVar: "DSLIM_SHOW_BLOGS",
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
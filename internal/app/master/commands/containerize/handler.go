@@ -1,26 +1,51 @@
 package containerize
 
 import (
+	"crypto/sha1"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
 
+	"github.com/docker-slim/docker-slim/internal/app/master/builder"
 	"github.com/docker-slim/docker-slim/internal/app/master/commands"
 	"github.com/docker-slim/docker-slim/internal/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/internal/app/master/inspectors/container"
 	"github.com/docker-slim/docker-slim/internal/app/master/version"
+	"github.com/docker-slim/docker-slim/internal/app/sensor/inspectors/sodeps"
 	"github.com/docker-slim/docker-slim/pkg/command"
 	"github.com/docker-slim/docker-slim/pkg/report"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
 
+	"github.com/dustin/go-humanize"
+	"github.com/fsouza/go-dockerclient"
 	log "github.com/sirupsen/logrus"
 )
 
 const appName = commands.AppName
 
+// Containerize command exit codes
+const (
+	eccOther = iota + 1
+	eccBadTarget
+	eccTargetNotBinary
+	eccImageBuildError
+)
+
+const stateKeyPrefix = "containerize."
+
 // OnCommand implements the 'containerize' docker-slim command
 func OnCommand(
 	gparams *commands.GenericParams,
 	targetRef string,
+	imageTag string,
+	appCmd []string,
+	includePaths []string,
+	doShowBuildLogs bool,
 	ec *commands.ExecutionContext) {
 	const cmdName = command.Containerize
 	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
@@ -30,9 +55,10 @@ func OnCommand(
 
 	cmdReport := report.NewContainerizeCommand(gparams.ReportLocation, gparams.InContainer)
 	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = targetRef
 
 	fmt.Printf("%s[%s]: state=started\n", appName, cmdName)
-	fmt.Printf("%s[%s]: info=params target=%v\n", appName, cmdName, targetRef)
+	fmt.Printf("%s[%s]: info=params target=%v tag=%v\n", appName, cmdName, targetRef, imageTag)
 
 	client, err := dockerclient.New(gparams.ClientConfig)
 	if err == dockerclient.ErrNoDockerInfo {
@@ -50,9 +76,128 @@ func OnCommand(
 		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
 	}
 
+	exePath, err := resolveTargetPath(targetRef)
+	if err != nil {
+		fmt.Printf("%s[%s]: info=target.error message='bad target executable' error='%v'\n", appName, cmdName, err)
+		fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+		commands.Exit(commands.ECTContainerize | eccBadTarget)
+	}
+
+	cmdReport.TargetExecutable = exePath
+	fmt.Printf("%s[%s]: info=target.executable path='%s'\n", appName, cmdName, exePath)
+
+	deps, err := sodeps.AllExeDependencies(exePath, false)
+	switch {
+	case err == sodeps.ErrFileNotBin:
+		fmt.Printf("%s[%s]: info=target.error message='target is not a binary executable' path='%s'\n", appName, cmdName, exePath)
+		fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+		commands.Exit(commands.ECTContainerize | eccTargetNotBinary)
+	case err != nil:
+		//ldd fails for statically linked executables (and it might not be installed),
+		//so the target executable (and its link targets) is all we can include
+		logger.Debugf("sodeps.AllExeDependencies(%v) error - %v", exePath, err)
+		fmt.Printf("%s[%s]: info=deps.resolver message='no shared library dependencies resolved (static binary?)' error='%v'\n",
+			appName, cmdName, err)
+		deps = nil
+	}
+
+	if len(deps) == 0 {
+		deps = exeArtifacts(exePath)
+	}
+
+	fmt.Printf("%s[%s]: info=deps count=%d\n", appName, cmdName, len(deps))
+
+	stateKey := fmt.Sprintf("%s%x", stateKeyPrefix, sha1.Sum([]byte(exePath)))
+	_, artifactLocation, _, _ := fsutil.PrepareImageStateDirs(gparams.StatePath, stateKey)
+	cmdReport.ArtifactLocation = artifactLocation
+
+	filesLocation := filepath.Join(artifactLocation, container.FileArtifactsDirName)
+	cmdReport.Files = copyArtifacts(logger, deps, filesLocation)
+
+	for _, includePath := range includePaths {
+		if includePath == "" {
+			continue
+		}
+
+		fullPath, err := filepath.Abs(includePath)
+		if err != nil {
+			logger.Debugf("filepath.Abs(%v) error - %v", includePath, err)
+			continue
+		}
+
+		if !fsutil.Exists(fullPath) {
+			fmt.Printf("%s[%s]: info=include.path message='skipping missing path' path='%s'\n", appName, cmdName, fullPath)
+			continue
+		}
+
+		cmdReport.Files = append(cmdReport.Files, copyArtifacts(logger, pathArtifacts(logger, fullPath), filesLocation)...)
+	}
+
+	fmt.Printf("%s[%s]: info=artifacts count=%d location='%s'\n", appName, cmdName, len(cmdReport.Files), artifactLocation)
+
+	if imageTag == "" {
+		imageTag = defaultImageTag(exePath)
+	}
+
+	imageInfo := &docker.Image{
+		Config: &docker.Config{
+			Entrypoint: []string{exePath},
+			Cmd:        appCmd,
+		},
+	}
+
+	fmt.Printf("%s[%s]: state=building message='building application image'\n", appName, cmdName)
+
+	imageBuilder, err := builder.NewImageBuilder(client,
+		imageTag,
+		imageInfo,
+		artifactLocation,
+		doShowBuildLogs,
+		nil,
+		nil,
+		nil)
+	errutil.FailOn(err)
+
+	if !imageBuilder.HasData {
+		logger.Info("WARNING - no data artifacts")
+	}
+
+	err = imageBuilder.Build()
+
+	if doShowBuildLogs || err != nil {
+		fmt.Printf("%s[%s]: build logs (application image) ====================\n", appName, cmdName)
+		fmt.Println(imageBuilder.BuildLog.String())
+		fmt.Printf("%s[%s]: end of build logs (application image) =============\n", appName, cmdName)
+	}
+
+	if err != nil {
+		fmt.Printf("%s[%s]: info=build.error status=application.image.build.error value='%v'\n", appName, cmdName, err)
+		fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+		commands.Exit(commands.ECTContainerize | eccImageBuildError)
+	}
+
+	cmdReport.ImageName = imageBuilder.RepoName
+	cmdReport.Entrypoint = imageBuilder.Entrypoint
+	cmdReport.Cmd = imageBuilder.Cmd
+
+	if newImageInfo, err := client.InspectImage(imageBuilder.RepoName); err == nil {
+		cmdReport.ImageSize = newImageInfo.VirtualSize
+		cmdReport.ImageSizeHuman = humanize.Bytes(uint64(newImageInfo.VirtualSize))
+	} else {
+		errutil.WarnOn(err)
+	}
+
 	fmt.Printf("%s[%s]: state=completed\n", appName, cmdName)
 	cmdReport.State = command.StateCompleted
 
+	fmt.Printf("%s[%s]: info=results status='CONTAINERIZED' image.name=%s image.size='%s' entrypoint=%+v\n",
+		appName, cmdName,
+		cmdReport.ImageName,
+		cmdReport.ImageSizeHuman,
+		cmdReport.Entrypoint)
+
+	fmt.Printf("%s[%s]: info=results artifacts.location='%v'\n", appName, cmdName, cmdReport.ArtifactLocation)
+
 	fmt.Printf("%s[%s]: state=done\n", appName, cmdName)
 
 	vinfo := <-viChan
@@ -63,3 +208,131 @@ func OnCommand(
 		fmt.Printf("%s[%s]: info=report file='%s'\n", appName, cmdName, cmdReport.ReportLocation())
 	}
 }
+
+func resolveTargetPath(targetRef string) (string, error) {
+	exePath := targetRef
+	if !strings.Contains(targetRef, "/") {
+		//not a path, so it must be an executable name in PATH
+		var err error
+		exePath, err = exec.LookPath(targetRef)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	exePath, err := filepath.Abs(exePath)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(exePath)
+	if err != nil {
+		return "", err
+	}
+
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("not a regular file - %s", exePath)
+	}
+
+	return exePath, nil
+}
+
+// exeArtifacts returns the target executable path and the paths
+// of its symlink targets (if the target is a symlink)
+func exeArtifacts(exePath string) []string {
+	artifacts := []string{exePath}
+	current := exePath
+	for i := 0; i < 10 && fsutil.IsSymlink(current); i++ {
+		linkRef, err := os.Readlink(current)
+		if err != nil {
+			break
+		}
+
+		if !filepath.IsAbs(linkRef) {
+			linkRef = filepath.Join(filepath.Dir(current), linkRef)
+		}
+
+		artifacts = append(artifacts, linkRef)
+		current = linkRef
+	}
+
+	return artifacts
+}
+
+// pathArtifacts returns the file and symlink paths for the included path
+// (walking it if it's a directory)
+func pathArtifacts(logger *log.Entry, fullPath string) []string {
+	if !fsutil.IsDir(fullPath) || fsutil.IsSymlink(fullPath) {
+		return []string{fullPath}
+	}
+
+	var artifacts []string
+	err := filepath.Walk(fullPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			logger.Debugf("pathArtifacts: skipping %v - %v", path, err)
+			return nil
+		}
+
+		if info.Mode().IsRegular() || info.Mode()&os.ModeSymlink != 0 {
+			artifacts = append(artifacts, path)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		logger.Debugf("pathArtifacts: filepath.Walk(%v) error - %v", fullPath, err)
+	}
+
+	return artifacts
+}
+
+func copyArtifacts(logger *log.Entry, paths []string, filesLocation string) []*report.ArtifactProps {
+	var artifacts []*report.ArtifactProps
+	for _, srcPath := range paths {
+		info, err := os.Lstat(srcPath)
+		if err != nil {
+			logger.Debugf("copyArtifacts: skipping %v - %v", srcPath, err)
+			continue
+		}
+
+		dstPath := filepath.Join(filesLocation, srcPath)
+		if fsutil.Exists(dstPath) {
+			continue
+		}
+
+		if err := fsutil.CopyFile(true, srcPath, dstPath, true); err != nil {
+			logger.Debugf("copyArtifacts: fsutil.CopyFile(%v,%v) error - %v", srcPath, dstPath, err)
+			continue
+		}
+
+		props := &report.ArtifactProps{
+			FileType: report.FileArtifactType,
+			FilePath: srcPath,
+			Mode:     info.Mode(),
+			ModeText: info.Mode().String(),
+			FileSize: info.Size(),
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			props.FileType = report.SymlinkArtifactType
+			props.LinkRef, _ = os.Readlink(srcPath)
+		}
+
+		artifacts = append(artifacts, props)
+	}
+
+	return artifacts
+}
+
+var badTagChars = regexp.MustCompile("[^a-z0-9_.-]+")
+
+func defaultImageTag(exePath string) string {
+	name := badTagChars.ReplaceAllString(strings.ToLower(filepath.Base(exePath)), "-")
+	name = strings.Trim(name, ".-_")
+	if name == "" {
+		name = "app"
+	}
+
+	return fmt.Sprintf("%s.containerized", name)
+}
@@ -1,6 +1,8 @@
 package containerize
 
 import (
+	"github.com/docker-slim/docker-slim/internal/app/master/commands"
+
 	"github.com/c-bata/go-prompt"
 )
 
@@ -8,3 +10,18 @@ var CommandSuggestion = prompt.Suggest{
 	Text:        Name,
 	Description: Usage,
 }
+
+var CommandFlagSuggestions = &commands.FlagSuggestions{
+	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(commands.FlagTarget), Description: FlagContainerizeTargetUsage},
+		{Text: commands.FullFlagName(FlagTag), Description: FlagTagUsage},
+		{Text: commands.FullFlagName(FlagCmd), Description: FlagCmdUsage},
+		{Text: commands.FullFlagName(FlagIncludePath), Description: FlagIncludePathUsage},
+		{Text: commands.FullFlagName(FlagShowBuildLogs), Description: FlagShowBuildLogsUsage},
+	},
+	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(commands.FlagTarget): commands.CompleteFile,
+		commands.FullFlagName(FlagIncludePath):     commands.CompleteFile,
+		commands.FullFlagName(FlagShowBuildLogs):   commands.CompleteBool,
+	},
+}
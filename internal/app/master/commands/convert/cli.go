@@ -18,12 +18,24 @@ var CLI = cli.Command{
 	Name:    Name,
 	Aliases: []string{Alias},
 	Usage:   Usage,
+	Flags: []cli.Flag{
+		commands.Cflag(commands.FlagTarget),
+		cflag(FlagTo),
+		cflag(FlagOutput),
+		commands.Cflag(commands.FlagRemoveFileArtifacts),
+	},
 	Action: func(ctx *cli.Context) error {
 		commands.ShowCommunityInfo()
-		if len(ctx.Args()) < 1 {
-			fmt.Printf("docker-slim[%s]: missing target info...\n\n", Name)
-			cli.ShowCommandHelp(ctx, Name)
-			return nil
+		targetRef := ctx.String(commands.FlagTarget)
+
+		if targetRef == "" {
+			if len(ctx.Args()) < 1 {
+				fmt.Printf("docker-slim[%s]: missing image ID/name...\n\n", Name)
+				cli.ShowCommandHelp(ctx, Name)
+				return nil
+			} else {
+				targetRef = ctx.Args().First()
+			}
 		}
 
 		gcvalues, err := commands.GlobalCommandFlagValues(ctx)
@@ -31,13 +43,26 @@ var CLI = cli.Command{
 			return err
 		}
 
-		targetRef := ctx.Args().First()
+		outputFormat := ctx.String(FlagTo)
+		switch outputFormat {
+		case FormatOCILayout, FormatDockerArchive, FormatRootFS:
+		default:
+			fmt.Printf("docker-slim[%s]: unsupported output format: %s\n\n", Name, outputFormat)
+			cli.ShowCommandHelp(ctx, Name)
+			return nil
+		}
+
+		outputPath := ctx.String(FlagOutput)
+		doRmFileArtifacts := ctx.Bool(commands.FlagRemoveFileArtifacts)
 
 		ec := &commands.ExecutionContext{}
 
 		OnCommand(
 			gcvalues,
 			targetRef,
+			outputFormat,
+			outputPath,
+			doRmFileArtifacts,
 			ec)
 		commands.ShowCommunityInfo()
 		return nil
@@ -0,0 +1,49 @@
+package convert
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// Convert command flag names
+const (
+	FlagTo     = "to"
+	FlagOutput = "output"
+)
+
+// Convert command flag usage info
+const (
+	FlagToUsage     = "Target image format (values: oci-layout, docker-archive, rootfs)"
+	FlagOutputUsage = "Output location (directory for 'oci-layout', tar file for 'docker-archive' and 'rootfs')"
+)
+
+// Supported output formats
+const (
+	FormatOCILayout     = "oci-layout"
+	FormatDockerArchive = "docker-archive"
+	FormatRootFS        = "rootfs"
+)
+
+var Flags = map[string]cli.Flag{
+	FlagTo: cli.StringFlag{
+		Name:   FlagTo,
+		Value:  FormatOCILayout,
+		Usage:  FlagToUsage,
+		EnvVar: "DSLIM_CONVERT_TO",
+	},
+	FlagOutput: cli.StringFlag{
+		Name:   FlagOutput,
+		Value:  "",
+		Usage:  FlagOutputUsage,
+		EnvVar: "DSLIM_CONVERT_OUTPUT",
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
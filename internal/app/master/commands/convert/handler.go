@@ -2,25 +2,43 @@ package convert
 
 import (
 	"fmt"
+	"path/filepath"
+	"time"
 
 	"github.com/docker-slim/docker-slim/internal/app/master/commands"
 	"github.com/docker-slim/docker-slim/internal/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/internal/app/master/inspectors/image"
 	"github.com/docker-slim/docker-slim/internal/app/master/version"
 	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
 	"github.com/docker-slim/docker-slim/pkg/report"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
 
+	"github.com/dustin/go-humanize"
 	log "github.com/sirupsen/logrus"
 )
 
 const appName = commands.AppName
 
+// Convert command exit codes
+const (
+	ecvOther = iota + 1
+	ecvImageSaveError
+	ecvImageConvertError
+)
+
+const shortImageIDLen = 12
+
 // OnCommand implements the 'convert' docker-slim command
 func OnCommand(
 	gparams *commands.GenericParams,
 	targetRef string,
+	outputFormat string,
+	outputPath string,
+	doRmFileArtifacts bool,
 	ec *commands.ExecutionContext) {
 	const cmdName = command.Convert
 	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
@@ -30,9 +48,11 @@ func OnCommand(
 
 	cmdReport := report.NewConvertCommand(gparams.ReportLocation, gparams.InContainer)
 	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = targetRef
+	cmdReport.OutputFormat = outputFormat
 
 	fmt.Printf("%s[%s]: state=started\n", appName, cmdName)
-	fmt.Printf("%s[%s]: info=params target=%v\n", appName, cmdName, targetRef)
+	fmt.Printf("%s[%s]: info=params target=%v to=%v output='%v'\n", appName, cmdName, targetRef, outputFormat, outputPath)
 
 	client, err := dockerclient.New(gparams.ClientConfig)
 	if err == dockerclient.ErrNoDockerInfo {
@@ -50,6 +70,102 @@ func OnCommand(
 		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
 	}
 
+	imageInspector, err := image.NewInspector(client, targetRef)
+	errutil.FailOn(err)
+
+	if imageInspector.NoImage() {
+		fmt.Printf("%s[%s]: info=target.image.error status=not.found image='%v' message='make sure the target image already exists locally'\n", appName, cmdName, targetRef)
+		fmt.Printf("%s[%s]: state=exited\n", appName, cmdName)
+		return
+	}
+
+	err = imageInspector.Inspect()
+	errutil.FailOn(err)
+
+	cmdReport.SourceImage = report.ImageMetadata{
+		AllNames:      imageInspector.ImageRecordInfo.RepoTags,
+		ID:            imageInspector.ImageRecordInfo.ID,
+		Size:          imageInspector.ImageInfo.VirtualSize,
+		SizeHuman:     humanize.Bytes(uint64(imageInspector.ImageInfo.VirtualSize)),
+		CreateTime:    imageInspector.ImageInfo.Created.UTC().Format(time.RFC3339),
+		Author:        imageInspector.ImageInfo.Author,
+		DockerVersion: imageInspector.ImageInfo.DockerVersion,
+		Architecture:  imageInspector.ImageInfo.Architecture,
+		User:          imageInspector.ImageInfo.Config.User,
+	}
+
+	if len(imageInspector.ImageRecordInfo.RepoTags) > 0 {
+		cmdReport.SourceImage.Name = imageInspector.ImageRecordInfo.RepoTags[0]
+	}
+
+	imageID := dockerutil.CleanImageID(imageInspector.ImageInfo.ID)
+	if outputPath == "" {
+		outputPath = defaultOutputPath(imageID, outputFormat)
+	}
+
+	outputPath, err = filepath.Abs(outputPath)
+	errutil.FailOn(err)
+	cmdReport.OutputLocation = outputPath
+
+	fmt.Printf("%s[%s]: info=image id=%v size.human='%v'\n",
+		appName, cmdName,
+		imageInspector.ImageInfo.ID,
+		cmdReport.SourceImage.SizeHuman)
+
+	fmt.Printf("%s[%s]: state=image.conversion.start\n", appName, cmdName)
+
+	switch outputFormat {
+	case FormatDockerArchive:
+		//saving using the original reference to preserve the repo tags in the archive
+		err = dockerutil.SaveImage(client, targetRef, outputPath, false, false)
+		if err != nil {
+			fmt.Printf("%s[%s]: info=image.save.error value='%v'\n", appName, cmdName, err)
+			fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+			commands.Exit(commands.ECTConvert | ecvImageSaveError)
+		}
+	default:
+		localVolumePath, artifactLocation, statePath, stateKey := fsutil.PrepareImageStateDirs(gparams.StatePath, imageInspector.ImageInfo.ID)
+		logger.Debugf("localVolumePath=%v, artifactLocation=%v, statePath=%v, stateKey=%v", localVolumePath, artifactLocation, statePath, stateKey)
+
+		iaName := fmt.Sprintf("%s.tar", imageID)
+		iaPath := filepath.Join(localVolumePath, "image", iaName)
+		err = dockerutil.SaveImage(client, imageID, iaPath, false, false)
+		if err != nil {
+			fmt.Printf("%s[%s]: info=image.save.error value='%v'\n", appName, cmdName, err)
+			fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+			commands.Exit(commands.ECTConvert | ecvImageSaveError)
+		}
+
+		switch outputFormat {
+		case FormatOCILayout:
+			var ociManifest *dockerimage.OCIManifest
+			ociManifest, err = dockerimage.SaveOCILayout(iaPath, imageID, outputPath, cmdReport.SourceImage.Name)
+			if err == nil {
+				fmt.Printf("%s[%s]: info=oci.layout config='%s' layers=%d\n",
+					appName, cmdName, ociManifest.Config.Digest, len(ociManifest.Layers))
+			}
+		case FormatRootFS:
+			err = dockerimage.SaveRootFS(iaPath, imageID, outputPath)
+		}
+
+		if err != nil {
+			fmt.Printf("%s[%s]: info=image.convert.error value='%v'\n", appName, cmdName, err)
+			fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+			commands.Exit(commands.ECTConvert | ecvImageConvertError)
+		}
+
+		if doRmFileArtifacts {
+			logger.Info("removing temporary artifacts...")
+			err = fsutil.Remove(iaPath)
+			errutil.WarnOn(err)
+		} else {
+			cmdReport.ImageArchiveLocation = iaPath
+		}
+	}
+
+	fmt.Printf("%s[%s]: state=image.conversion.done\n", appName, cmdName)
+	fmt.Printf("%s[%s]: info=results format=%s output='%s'\n", appName, cmdName, outputFormat, outputPath)
+
 	fmt.Printf("%s[%s]: state=completed\n", appName, cmdName)
 	cmdReport.State = command.StateCompleted
 
@@ -63,3 +179,18 @@ func OnCommand(
 		fmt.Printf("%s[%s]: info=report file='%s'\n", appName, cmdName, cmdReport.ReportLocation())
 	}
 }
+
+func defaultOutputPath(imageID, outputFormat string) string {
+	if len(imageID) > shortImageIDLen {
+		imageID = imageID[:shortImageIDLen]
+	}
+
+	switch outputFormat {
+	case FormatOCILayout:
+		return fmt.Sprintf("image-%s.oci", imageID)
+	case FormatRootFS:
+		return fmt.Sprintf("image-%s.rootfs.tar", imageID)
+	default:
+		return fmt.Sprintf("image-%s.tar", imageID)
+	}
+}
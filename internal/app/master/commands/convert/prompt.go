@@ -1,6 +1,8 @@
 package convert
 
 import (
+	"github.com/docker-slim/docker-slim/internal/app/master/commands"
+
 	"github.com/c-bata/go-prompt"
 )
 
@@ -8,3 +10,28 @@ var CommandSuggestion = prompt.Suggest{
 	Text:        Name,
 	Description: Usage,
 }
+
+var CommandFlagSuggestions = &commands.FlagSuggestions{
+	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(commands.FlagTarget), Description: commands.FlagTargetUsage},
+		{Text: commands.FullFlagName(FlagTo), Description: FlagToUsage},
+		{Text: commands.FullFlagName(FlagOutput), Description: FlagOutputUsage},
+		{Text: commands.FullFlagName(commands.FlagRemoveFileArtifacts), Description: commands.FlagRemoveFileArtifactsUsage},
+	},
+	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(commands.FlagTarget):              commands.CompleteTarget,
+		commands.FullFlagName(FlagTo):                           completeOutputFormat,
+		commands.FullFlagName(FlagOutput):                       commands.CompleteFile,
+		commands.FullFlagName(commands.FlagRemoveFileArtifacts): commands.CompleteBool,
+	},
+}
+
+var outputFormatValues = []prompt.Suggest{
+	{Text: FormatOCILayout, Description: "OCI image layout directory"},
+	{Text: FormatDockerArchive, Description: "Docker image archive ('docker save' format)"},
+	{Text: FormatRootFS, Description: "Flattened root filesystem tarball"},
+}
+
+func completeOutputFormat(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	return prompt.FilterHasPrefix(outputFormatValues, token, true)
+}
@@ -0,0 +1,69 @@
+package diff
+
+import (
+	"fmt"
+
+	"github.com/docker-slim/docker-slim/internal/app/master/commands"
+
+	"github.com/urfave/cli"
+)
+
+var (
+	Name  = "diff"
+	Usage = "Show what changed between two container images (e.g., original and minified)"
+	Alias = "d"
+)
+
+var CLI = cli.Command{
+	Name:    Name,
+	Aliases: []string{Alias},
+	Usage:   Usage,
+	Flags: []cli.Flag{
+		cflag(FlagFrom),
+		cflag(FlagTo),
+		cflag(FlagShowFiles),
+		cflag(FlagOutputFile),
+		commands.Cflag(commands.FlagRemoveFileArtifacts),
+	},
+	Action: func(ctx *cli.Context) error {
+		commands.ShowCommunityInfo()
+		fromRef := ctx.String(FlagFrom)
+		toRef := ctx.String(FlagTo)
+
+		if fromRef == "" && len(ctx.Args()) > 0 {
+			fromRef = ctx.Args().Get(0)
+		}
+
+		if toRef == "" && len(ctx.Args()) > 1 {
+			toRef = ctx.Args().Get(1)
+		}
+
+		if fromRef == "" || toRef == "" {
+			fmt.Printf("docker-slim[%s]: missing image ID/name (need both 'from' and 'to' images)...\n\n", Name)
+			cli.ShowCommandHelp(ctx, Name)
+			return nil
+		}
+
+		gcvalues, err := commands.GlobalCommandFlagValues(ctx)
+		if err != nil {
+			return err
+		}
+
+		doShowFiles := ctx.Bool(FlagShowFiles)
+		outputFile := ctx.String(FlagOutputFile)
+		doRmFileArtifacts := ctx.Bool(commands.FlagRemoveFileArtifacts)
+
+		ec := &commands.ExecutionContext{}
+
+		OnCommand(
+			gcvalues,
+			fromRef,
+			toRef,
+			doShowFiles,
+			outputFile,
+			doRmFileArtifacts,
+			ec)
+		commands.ShowCommunityInfo()
+		return nil
+	},
+}
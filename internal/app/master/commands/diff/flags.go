@@ -0,0 +1,57 @@
+package diff
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// Diff command flag names
+const (
+	FlagFrom       = "from"
+	FlagTo         = "to"
+	FlagShowFiles  = "show-files"
+	FlagOutputFile = "output-file"
+)
+
+// Diff command flag usage info
+const (
+	FlagFromUsage       = "Original ('fat') image ID/name"
+	FlagToUsage         = "Changed (e.g., minified) image ID/name"
+	FlagShowFilesUsage  = "Show the removed and added files in the console output"
+	FlagOutputFileUsage = "Save the image diff to a JSON file"
+)
+
+var Flags = map[string]cli.Flag{
+	FlagFrom: cli.StringFlag{
+		Name:   FlagFrom,
+		Value:  "",
+		Usage:  FlagFromUsage,
+		EnvVar: "DSLIM_DIFF_FROM",
+	},
+	FlagTo: cli.StringFlag{
+		Name:   FlagTo,
+		Value:  "",
+		Usage:  FlagToUsage,
+		EnvVar: "DSLIM_DIFF_TO",
+	},
+	FlagShowFiles: cli.BoolFlag{
+		Name:   FlagShowFiles,
+		Usage:  FlagShowFilesUsage,
+		EnvVar: "DSLIM_DIFF_SHOW_FILES",
+	},
+	FlagOutputFile: cli.StringFlag{
+		Name:   FlagOutputFile,
+		Value:  "",
+		Usage:  FlagOutputFileUsage,
+		EnvVar: "DSLIM_DIFF_OUTPUT_FILE",
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
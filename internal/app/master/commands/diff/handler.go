@@ -0,0 +1,323 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/docker-slim/docker-slim/internal/app/master/commands"
+	"github.com/docker-slim/docker-slim/internal/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/internal/app/master/inspectors/image"
+	"github.com/docker-slim/docker-slim/internal/app/master/version"
+	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/report"
+	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fsouza/go-dockerclient"
+	log "github.com/sirupsen/logrus"
+)
+
+const appName = commands.AppName
+
+// Diff command exit codes
+const (
+	ecdOther = iota + 1
+	ecdImageSaveError
+	ecdImageLoadError
+	ecdOutputSaveError
+)
+
+// OnCommand implements the 'diff' docker-slim command
+func OnCommand(
+	gparams *commands.GenericParams,
+	fromRef string,
+	toRef string,
+	doShowFiles bool,
+	outputFile string,
+	doRmFileArtifacts bool,
+	ec *commands.ExecutionContext) {
+	const cmdName = command.Diff
+	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
+	prefix := fmt.Sprintf("%s[%s]:", appName, cmdName)
+
+	viChan := version.CheckAsync(gparams.CheckVersion, gparams.InContainer, gparams.IsDSImage)
+
+	cmdReport := report.NewDiffCommand(gparams.ReportLocation, gparams.InContainer)
+	cmdReport.State = command.StateStarted
+	cmdReport.FromReference = fromRef
+	cmdReport.ToReference = toRef
+
+	fmt.Printf("%s[%s]: state=started\n", appName, cmdName)
+	fmt.Printf("%s[%s]: info=params from=%v to=%v show-files=%v output-file='%v'\n",
+		appName, cmdName, fromRef, toRef, doShowFiles, outputFile)
+
+	client, err := dockerclient.New(gparams.ClientConfig)
+	if err == dockerclient.ErrNoDockerInfo {
+		exitMsg := "missing Docker connection info"
+		if gparams.InContainer && gparams.IsDSImage {
+			exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+		}
+		fmt.Printf("%s[%s]: info=docker.connect.error message='%s'\n", appName, cmdName, exitMsg)
+		fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+		commands.Exit(commands.ECTCommon | commands.ECNoDockerConnectInfo)
+	}
+	errutil.FailOn(err)
+
+	if gparams.Debug {
+		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
+	}
+
+	fmt.Printf("%s[%s]: state=image.data.inspection.start\n", appName, cmdName)
+
+	fromImage := loadImage(gparams, client, cmdName, "from", fromRef)
+	if fromImage == nil {
+		return
+	}
+
+	toImage := loadImage(gparams, client, cmdName, "to", toRef)
+	if toImage == nil {
+		return
+	}
+
+	cmdReport.FromImage = fromImage.Metadata
+	cmdReport.ToImage = toImage.Metadata
+
+	fmt.Printf("%s[%s]: state=image.data.inspection.done\n", appName, cmdName)
+
+	imageDiff := dockerimage.DiffImages(fromImage.Source, toImage.Source)
+	cmdReport.Diff = imageDiff
+
+	printImageDiff(appName, cmdName, imageDiff, doShowFiles)
+
+	if outputFile != "" {
+		outputFile, err = filepath.Abs(outputFile)
+		errutil.FailOn(err)
+
+		if err := saveImageDiff(outputFile, imageDiff); err != nil {
+			fmt.Printf("%s[%s]: info=output.save.error file='%s' value='%v'\n", appName, cmdName, outputFile, err)
+			fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+			commands.Exit(commands.ECTDiff | ecdOutputSaveError)
+		}
+
+		cmdReport.OutputLocation = outputFile
+		fmt.Printf("%s[%s]: info=output file='%s'\n", appName, cmdName, outputFile)
+	}
+
+	fmt.Printf("%s[%s]: state=completed\n", appName, cmdName)
+	cmdReport.State = command.StateCompleted
+
+	if doRmFileArtifacts {
+		logger.Info("removing temporary artifacts...")
+		errutil.WarnOn(fsutil.Remove(fromImage.ArchivePath))
+		errutil.WarnOn(fsutil.Remove(toImage.ArchivePath))
+	} else {
+		cmdReport.FromImageArchiveLocation = fromImage.ArchivePath
+		cmdReport.ToImageArchiveLocation = toImage.ArchivePath
+	}
+
+	fmt.Printf("%s[%s]: state=done\n", appName, cmdName)
+
+	vinfo := <-viChan
+	version.PrintCheckVersion(prefix, vinfo)
+
+	cmdReport.State = command.StateDone
+	if cmdReport.Save() {
+		fmt.Printf("%s[%s]: info=report file='%s'\n", appName, cmdName, cmdReport.ReportLocation())
+	}
+}
+
+type imageData struct {
+	Metadata    report.ImageMetadata
+	ArchivePath string
+	Source      *dockerimage.ImageDiffSource
+}
+
+// loadImage saves the target image and loads its data (returns nil if the image doesn't exist)
+func loadImage(gparams *commands.GenericParams,
+	client *docker.Client,
+	cmdName command.Type,
+	side string,
+	targetRef string) *imageData {
+	imageInspector, err := image.NewInspector(client, targetRef)
+	errutil.FailOn(err)
+
+	if imageInspector.NoImage() {
+		fmt.Printf("%s[%s]: info=target.image.error status=not.found image='%v' message='make sure the target image already exists locally'\n", appName, cmdName, targetRef)
+		fmt.Printf("%s[%s]: state=exited\n", appName, cmdName)
+		return nil
+	}
+
+	err = imageInspector.Inspect()
+	errutil.FailOn(err)
+
+	data := &imageData{
+		Metadata: report.ImageMetadata{
+			AllNames:      imageInspector.ImageRecordInfo.RepoTags,
+			ID:            imageInspector.ImageRecordInfo.ID,
+			Size:          imageInspector.ImageInfo.VirtualSize,
+			SizeHuman:     humanize.Bytes(uint64(imageInspector.ImageInfo.VirtualSize)),
+			CreateTime:    imageInspector.ImageInfo.Created.UTC().Format(time.RFC3339),
+			Author:        imageInspector.ImageInfo.Author,
+			DockerVersion: imageInspector.ImageInfo.DockerVersion,
+			Architecture:  imageInspector.ImageInfo.Architecture,
+			User:          imageInspector.ImageInfo.Config.User,
+		},
+	}
+
+	if len(imageInspector.ImageRecordInfo.RepoTags) > 0 {
+		data.Metadata.Name = imageInspector.ImageRecordInfo.RepoTags[0]
+	}
+
+	fmt.Printf("%s[%s]: info=image.%s id=%v size.bytes=%v size.human='%v'\n",
+		appName, cmdName, side,
+		imageInspector.ImageInfo.ID,
+		imageInspector.ImageInfo.VirtualSize,
+		data.Metadata.SizeHuman)
+
+	localVolumePath, artifactLocation, statePath, stateKey := fsutil.PrepareImageStateDirs(gparams.StatePath, imageInspector.ImageInfo.ID)
+	log.Debugf("diff.loadImage(%s): localVolumePath=%v, artifactLocation=%v, statePath=%v, stateKey=%v",
+		side, localVolumePath, artifactLocation, statePath, stateKey)
+
+	imageID := dockerutil.CleanImageID(imageInspector.ImageInfo.ID)
+	iaName := fmt.Sprintf("%s.tar", imageID)
+	data.ArchivePath = filepath.Join(localVolumePath, "image", iaName)
+	err = dockerutil.SaveImage(client, imageID, data.ArchivePath, false, false)
+	if err != nil {
+		fmt.Printf("%s[%s]: info=image.save.error image='%v' value='%v'\n", appName, cmdName, targetRef, err)
+		fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+		commands.Exit(commands.ECTDiff | ecdImageSaveError)
+	}
+
+	imagePkg, err := dockerimage.LoadPackage(data.ArchivePath, imageID, false, false)
+	if err == nil {
+		data.Source, err = dockerimage.NewImageDiffSource(data.ArchivePath, imagePkg)
+	}
+
+	if err != nil {
+		fmt.Printf("%s[%s]: info=image.load.error image='%v' value='%v'\n", appName, cmdName, targetRef, err)
+		fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+		commands.Exit(commands.ECTDiff | ecdImageLoadError)
+	}
+
+	return data
+}
+
+func printImageDiff(appName string,
+	cmdName command.Type,
+	imageDiff *dockerimage.ImageDiff,
+	doShowFiles bool) {
+	printObjectsSummary(appName, cmdName, "files", &imageDiff.Files)
+	printObjectsSummary(appName, cmdName, "binaries", &imageDiff.Binaries)
+
+	for _, object := range imageDiff.Binaries.Removed {
+		fmt.Printf("%s[%s]: info=binary change=%s size.human='%v' '%s'\n",
+			appName, cmdName, dockerimage.DiffRemoved, humanize.Bytes(uint64(object.Size)), object.Name)
+	}
+
+	for _, object := range imageDiff.Binaries.Added {
+		fmt.Printf("%s[%s]: info=binary change=%s size.human='%v' '%s'\n",
+			appName, cmdName, dockerimage.DiffAdded, humanize.Bytes(uint64(object.Size)), object.Name)
+	}
+
+	fmt.Printf("%s[%s]: info=packages.summary removed=%d partial=%d kept=%d added=%d\n",
+		appName, cmdName,
+		len(imageDiff.Packages.Removed),
+		len(imageDiff.Packages.Partial),
+		len(imageDiff.Packages.Kept),
+		len(imageDiff.Packages.Added))
+
+	printPackages(appName, cmdName, dockerimage.DiffRemoved, imageDiff.Packages.Removed)
+	printPackages(appName, cmdName, dockerimage.DiffPartial, imageDiff.Packages.Partial)
+	printPackages(appName, cmdName, dockerimage.DiffAdded, imageDiff.Packages.Added)
+
+	fmt.Printf("%s[%s]: info=config.summary changes=%d\n", appName, cmdName, len(imageDiff.Config))
+	for _, change := range imageDiff.Config {
+		fmt.Printf("%s[%s]: info=config.change field=%s key='%s' change=%s from='%s' to='%s'\n",
+			appName, cmdName, change.Field, change.Key, change.Change, change.From, change.To)
+	}
+
+	if doShowFiles {
+		printObjects(dockerimage.DiffRemoved, imageDiff.Files.Removed)
+		printObjects(dockerimage.DiffAdded, imageDiff.Files.Added)
+
+		var modified []*dockerimage.DiffObject
+		for _, object := range imageDiff.Files.Kept {
+			if object.Modified {
+				modified = append(modified, object)
+			}
+		}
+
+		printObjects(dockerimage.DiffModified, modified)
+	}
+}
+
+func printObjectsSummary(appName string, cmdName command.Type, name string, objects *dockerimage.ObjectsDiff) {
+	fmt.Printf("%s[%s]: info=%s.summary removed=%d removed_size.human='%v' kept=%d kept_size.human='%v' modified=%d added=%d added_size.human='%v'\n",
+		appName, cmdName, name,
+		objects.Summary.RemovedCount,
+		humanize.Bytes(uint64(objects.Summary.RemovedSize)),
+		objects.Summary.KeptCount,
+		humanize.Bytes(uint64(objects.Summary.KeptSize)),
+		objects.Summary.ModifiedCount,
+		objects.Summary.AddedCount,
+		humanize.Bytes(uint64(objects.Summary.AddedSize)))
+}
+
+func printPackages(appName string, cmdName command.Type, change string, packages []*dockerimage.PackageChange) {
+	for _, pkg := range packages {
+		version := pkg.Version
+		if pkg.ToVersion != "" {
+			version = fmt.Sprintf("%s -> %s", pkg.Version, pkg.ToVersion)
+		}
+
+		fmt.Printf("%s[%s]: info=package change=%s type=%s name=%s version='%s' files=%d kept=%d removed_size.human='%v'\n",
+			appName, cmdName, change, pkg.Type, pkg.Name, version,
+			pkg.FileCount, pkg.KeptCount, humanize.Bytes(uint64(pkg.RemovedSize)))
+	}
+}
+
+func printObjects(change string, objects []*dockerimage.DiffObject) {
+	if len(objects) == 0 {
+		return
+	}
+
+	fmt.Printf("%s[%s]: info=files.%s:\n", appName, command.Diff, change)
+	for _, object := range objects {
+		fmt.Printf("%s: type=%s mode=%s size.human='%v' size.bytes=%d layer=%d '%s'",
+			change,
+			object.Type,
+			object.Mode,
+			humanize.Bytes(uint64(object.Size)),
+			object.Size,
+			object.LayerIndex,
+			object.Name)
+
+		if object.LinkTarget != "" {
+			fmt.Printf(" -> '%s'\n", object.LinkTarget)
+		} else {
+			fmt.Printf("\n")
+		}
+	}
+
+	fmt.Printf("\n")
+}
+
+func saveImageDiff(outputFile string, imageDiff *dockerimage.ImageDiff) error {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(imageDiff); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(outputFile, data.Bytes(), 0644)
+}
@@ -0,0 +1,29 @@
+package diff
+
+import (
+	"github.com/docker-slim/docker-slim/internal/app/master/commands"
+
+	"github.com/c-bata/go-prompt"
+)
+
+var CommandSuggestion = prompt.Suggest{
+	Text:        Name,
+	Description: Usage,
+}
+
+var CommandFlagSuggestions = &commands.FlagSuggestions{
+	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(FlagFrom), Description: FlagFromUsage},
+		{Text: commands.FullFlagName(FlagTo), Description: FlagToUsage},
+		{Text: commands.FullFlagName(FlagShowFiles), Description: FlagShowFilesUsage},
+		{Text: commands.FullFlagName(FlagOutputFile), Description: FlagOutputFileUsage},
+		{Text: commands.FullFlagName(commands.FlagRemoveFileArtifacts), Description: commands.FlagRemoveFileArtifactsUsage},
+	},
+	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(FlagFrom):                         commands.CompleteTarget,
+		commands.FullFlagName(FlagTo):                           commands.CompleteTarget,
+		commands.FullFlagName(FlagShowFiles):                    commands.CompleteBool,
+		commands.FullFlagName(FlagOutputFile):                   commands.CompleteFile,
+		commands.FullFlagName(commands.FlagRemoveFileArtifacts): commands.CompleteBool,
+	},
+}
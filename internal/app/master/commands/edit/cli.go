@@ -18,12 +18,36 @@ var CLI = cli.Command{
 	Name:    Name,
 	Aliases: []string{Alias},
 	Usage:   Usage,
+	Flags: []cli.Flag{
+		commands.Cflag(commands.FlagTarget),
+		cflag(FlagTag),
+		commands.Cflag(commands.FlagNewEntrypoint),
+		commands.Cflag(commands.FlagNewCmd),
+		commands.Cflag(commands.FlagNewExpose),
+		commands.Cflag(commands.FlagNewWorkdir),
+		commands.Cflag(commands.FlagNewEnv),
+		commands.Cflag(commands.FlagNewVolume),
+		commands.Cflag(commands.FlagNewLabel),
+		commands.Cflag(commands.FlagRemoveExpose),
+		commands.Cflag(commands.FlagRemoveEnv),
+		commands.Cflag(commands.FlagRemoveLabel),
+		commands.Cflag(commands.FlagRemoveVolume),
+		cflag(FlagAddFile),
+		cflag(FlagRemoveFile),
+		commands.Cflag(commands.FlagRemoveFileArtifacts),
+	},
 	Action: func(ctx *cli.Context) error {
 		commands.ShowCommunityInfo()
-		if len(ctx.Args()) < 1 {
-			fmt.Printf("docker-slim[%s]: missing target info...\n\n", Name)
-			cli.ShowCommandHelp(ctx, Name)
-			return nil
+		targetRef := ctx.String(commands.FlagTarget)
+
+		if targetRef == "" {
+			if len(ctx.Args()) < 1 {
+				fmt.Printf("docker-slim[%s]: missing image ID/name...\n\n", Name)
+				cli.ShowCommandHelp(ctx, Name)
+				return nil
+			} else {
+				targetRef = ctx.Args().First()
+			}
 		}
 
 		gcvalues, err := commands.GlobalCommandFlagValues(ctx)
@@ -31,13 +55,33 @@ var CLI = cli.Command{
 			return err
 		}
 
-		targetRef := ctx.Args().First()
+		imageTag := ctx.String(FlagTag)
+
+		instructions, err := commands.GetImageInstructions(ctx)
+		if err != nil {
+			fmt.Printf("docker-slim[%s]: invalid image instructions: %v\n", Name, err)
+			return err
+		}
+
+		addFiles, err := ParseAddFiles(ctx.StringSlice(FlagAddFile))
+		if err != nil {
+			fmt.Printf("docker-slim[%s]: invalid add file options: %v\n", Name, err)
+			return err
+		}
+
+		removeFiles := ctx.StringSlice(FlagRemoveFile)
+		doRmFileArtifacts := ctx.Bool(commands.FlagRemoveFileArtifacts)
 
 		ec := &commands.ExecutionContext{}
 
 		OnCommand(
 			gcvalues,
 			targetRef,
+			imageTag,
+			instructions,
+			addFiles,
+			removeFiles,
+			doRmFileArtifacts,
 			ec)
 		commands.ShowCommunityInfo()
 		return nil
@@ -0,0 +1,93 @@
+package edit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// Edit command flag names
+const (
+	FlagTag        = "tag"
+	FlagAddFile    = "add-file"
+	FlagRemoveFile = "remove-file"
+)
+
+// Edit command flag usage info
+const (
+	FlagTagUsage        = "Custom tag for the edited image"
+	FlagAddFileUsage    = "Add local file or directory to the image (format: local_path[:image_path])"
+	FlagRemoveFileUsage = "Remove file or directory from the image"
+)
+
+var Flags = map[string]cli.Flag{
+	FlagTag: cli.StringFlag{
+		Name:   FlagTag,
+		Value:  "",
+		Usage:  FlagTagUsage,
+		EnvVar: "DSLIM_EDIT_TAG",
+	},
+	FlagAddFile: cli.StringSliceFlag{
+		Name:   FlagAddFile,
+		Value:  &cli.StringSlice{},
+		Usage:  FlagAddFileUsage,
+		EnvVar: "DSLIM_EDIT_ADD_FILE",
+	},
+	FlagRemoveFile: cli.StringSliceFlag{
+		Name:   FlagRemoveFile,
+		Value:  &cli.StringSlice{},
+		Usage:  FlagRemoveFileUsage,
+		EnvVar: "DSLIM_EDIT_RM_FILE",
+	},
+}
+
+func cflag(name string) cli.Flag {
+	cf, ok := Flags[name]
+	if !ok {
+		log.Fatalf("unknown flag='%s'", name)
+	}
+
+	return cf
+}
+
+// ParseAddFiles parses the add-file flag values (returns map[IMAGE_PATH]LOCAL_PATH)
+func ParseAddFiles(values []string) (map[string]string, error) {
+	paths := map[string]string{}
+	for _, raw := range values {
+		if raw == "" {
+			continue
+		}
+
+		localPath := raw
+		var imagePath string
+		if parts := strings.SplitN(raw, ":", 2); len(parts) == 2 {
+			localPath = parts[0]
+			imagePath = parts[1]
+		}
+
+		localPath, err := filepath.Abs(localPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := os.Lstat(localPath); err != nil {
+			return nil, err
+		}
+
+		if imagePath == "" {
+			imagePath = localPath
+		}
+
+		if !filepath.IsAbs(imagePath) {
+			return nil, fmt.Errorf("image path is not absolute - %s", imagePath)
+		}
+
+		paths[filepath.Clean(imagePath)] = localPath
+	}
+
+	return paths, nil
+}
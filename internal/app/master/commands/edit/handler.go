@@ -2,25 +2,53 @@ package edit
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/docker-slim/docker-slim/internal/app/master/commands"
+	"github.com/docker-slim/docker-slim/internal/app/master/config"
 	"github.com/docker-slim/docker-slim/internal/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/internal/app/master/inspectors/image"
 	"github.com/docker-slim/docker-slim/internal/app/master/version"
 	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
 	"github.com/docker-slim/docker-slim/pkg/report"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
 	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 	v "github.com/docker-slim/docker-slim/pkg/version"
 
+	"github.com/dustin/go-humanize"
 	log "github.com/sirupsen/logrus"
 )
 
 const appName = commands.AppName
 
+// Edit command exit codes
+const (
+	eceOther = iota + 1
+	eceImageSaveError
+	eceImageEditError
+	eceImageLoadError
+)
+
+const (
+	editedImageRepoSuffix = ".edited"
+	editedImageRepo       = "edited"
+	editedArchiveName     = "edited.tar"
+	newLayerFileName      = "layer.tar"
+)
+
 // OnCommand implements the 'edit' docker-slim command
 func OnCommand(
 	gparams *commands.GenericParams,
 	targetRef string,
+	imageTag string,
+	instructions *config.ImageNewInstructions,
+	addFiles map[string]string,
+	removeFiles []string,
+	doRmFileArtifacts bool,
 	ec *commands.ExecutionContext) {
 	const cmdName = command.Edit
 	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
@@ -30,9 +58,11 @@ func OnCommand(
 
 	cmdReport := report.NewEditCommand(gparams.ReportLocation, gparams.InContainer)
 	cmdReport.State = command.StateStarted
+	cmdReport.TargetReference = targetRef
 
 	fmt.Printf("%s[%s]: state=started\n", appName, cmdName)
-	fmt.Printf("%s[%s]: info=params target=%v\n", appName, cmdName, targetRef)
+	fmt.Printf("%s[%s]: info=params target=%v tag=%v add.files=%v remove.files=%v\n",
+		appName, cmdName, targetRef, imageTag, len(addFiles), len(removeFiles))
 
 	client, err := dockerclient.New(gparams.ClientConfig)
 	if err == dockerclient.ErrNoDockerInfo {
@@ -50,6 +80,131 @@ func OnCommand(
 		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
 	}
 
+	imageInspector, err := image.NewInspector(client, targetRef)
+	errutil.FailOn(err)
+
+	if imageInspector.NoImage() {
+		fmt.Printf("%s[%s]: info=target.image.error status=not.found image='%v' message='make sure the target image already exists locally'\n", appName, cmdName, targetRef)
+		fmt.Printf("%s[%s]: state=exited\n", appName, cmdName)
+		return
+	}
+
+	err = imageInspector.Inspect()
+	errutil.FailOn(err)
+
+	cmdReport.SourceImage = report.ImageMetadata{
+		AllNames:      imageInspector.ImageRecordInfo.RepoTags,
+		ID:            imageInspector.ImageRecordInfo.ID,
+		Size:          imageInspector.ImageInfo.VirtualSize,
+		SizeHuman:     humanize.Bytes(uint64(imageInspector.ImageInfo.VirtualSize)),
+		CreateTime:    imageInspector.ImageInfo.Created.UTC().Format(time.RFC3339),
+		Author:        imageInspector.ImageInfo.Author,
+		DockerVersion: imageInspector.ImageInfo.DockerVersion,
+		Architecture:  imageInspector.ImageInfo.Architecture,
+		User:          imageInspector.ImageInfo.Config.User,
+	}
+
+	if len(imageInspector.ImageRecordInfo.RepoTags) > 0 {
+		cmdReport.SourceImage.Name = imageInspector.ImageRecordInfo.RepoTags[0]
+	}
+
+	if imageTag == "" {
+		imageTag = defaultImageTag(cmdReport.SourceImage.Name)
+	}
+
+	if !strings.Contains(filepath.Base(imageTag), ":") {
+		imageTag = fmt.Sprintf("%s:latest", imageTag)
+	}
+
+	localVolumePath, artifactLocation, statePath, stateKey := fsutil.PrepareImageStateDirs(gparams.StatePath, imageInspector.ImageInfo.ID)
+	logger.Debugf("localVolumePath=%v, artifactLocation=%v, statePath=%v, stateKey=%v", localVolumePath, artifactLocation, statePath, stateKey)
+	cmdReport.ArtifactLocation = artifactLocation
+
+	fmt.Printf("%s[%s]: state=image.edit.start\n", appName, cmdName)
+
+	imageID := dockerutil.CleanImageID(imageInspector.ImageInfo.ID)
+	iaName := fmt.Sprintf("%s.tar", imageID)
+	iaPath := filepath.Join(localVolumePath, "image", iaName)
+	err = dockerutil.SaveImage(client, imageID, iaPath, false, false)
+	if err != nil {
+		fmt.Printf("%s[%s]: info=image.save.error value='%v'\n", appName, cmdName, err)
+		fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+		commands.Exit(commands.ECTEdit | eceImageSaveError)
+	}
+
+	imageConfig, err := dockerimage.LoadConfigObject(iaPath, imageID)
+	errutil.FailOn(err)
+
+	runtimeConfig := imageConfig.Config
+	if runtimeConfig == nil {
+		runtimeConfig = &dockerimage.ContainerConfig{}
+	}
+
+	applyInstructions(runtimeConfig, instructions)
+
+	edits := &dockerimage.ImageEdits{
+		Config:    runtimeConfig,
+		CreatedBy: fmt.Sprintf("%s %s", appName, cmdName),
+		Comment:   fmt.Sprintf("%s version %s", appName, v.Current()),
+		RepoTags:  []string{imageTag},
+	}
+
+	if len(addFiles) > 0 || len(removeFiles) > 0 {
+		edits.LayerPath = filepath.Join(artifactLocation, newLayerFileName)
+		changes := &dockerimage.LayerChanges{
+			AddPaths:    addFiles,
+			RemovePaths: removeFiles,
+		}
+
+		err = dockerimage.WriteLayer(changes, edits.LayerPath)
+		if err != nil {
+			fmt.Printf("%s[%s]: info=image.layer.error value='%v'\n", appName, cmdName, err)
+			fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+			commands.Exit(commands.ECTEdit | eceImageEditError)
+		}
+
+		cmdReport.AddedFiles = addFiles
+		cmdReport.RemovedFiles = removeFiles
+	}
+
+	editedArchivePath := filepath.Join(localVolumePath, "image", editedArchiveName)
+	newImageID, err := dockerimage.SaveEditedImage(iaPath, imageID, editedArchivePath, edits)
+	if err != nil {
+		fmt.Printf("%s[%s]: info=image.edit.error value='%v'\n", appName, cmdName, err)
+		fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+		commands.Exit(commands.ECTEdit | eceImageEditError)
+	}
+
+	err = dockerutil.LoadImage(client, editedArchivePath)
+	if err != nil {
+		fmt.Printf("%s[%s]: info=image.load.error value='%v'\n", appName, cmdName, err)
+		fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+		commands.Exit(commands.ECTEdit | eceImageLoadError)
+	}
+
+	fmt.Printf("%s[%s]: state=image.edit.done\n", appName, cmdName)
+
+	cmdReport.EditedImage = imageTag
+	cmdReport.EditedImageID = fmt.Sprintf("sha256:%s", newImageID)
+	if newImageInfo, err := client.InspectImage(imageTag); err == nil {
+		cmdReport.EditedImageSize = newImageInfo.VirtualSize
+		cmdReport.EditedImageSizeHuman = humanize.Bytes(uint64(newImageInfo.VirtualSize))
+	} else {
+		errutil.WarnOn(err)
+	}
+
+	fmt.Printf("%s[%s]: info=results image.name=%s image.id=%s image.size='%s'\n",
+		appName, cmdName,
+		cmdReport.EditedImage,
+		cmdReport.EditedImageID,
+		cmdReport.EditedImageSizeHuman)
+
+	if doRmFileArtifacts {
+		logger.Info("removing temporary artifacts...")
+		errutil.WarnOn(fsutil.Remove(iaPath))
+		errutil.WarnOn(fsutil.Remove(editedArchivePath))
+	}
+
 	fmt.Printf("%s[%s]: state=completed\n", appName, cmdName)
 	cmdReport.State = command.StateCompleted
 
@@ -63,3 +218,103 @@ func OnCommand(
 		fmt.Printf("%s[%s]: info=report file='%s'\n", appName, cmdName, cmdReport.ReportLocation())
 	}
 }
+
+func defaultImageTag(sourceName string) string {
+	if rtInfo := strings.Split(sourceName, ":"); len(rtInfo) > 1 && rtInfo[0] != "" {
+		return fmt.Sprintf("%s%s", strings.Join(rtInfo[:len(rtInfo)-1], ":"), editedImageRepoSuffix)
+	}
+
+	return editedImageRepo
+}
+
+func applyInstructions(runtimeConfig *dockerimage.ContainerConfig, instructions *config.ImageNewInstructions) {
+	if instructions == nil {
+		return
+	}
+
+	if instructions.Workdir != "" {
+		runtimeConfig.WorkingDir = instructions.Workdir
+	}
+
+	if len(instructions.Entrypoint) > 0 {
+		runtimeConfig.Entrypoint = instructions.Entrypoint
+	} else if instructions.ClearEntrypoint {
+		runtimeConfig.Entrypoint = nil
+	}
+
+	if len(instructions.Cmd) > 0 {
+		runtimeConfig.Cmd = instructions.Cmd
+	} else if instructions.ClearCmd {
+		runtimeConfig.Cmd = nil
+	}
+
+	if len(instructions.Env) > 0 || len(instructions.RemoveEnvs) > 0 {
+		envs := map[string]string{}
+		var envNames []string
+		for _, envPair := range append(append([]string{}, runtimeConfig.Env...), instructions.Env...) {
+			envParts := strings.SplitN(envPair, "=", 2)
+			if len(envParts) == 0 || envParts[0] == "" {
+				continue
+			}
+
+			if _, ok := instructions.RemoveEnvs[envParts[0]]; ok {
+				continue
+			}
+
+			if _, ok := envs[envParts[0]]; !ok {
+				envNames = append(envNames, envParts[0])
+			}
+
+			envs[envParts[0]] = envPair
+		}
+
+		var newEnv []string
+		for _, name := range envNames {
+			newEnv = append(newEnv, envs[name])
+		}
+
+		runtimeConfig.Env = newEnv
+	}
+
+	if len(instructions.ExposedPorts) > 0 || len(instructions.RemoveExposedPorts) > 0 {
+		if runtimeConfig.ExposedPorts == nil {
+			runtimeConfig.ExposedPorts = map[string]struct{}{}
+		}
+
+		for k := range instructions.ExposedPorts {
+			runtimeConfig.ExposedPorts[string(k)] = struct{}{}
+		}
+
+		for k := range instructions.RemoveExposedPorts {
+			delete(runtimeConfig.ExposedPorts, string(k))
+		}
+	}
+
+	if len(instructions.Volumes) > 0 || len(instructions.RemoveVolumes) > 0 {
+		if runtimeConfig.Volumes == nil {
+			runtimeConfig.Volumes = map[string]struct{}{}
+		}
+
+		for k := range instructions.Volumes {
+			runtimeConfig.Volumes[k] = struct{}{}
+		}
+
+		for k := range instructions.RemoveVolumes {
+			delete(runtimeConfig.Volumes, k)
+		}
+	}
+
+	if len(instructions.Labels) > 0 || len(instructions.RemoveLabels) > 0 {
+		if runtimeConfig.Labels == nil {
+			runtimeConfig.Labels = map[string]string{}
+		}
+
+		for name, value := range instructions.Labels {
+			runtimeConfig.Labels[name] = value
+		}
+
+		for k := range instructions.RemoveLabels {
+			delete(runtimeConfig.Labels, k)
+		}
+	}
+}
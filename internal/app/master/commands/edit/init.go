@@ -6,5 +6,6 @@ import (
 
 func init() {
 	commands.CLI = append(commands.CLI, CLI)
+	commands.CommandFlagSuggestions[Name] = CommandFlagSuggestions
 	commands.CommandSuggestions = append(commands.CommandSuggestions, CommandSuggestion)
 }
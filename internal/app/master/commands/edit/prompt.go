@@ -1,6 +1,8 @@
 package edit
 
 import (
+	"github.com/docker-slim/docker-slim/internal/app/master/commands"
+
 	"github.com/c-bata/go-prompt"
 )
 
@@ -8,3 +10,29 @@ var CommandSuggestion = prompt.Suggest{
 	Text:        Name,
 	Description: Usage,
 }
+
+var CommandFlagSuggestions = &commands.FlagSuggestions{
+	Names: []prompt.Suggest{
+		{Text: commands.FullFlagName(commands.FlagTarget), Description: commands.FlagTargetUsage},
+		{Text: commands.FullFlagName(FlagTag), Description: FlagTagUsage},
+		{Text: commands.FullFlagName(commands.FlagNewEntrypoint), Description: commands.FlagNewEntrypointUsage},
+		{Text: commands.FullFlagName(commands.FlagNewCmd), Description: commands.FlagNewCmdUsage},
+		{Text: commands.FullFlagName(commands.FlagNewExpose), Description: commands.FlagNewExposeUsage},
+		{Text: commands.FullFlagName(commands.FlagNewWorkdir), Description: commands.FlagNewWorkdirUsage},
+		{Text: commands.FullFlagName(commands.FlagNewEnv), Description: commands.FlagNewEnvUsage},
+		{Text: commands.FullFlagName(commands.FlagNewVolume), Description: commands.FlagNewVolumeUsage},
+		{Text: commands.FullFlagName(commands.FlagNewLabel), Description: commands.FlagNewLabelUsage},
+		{Text: commands.FullFlagName(commands.FlagRemoveExpose), Description: commands.FlagRemoveExposeUsage},
+		{Text: commands.FullFlagName(commands.FlagRemoveEnv), Description: commands.FlagRemoveEnvUsage},
+		{Text: commands.FullFlagName(commands.FlagRemoveLabel), Description: commands.FlagRemoveLabelUsage},
+		{Text: commands.FullFlagName(commands.FlagRemoveVolume), Description: commands.FlagRemoveVolumeUsage},
+		{Text: commands.FullFlagName(FlagAddFile), Description: FlagAddFileUsage},
+		{Text: commands.FullFlagName(FlagRemoveFile), Description: FlagRemoveFileUsage},
+		{Text: commands.FullFlagName(commands.FlagRemoveFileArtifacts), Description: commands.FlagRemoveFileArtifactsUsage},
+	},
+	Values: map[string]commands.CompleteValue{
+		commands.FullFlagName(commands.FlagTarget):              commands.CompleteTarget,
+		commands.FullFlagName(FlagAddFile):                      commands.CompleteFile,
+		commands.FullFlagName(commands.FlagRemoveFileArtifacts): commands.CompleteBool,
+	},
+}
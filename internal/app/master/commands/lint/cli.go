@@ -4,13 +4,15 @@ import (
 	"fmt"
 
 	"github.com/docker-slim/docker-slim/internal/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/docker/linter"
+	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
 
 	"github.com/urfave/cli"
 )
 
 var (
 	Name  = "lint"
-	Usage = "Analyzes container instructions in Dockerfiles"
+	Usage = "Analyzes container instructions in Dockerfiles or container images"
 	Alias = "l"
 )
 
@@ -33,6 +35,10 @@ var CLI = cli.Command{
 		cflag(FlagShowNoHits),
 		cflag(FlagShowSnippet),
 		cflag(FlagListChecks),
+		cflag(FlagOutputFormat),
+		cflag(FlagOutputFile),
+		cflag(FlagRulesFile),
+		cflag(FlagFix),
 	},
 	Action: func(ctx *cli.Context) error {
 		commands.ShowCommunityInfo()
@@ -57,6 +63,14 @@ var CLI = cli.Command{
 		}
 
 		targetType := ctx.String(FlagTargetType)
+		switch targetType {
+		case "", linter.DockerfileTargetType, linter.ImageTargetType:
+		default:
+			fmt.Printf("docker-slim[%s]: invalid target type: %s\n", Name, targetType)
+			cli.ShowCommandHelp(ctx, Name)
+			return nil
+		}
+
 		doSkipBuildContext := ctx.Bool(FlagSkipBuildContext)
 		buildContextDir := ctx.String(FlagBuildContextDir)
 		doSkipDockerignore := ctx.Bool(FlagSkipDockerignore)
@@ -110,6 +124,26 @@ var CLI = cli.Command{
 		doShowNoHits := ctx.Bool(FlagShowNoHits)
 		doShowSnippet := ctx.Bool(FlagShowSnippet)
 
+		outputFormat := ctx.String(FlagOutputFormat)
+		if outputFormat != "" && !linter.IsOutputFormat(outputFormat) {
+			fmt.Printf("docker-slim[%s]: invalid output format: %s\n", Name, outputFormat)
+			cli.ShowCommandHelp(ctx, Name)
+			return nil
+		}
+
+		outputFile := ctx.String(FlagOutputFile)
+
+		doFix := ctx.Bool(FlagFix)
+
+		var policyChecks []check.Runner
+		if rulesFile := ctx.String(FlagRulesFile); rulesFile != "" {
+			policyChecks, err = check.LoadPolicyChecks(rulesFile)
+			if err != nil {
+				fmt.Printf("docker-slim[%s]: invalid rules file(%v): %v\n", Name, rulesFile, err)
+				return err
+			}
+		}
+
 		ec := &commands.ExecutionContext{}
 
 		OnCommand(
@@ -126,6 +160,10 @@ var CLI = cli.Command{
 			doShowNoHits,
 			doShowSnippet,
 			doListChecks,
+			outputFormat,
+			outputFile,
+			policyChecks,
+			doFix,
 			ec)
 		commands.ShowCommunityInfo()
 		return nil
@@ -22,11 +22,15 @@ const (
 	FlagShowNoHits         = "show-nohits"
 	FlagShowSnippet        = "show-snippet"
 	FlagListChecks         = "list-checks"
+	FlagOutputFormat       = "output-format"
+	FlagOutputFile         = "output-file"
+	FlagRulesFile          = "rules-file"
+	FlagFix                = "fix"
 )
 
 // Lint command flag usage info
 const (
-	FlagLintTargetUsage         = "Target Dockerfile path (or container image)"
+	FlagLintTargetUsage         = "Target Dockerfile path or container image"
 	FlagTargetTypeUsage         = "Explicitly specify the command target type (values: dockerfile, image)"
 	FlagSkipBuildContextUsage   = "Don't try to analyze build context"
 	FlagBuildContextDirUsage    = "Explicitly specify the build context directory"
@@ -40,6 +44,10 @@ const (
 	FlagShowNoHitsUsage         = "Show checks with no matches"
 	FlagShowSnippetUsage        = "Show check match snippet"
 	FlagListChecksUsage         = "List available checks"
+	FlagOutputFormatUsage       = "Save lint results in the selected format (values: sarif, junit, checkstyle)"
+	FlagOutputFileUsage         = "Lint results output file (used with the output-format flag)"
+	FlagRulesFileUsage          = "File with user-defined policy check rules (YAML or JSON)"
+	FlagFixUsage                = "Fix the Dockerfile problems (for the checks with safe fixes)"
 )
 
 var Flags = map[string]cli.Flag{
@@ -122,6 +130,29 @@ var Flags = map[string]cli.Flag{
 		Usage:  FlagListChecksUsage,
 		EnvVar: "DSLIM_LINT_LIST_CHECKS",
 	},
+	FlagOutputFormat: cli.StringFlag{
+		Name:   FlagOutputFormat,
+		Value:  "",
+		Usage:  FlagOutputFormatUsage,
+		EnvVar: "DSLIM_LINT_OUTPUT_FORMAT",
+	},
+	FlagOutputFile: cli.StringFlag{
+		Name:   FlagOutputFile,
+		Value:  "",
+		Usage:  FlagOutputFileUsage,
+		EnvVar: "DSLIM_LINT_OUTPUT_FILE",
+	},
+	FlagRulesFile: cli.StringFlag{
+		Name:   FlagRulesFile,
+		Value:  "",
+		Usage:  FlagRulesFileUsage,
+		EnvVar: "DSLIM_LINT_RULES_FILE",
+	},
+	FlagFix: cli.BoolFlag{
+		Name:   FlagFix,
+		Usage:  FlagFixUsage,
+		EnvVar: "DSLIM_LINT_FIX",
+	},
 }
 
 func cflag(name string) cli.Flag {
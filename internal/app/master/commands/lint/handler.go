@@ -1,16 +1,26 @@
 package lint
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/docker-slim/docker-slim/internal/app/master/commands"
+	"github.com/docker-slim/docker-slim/internal/app/master/docker/dockerclient"
+	"github.com/docker-slim/docker-slim/internal/app/master/inspectors/image"
 	"github.com/docker-slim/docker-slim/internal/app/master/version"
 	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/reverse"
 	"github.com/docker-slim/docker-slim/pkg/docker/linter"
 	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
 	"github.com/docker-slim/docker-slim/pkg/report"
 	"github.com/docker-slim/docker-slim/pkg/util/errutil"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+	v "github.com/docker-slim/docker-slim/pkg/version"
 
 	dockerapi "github.com/fsouza/go-dockerclient"
 	log "github.com/sirupsen/logrus"
@@ -18,6 +28,13 @@ import (
 
 const appName = commands.AppName
 
+// imageRefPattern is a simplified image reference pattern
+// ([domain[:port]/]name[:tag][@digest] with lowercase name components)
+var imageRefPattern = regexp.MustCompile(`^(?:[a-zA-Z0-9.-]+(?::[0-9]+)?/)?[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*(?::[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127})?(?:@[a-z0-9]+:[a-fA-F0-9]{32,})?$`)
+
+// utf8BOM is the byte order mark (the Dockerfile parser removes it from the first line)
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
 // OnCommand implements the 'lint' docker-slim command
 func OnCommand(
 	gparams *commands.GenericParams,
@@ -33,6 +50,10 @@ func OnCommand(
 	doShowNoHits bool,
 	doShowSnippet bool,
 	doListChecks bool,
+	outputFormat string,
+	outputFile string,
+	policyChecks []check.Runner,
+	doFix bool,
 	ec *commands.ExecutionContext) {
 	const cmdName = command.Lint
 	logger := log.WithFields(log.Fields{"app": appName, "command": cmdName})
@@ -43,12 +64,22 @@ func OnCommand(
 	cmdReport := report.NewLintCommand(gparams.ReportLocation, gparams.InContainer)
 	cmdReport.State = command.StateStarted
 
+	if targetType == "" {
+		targetType = linter.DockerfileTargetType
+		//the target is an image if there is no Dockerfile with the target name
+		//and the target name looks like an image reference
+		if !doListChecks && !fsutil.Exists(targetRef) && isImageReference(targetRef) {
+			targetType = linter.ImageTargetType
+		}
+	}
+
 	fmt.Printf("%s[%s]: state=started\n", appName, cmdName)
-	fmt.Printf("%s[%s]: info=params target=%v list.checks=%v\n", appName, cmdName, targetRef, doListChecks)
+	fmt.Printf("%s[%s]: info=params target=%v target.type=%v list.checks=%v\n", appName, cmdName, targetRef, targetType, doListChecks)
 
-	/*
-		do it only when targetting images
-		client, err := dockerclient.New(gparams.ClientConfig)
+	var client *dockerapi.Client
+	if targetType == linter.ImageTargetType && !doListChecks {
+		var err error
+		client, err = dockerclient.New(gparams.ClientConfig)
 		if err == dockerclient.ErrNoDockerInfo {
 			exitMsg := "missing Docker connection info"
 			if gparams.InContainer && gparams.IsDSImage {
@@ -56,25 +87,23 @@ func OnCommand(
 			}
 			fmt.Printf("%s[%s]: info=docker.connect.error message='%s'\n", appName, cmdName, exitMsg)
 			fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
-			os.Exit(ectCommon | ecNoDockerConnectInfo)
+			commands.Exit(commands.ECTCommon | commands.ECNoDockerConnectInfo)
 		}
 		errutil.FailOn(err)
-	*/
-	var client *dockerapi.Client
+	}
 
 	if gparams.Debug {
 		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
 	}
 
 	if doListChecks {
-		checks := linter.ListChecks()
+		checks := linter.ListChecks(policyChecks...)
 		printLintChecks(checks, appName, cmdName)
 	} else {
-		cmdReport.TargetType = linter.DockerfileTargetType
+		cmdReport.TargetType = targetType
 		cmdReport.TargetReference = targetRef
 
 		options := linter.Options{
-			DockerfilePath:   targetRef,
 			SkipBuildContext: doSkipBuildContext,
 			BuildContextDir:  buildContextDir,
 			SkipDockerignore: doSkipDockerignore,
@@ -84,6 +113,26 @@ func OnCommand(
 				ExcludeCheckLabels: excludeCheckLabels,
 				ExcludeCheckIDs:    excludeCheckIDs,
 			},
+			ExtraChecks: policyChecks,
+		}
+
+		if targetType == linter.ImageTargetType {
+			imageInfo := getImageInfo(client, targetRef, appName, cmdName)
+			if imageInfo == nil {
+				fmt.Printf("%s[%s]: state=exited\n", appName, cmdName)
+				return
+			}
+
+			options.Image = imageInfo
+		} else {
+			if !fsutil.Exists(targetRef) {
+				fmt.Printf("%s[%s]: info=target.dockerfile.error status=not.found file='%v' message='Dockerfile not found (use --%s %s for image targets)'\n",
+					appName, cmdName, targetRef, FlagTargetType, linter.ImageTargetType)
+				fmt.Printf("%s[%s]: state=exited\n", appName, cmdName)
+				return
+			}
+
+			options.DockerfilePath = targetRef
 		}
 
 		lintResults, err := linter.Execute(options)
@@ -91,9 +140,34 @@ func OnCommand(
 
 		cmdReport.BuildContextDir = lintResults.BuildContextDir
 		cmdReport.Hits = lintResults.Hits
+		cmdReport.Suppressed = lintResults.Suppressed
 		cmdReport.Errors = lintResults.Errors
 
 		printLintResults(lintResults, appName, cmdName, cmdReport, doShowNoHits, doShowSnippet)
+
+		if doFix {
+			if targetType == linter.DockerfileTargetType {
+				fixDockerfile(lintResults, targetRef, policyChecks, appName, cmdName, cmdReport)
+			} else {
+				fmt.Printf("%s[%s]: info=lint.fix status=skipped message='fixes are only supported for Dockerfile targets'\n", appName, cmdName)
+			}
+		}
+
+		if outputFormat != "" {
+			if outputFile == "" {
+				outputFile = linter.OutputFileName(outputFormat)
+			}
+
+			output, err := linter.FormatReport(lintResults, outputFormat, targetRef)
+			errutil.FailOn(err)
+
+			err = ioutil.WriteFile(outputFile, output, 0644)
+			errutil.FailOn(err)
+
+			cmdReport.OutputFormat = outputFormat
+			cmdReport.OutputLocation = outputFile
+			fmt.Printf("%s[%s]: info=lint.output format=%s file='%s'\n", appName, cmdName, outputFormat, outputFile)
+		}
 	}
 
 	fmt.Printf("%s[%s]: state=completed\n", appName, cmdName)
@@ -110,6 +184,120 @@ func OnCommand(
 	}
 }
 
+func fixDockerfile(lintResults *linter.Report,
+	dockerfilePath string,
+	policyChecks []check.Runner,
+	appName string,
+	cmdName command.Type,
+	cmdReport *report.LintCommand) {
+	fixReport, err := linter.Fix(lintResults, policyChecks...)
+	errutil.FailOn(err)
+
+	cmdReport.FixesCount = len(fixReport.Applied)
+	cmdReport.Fixes = fixReport.Applied
+	cmdReport.FixDiff = fixReport.Diff
+
+	fmt.Printf("%s[%s]: info=lint.fix applied=%d skipped=%d\n",
+		appName, cmdName, len(fixReport.Applied), len(fixReport.Skipped))
+
+	if len(fixReport.Applied) == 0 {
+		return
+	}
+
+	for _, fix := range fixReport.Applied {
+		fmt.Printf("%s[%s]: info=lint.fix.applied id=%s start=%d end=%d\n",
+			appName, cmdName, fix.CheckID, fix.StartLine, fix.EndLine)
+	}
+
+	//the skipped fixes overlap with the applied fixes (run 'lint --fix' again to apply them)
+	for _, fix := range fixReport.Skipped {
+		fmt.Printf("%s[%s]: info=lint.fix.skipped id=%s start=%d end=%d\n",
+			appName, cmdName, fix.CheckID, fix.StartLine, fix.EndLine)
+	}
+
+	fileInfo, err := os.Stat(dockerfilePath)
+	errutil.FailOn(err)
+
+	original, err := ioutil.ReadFile(dockerfilePath)
+	errutil.FailOn(err)
+
+	err = ioutil.WriteFile(dockerfilePath, fixedDockerfileData(original, fixReport.Lines), fileInfo.Mode().Perm())
+	errutil.FailOn(err)
+
+	fmt.Printf("%s[%s]: info=lint.fix.diff\n%s", appName, cmdName, fixReport.Diff)
+}
+
+// fixedDockerfileData creates the fixed Dockerfile data
+// (keeping the line endings, the byte order mark and the final line ending from the original Dockerfile)
+func fixedDockerfileData(original []byte, lines []string) []byte {
+	lineEnd := "\n"
+	if bytes.Contains(original, []byte("\r\n")) {
+		lineEnd = "\r\n"
+	}
+
+	var data bytes.Buffer
+	if bytes.HasPrefix(original, utf8BOM) {
+		data.Write(utf8BOM)
+	}
+
+	data.WriteString(strings.Join(lines, lineEnd))
+	if len(original) == 0 || bytes.HasSuffix(original, []byte("\n")) {
+		data.WriteString(lineEnd)
+	}
+
+	return data.Bytes()
+}
+
+// isImageReference checks if the lint target looks like an image reference (and not like a file path)
+func isImageReference(targetRef string) bool {
+	if strings.HasPrefix(targetRef, ".") ||
+		strings.HasPrefix(targetRef, "/") ||
+		strings.HasPrefix(targetRef, "~") {
+		return false
+	}
+
+	if strings.Contains(strings.ToLower(filepath.Base(targetRef)), "dockerfile") {
+		return false
+	}
+
+	return imageRefPattern.MatchString(targetRef)
+}
+
+func getImageInfo(client *dockerapi.Client,
+	targetRef string,
+	appName string,
+	cmdName command.Type) *check.ImageInfo {
+	imageInspector, err := image.NewInspector(client, targetRef)
+	errutil.FailOn(err)
+
+	if imageInspector.NoImage() {
+		fmt.Printf("%s[%s]: info=target.image.error status=not.found image='%v' message='make sure the target image already exists locally'\n", appName, cmdName, targetRef)
+		return nil
+	}
+
+	err = imageInspector.Inspect()
+	errutil.FailOn(err)
+
+	history, err := reverse.DockerfileFromHistory(client, imageInspector.ImageInfo.ID)
+	errutil.FailOn(err)
+
+	info := &check.ImageInfo{
+		ID:      imageInspector.ImageInfo.ID,
+		Name:    targetRef,
+		Config:  imageInspector.ImageInfo.Config,
+		History: history,
+	}
+
+	if len(imageInspector.ImageRecordInfo.RepoTags) > 0 {
+		info.Name = imageInspector.ImageRecordInfo.RepoTags[0]
+	}
+
+	fmt.Printf("%s[%s]: info=image id=%v name='%v' instructions=%d\n",
+		appName, cmdName, info.ID, info.Name, len(history.AllInstructions))
+
+	return info
+}
+
 func printLintChecks(checks []*check.Info,
 	appName string,
 	cmdName command.Type) {
@@ -148,13 +336,15 @@ func printLintResults(lintResults *linter.Report,
 	cmdReport.HitsCount = len(lintResults.Hits)
 	cmdReport.NoHitsCount = len(lintResults.NoHits)
 	cmdReport.ErrorsCount = len(lintResults.Errors)
+	cmdReport.SuppressedCount = len(lintResults.Suppressed)
 
-	fmt.Printf("%s[%s]: info=lint.results hits=%d nohits=%d errors=%d:\n",
+	fmt.Printf("%s[%s]: info=lint.results hits=%d nohits=%d errors=%d suppressed=%d:\n",
 		appName,
 		cmdName,
 		cmdReport.HitsCount,
 		cmdReport.NoHitsCount,
-		cmdReport.ErrorsCount)
+		cmdReport.ErrorsCount,
+		cmdReport.SuppressedCount)
 
 	if cmdReport.HitsCount > 0 {
 		fmt.Printf("%s[%s]: info=lint.check.hits count=%d\n",
@@ -215,6 +405,16 @@ func printLintResults(lintResults *linter.Report,
 		}
 	}
 
+	if cmdReport.SuppressedCount > 0 {
+		fmt.Printf("%s[%s]: info=lint.check.suppressed count=%d\n",
+			appName, cmdName, cmdReport.SuppressedCount)
+
+		for id, result := range lintResults.Suppressed {
+			fmt.Printf("%s[%s]: info=lint.check.suppressed id=%s name='%s' matches=%d\n",
+				appName, cmdName, id, result.Source.Name, len(result.Matches))
+		}
+	}
+
 	if cmdReport.ErrorsCount > 0 {
 		fmt.Printf("%s[%s]: info=lint.check.errors count=%d: %v\n",
 			appName, cmdName, cmdReport.ErrorsCount)
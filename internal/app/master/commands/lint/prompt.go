@@ -2,6 +2,7 @@ package lint
 
 import (
 	"github.com/docker-slim/docker-slim/internal/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/docker/linter"
 	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
 
 	"github.com/c-bata/go-prompt"
@@ -28,6 +29,10 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		{Text: commands.FullFlagName(FlagShowNoHits), Description: FlagShowNoHitsUsage},
 		{Text: commands.FullFlagName(FlagShowSnippet), Description: FlagShowSnippetUsage},
 		{Text: commands.FullFlagName(FlagListChecks), Description: FlagListChecksUsage},
+		{Text: commands.FullFlagName(FlagOutputFormat), Description: FlagOutputFormatUsage},
+		{Text: commands.FullFlagName(FlagOutputFile), Description: FlagOutputFileUsage},
+		{Text: commands.FullFlagName(FlagRulesFile), Description: FlagRulesFileUsage},
+		{Text: commands.FullFlagName(FlagFix), Description: FlagFixUsage},
 	},
 	Values: map[string]commands.CompleteValue{
 		commands.FullFlagName(commands.FlagTarget):    completeLintTarget,
@@ -42,11 +47,16 @@ var CommandFlagSuggestions = &commands.FlagSuggestions{
 		commands.FullFlagName(FlagShowNoHits):         commands.CompleteBool,
 		commands.FullFlagName(FlagShowSnippet):        commands.CompleteTBool,
 		commands.FullFlagName(FlagListChecks):         commands.CompleteBool,
+		commands.FullFlagName(FlagOutputFormat):       completeLintOutputFormat,
+		commands.FullFlagName(FlagOutputFile):         commands.CompleteFile,
+		commands.FullFlagName(FlagRulesFile):          commands.CompleteFile,
+		commands.FullFlagName(FlagFix):                commands.CompleteBool,
 	},
 }
 
 func completeLintTarget(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
 	//for now only support selecting Dockerfiles
+	//(image targets can be entered manually)
 	//later add an ability to choose (files or images)
 	//based on the target-type parameter
 	return commands.CompleteFile(ia, token, params)
@@ -61,6 +71,16 @@ func completeLintTargetType(ia *commands.InteractiveApp, token string, params pr
 	return prompt.FilterHasPrefix(lintTargetTypeValues, token, true)
 }
 
+var lintOutputFormatValues = []prompt.Suggest{
+	{Text: linter.OutputFormatSARIF, Description: "SARIF (Static Analysis Results Interchange Format)"},
+	{Text: linter.OutputFormatJUnit, Description: "JUnit XML test report"},
+	{Text: linter.OutputFormatCheckstyle, Description: "Checkstyle XML report"},
+}
+
+func completeLintOutputFormat(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	return prompt.FilterHasPrefix(lintOutputFormatValues, token, true)
+}
+
 func completeLintCheckID(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
 	var values []prompt.Suggest
 	for _, check := range check.AllChecks {
@@ -2,8 +2,11 @@ package xray
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/docker-slim/docker-slim/internal/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
 
 	"github.com/urfave/cli"
 )
@@ -20,24 +23,58 @@ var CLI = cli.Command{
 	Usage:   Usage,
 	Flags: []cli.Flag{
 		commands.Cflag(commands.FlagTarget),
+		cflag(FlagTargetArchive),
+		cflag(FlagTargetOCILayout),
 		cflag(FlagChanges),
 		cflag(FlagLayer),
 		cflag(FlagAddImageManifest),
 		cflag(FlagAddImageConfig),
+		cflag(FlagSBOMFormat),
+		cflag(FlagSBOMFile),
+		cflag(FlagHashData),
+		cflag(FlagScanSecrets),
+		cflag(FlagElfInventory),
+		cflag(FlagCompressedSize),
+		cflag(FlagExtractPath),
+		cflag(FlagExportLayer),
+		cflag(FlagOutput),
+		cflag(FlagUI),
+		cflag(FlagCompare),
+		cflag(FlagPolicy),
 		commands.Cflag(commands.FlagRemoveFileArtifacts),
 	},
 	Action: func(ctx *cli.Context) error {
 		commands.ShowCommunityInfo()
 		targetRef := ctx.String(commands.FlagTarget)
+		targetArchive := ctx.String(FlagTargetArchive)
+		targetOCILayout := ctx.String(FlagTargetOCILayout)
 
-		if targetRef == "" {
-			if len(ctx.Args()) < 1 {
-				fmt.Printf("docker-slim[%s]: missing image ID/name...\n\n", Name)
-				cli.ShowCommandHelp(ctx, Name)
-				return nil
-			} else {
-				targetRef = ctx.Args().First()
-			}
+		if targetArchive != "" && targetOCILayout != "" {
+			fmt.Printf("docker-slim[%s]: use only one of the --%s and --%s flags...\n\n", Name, FlagTargetArchive, FlagTargetOCILayout)
+			cli.ShowCommandHelp(ctx, Name)
+			return nil
+		}
+
+		if targetArchive != "" && !fsutil.IsRegularFile(targetArchive) {
+			fmt.Printf("docker-slim[%s]: target image archive file not found - %s\n\n", Name, targetArchive)
+			return nil
+		}
+
+		if targetOCILayout != "" && !fsutil.DirExists(targetOCILayout) {
+			fmt.Printf("docker-slim[%s]: target OCI layout directory not found - %s\n\n", Name, targetOCILayout)
+			return nil
+		}
+
+		//the target image reference is optional for the image archives and OCI layouts
+		//(it's used to select the image when there's more than one image)
+		if targetRef == "" && len(ctx.Args()) > 0 {
+			targetRef = ctx.Args().First()
+		}
+
+		if targetRef == "" && targetArchive == "" && targetOCILayout == "" {
+			fmt.Printf("docker-slim[%s]: missing image ID/name...\n\n", Name)
+			cli.ShowCommandHelp(ctx, Name)
+			return nil
 		}
 
 		gcvalues, err := commands.GlobalCommandFlagValues(ctx)
@@ -59,6 +96,40 @@ var CLI = cli.Command{
 
 		doAddImageManifest := ctx.Bool(FlagAddImageManifest)
 		doAddImageConfig := ctx.Bool(FlagAddImageConfig)
+		sbomFormat := ctx.String(FlagSBOMFormat)
+		if sbomFormat != "" && !dockerimage.IsSBOMFormat(sbomFormat) {
+			fmt.Printf("docker-slim[%s]: unsupported SBOM format: %s\n\n", Name, sbomFormat)
+			cli.ShowCommandHelp(ctx, Name)
+			return nil
+		}
+
+		sbomFile := ctx.String(FlagSBOMFile)
+		doHashData := ctx.Bool(FlagHashData)
+		doScanSecrets := ctx.Bool(FlagScanSecrets)
+		doElfInventory := ctx.Bool(FlagElfInventory)
+		doCompressedSize := ctx.Bool(FlagCompressedSize)
+		extractPaths := ctx.StringSlice(FlagExtractPath)
+		exportLayer := ctx.String(FlagExportLayer)
+		output := ctx.String(FlagOutput)
+		if (len(extractPaths) > 0 || exportLayer != "") && output == "" {
+			fmt.Printf("docker-slim[%s]: missing output location (--%s)...\n\n", Name, FlagOutput)
+			cli.ShowCommandHelp(ctx, Name)
+			return nil
+		}
+
+		if len(extractPaths) > 0 && strings.HasSuffix(output, ".tar") {
+			fmt.Printf("docker-slim[%s]: the extracted paths need a directory output location - %s\n\n", Name, output)
+			return nil
+		}
+
+		doUI := ctx.Bool(FlagUI)
+		compareRef := ctx.String(FlagCompare)
+		policyPath := ctx.String(FlagPolicy)
+		if policyPath != "" && !fsutil.IsRegularFile(policyPath) {
+			fmt.Printf("docker-slim[%s]: policy file not found - %s\n\n", Name, policyPath)
+			commands.Exit(commands.ECTXray | ecxPolicyLoadError)
+		}
+
 		doRmFileArtifacts := ctx.Bool(commands.FlagRemoveFileArtifacts)
 
 		ec := &commands.ExecutionContext{}
@@ -66,10 +137,24 @@ var CLI = cli.Command{
 		OnCommand(
 			gcvalues,
 			targetRef,
+			targetArchive,
+			targetOCILayout,
 			changes,
 			layers,
 			doAddImageManifest,
 			doAddImageConfig,
+			sbomFormat,
+			sbomFile,
+			doHashData,
+			doScanSecrets,
+			doElfInventory,
+			doCompressedSize,
+			extractPaths,
+			exportLayer,
+			output,
+			doUI,
+			compareRef,
+			policyPath,
 			doRmFileArtifacts,
 			ec)
 		commands.ShowCommunityInfo()
@@ -7,21 +7,61 @@ import (
 
 // Xray command flag names
 const (
+	FlagTargetArchive    = "target-archive"
+	FlagTargetOCILayout  = "target-oci-layout"
 	FlagChanges          = "changes"
 	FlagLayer            = "layer"
 	FlagAddImageManifest = "add-image-manifest"
 	FlagAddImageConfig   = "add-image-config"
+	FlagSBOMFormat       = "sbom-format"
+	FlagSBOMFile         = "sbom-file"
+	FlagHashData         = "hash-data"
+	FlagScanSecrets      = "scan-secrets"
+	FlagElfInventory     = "elf-inventory"
+	FlagCompressedSize   = "compressed-size"
+	FlagExtractPath      = "extract-path"
+	FlagExportLayer      = "export-layer"
+	FlagOutput           = "output"
+	FlagUI               = "ui"
+	FlagCompare          = "compare"
+	FlagPolicy           = "policy"
 )
 
 // Xray command flag usage info
 const (
+	FlagTargetArchiveUsage    = "Target image archive file created with 'docker save' (analyzed without the Docker daemon)"
+	FlagTargetOCILayoutUsage  = "Target OCI image layout directory (analyzed without the Docker daemon)"
 	FlagChangesUsage          = "Show layer change details for the selected change type (values: none, all, delete, modify, add)"
 	FlagLayerUsage            = "Show details for the selected layer (using layer index or ID)"
 	FlagAddImageManifestUsage = "Add raw image manifest to the command execution report file"
 	FlagAddImageConfigUsage   = "Add raw image config object to the command execution report file"
+	FlagSBOMFormatUsage       = "Generate software bill of materials (SBOM) for the image (values: cyclonedx, spdx)"
+	FlagSBOMFileUsage         = "SBOM output file (default: sbom.cdx.json or sbom.spdx.json in the artifacts directory)"
+	FlagHashDataUsage         = "Generate file data hashes to find the duplicate files in the image layers"
+	FlagScanSecretsUsage      = "Scan the files in all image layers for secrets and credentials (including the deleted files)"
+	FlagElfInventoryUsage     = "Inspect the ELF binaries and shared libraries in the image"
+	FlagCompressedSizeUsage   = "Calculate the compressed (gzip and zstd) layer sizes to estimate the registry storage and pull size"
+	FlagExtractPathUsage      = "Extract the file or directory from the final image filesystem to the output directory"
+	FlagExportLayerUsage      = "Export the selected layer (using layer index or ID) to the output tar file or directory"
+	FlagOutputUsage           = "Output location for the extracted paths (directory) and the exported layer (tar file or directory)"
+	FlagUIUsage               = "Open the interactive terminal UI to browse the image layers, their instructions and their files"
+	FlagCompareUsage          = "Compare the target image with another image (from the same image archive or OCI layout for the daemonless analysis)"
+	FlagPolicyUsage           = "Check the image with the policy rules from the policy file (YAML or JSON) and fail if the image doesn't satisfy them"
 )
 
 var Flags = map[string]cli.Flag{
+	FlagTargetArchive: cli.StringFlag{
+		Name:   FlagTargetArchive,
+		Value:  "",
+		Usage:  FlagTargetArchiveUsage,
+		EnvVar: "DSLIM_XRAY_TARGET_ARCHIVE",
+	},
+	FlagTargetOCILayout: cli.StringFlag{
+		Name:   FlagTargetOCILayout,
+		Value:  "",
+		Usage:  FlagTargetOCILayoutUsage,
+		EnvVar: "DSLIM_XRAY_TARGET_OCI_LAYOUT",
+	},
 	FlagChanges: cli.StringSliceFlag{
 		Name:   FlagChanges,
 		Value:  &cli.StringSlice{""},
@@ -44,6 +84,73 @@ var Flags = map[string]cli.Flag{
 		Usage:  FlagAddImageConfigUsage,
 		EnvVar: "DSLIM_XRAY_IMAGE_CONFIG",
 	},
+	FlagSBOMFormat: cli.StringFlag{
+		Name:   FlagSBOMFormat,
+		Value:  "",
+		Usage:  FlagSBOMFormatUsage,
+		EnvVar: "DSLIM_XRAY_SBOM_FORMAT",
+	},
+	FlagSBOMFile: cli.StringFlag{
+		Name:   FlagSBOMFile,
+		Value:  "",
+		Usage:  FlagSBOMFileUsage,
+		EnvVar: "DSLIM_XRAY_SBOM_FILE",
+	},
+	FlagHashData: cli.BoolFlag{
+		Name:   FlagHashData,
+		Usage:  FlagHashDataUsage,
+		EnvVar: "DSLIM_XRAY_HASH_DATA",
+	},
+	FlagScanSecrets: cli.BoolFlag{
+		Name:   FlagScanSecrets,
+		Usage:  FlagScanSecretsUsage,
+		EnvVar: "DSLIM_XRAY_SCAN_SECRETS",
+	},
+	FlagElfInventory: cli.BoolFlag{
+		Name:   FlagElfInventory,
+		Usage:  FlagElfInventoryUsage,
+		EnvVar: "DSLIM_XRAY_ELF_INVENTORY",
+	},
+	FlagCompressedSize: cli.BoolFlag{
+		Name:   FlagCompressedSize,
+		Usage:  FlagCompressedSizeUsage,
+		EnvVar: "DSLIM_XRAY_COMPRESSED_SIZE",
+	},
+	FlagExtractPath: cli.StringSliceFlag{
+		Name:   FlagExtractPath,
+		Value:  &cli.StringSlice{},
+		Usage:  FlagExtractPathUsage,
+		EnvVar: "DSLIM_XRAY_EXTRACT_PATH",
+	},
+	FlagExportLayer: cli.StringFlag{
+		Name:   FlagExportLayer,
+		Value:  "",
+		Usage:  FlagExportLayerUsage,
+		EnvVar: "DSLIM_XRAY_EXPORT_LAYER",
+	},
+	FlagOutput: cli.StringFlag{
+		Name:   FlagOutput,
+		Value:  "",
+		Usage:  FlagOutputUsage,
+		EnvVar: "DSLIM_XRAY_OUTPUT",
+	},
+	FlagUI: cli.BoolFlag{
+		Name:   FlagUI,
+		Usage:  FlagUIUsage,
+		EnvVar: "DSLIM_XRAY_UI",
+	},
+	FlagCompare: cli.StringFlag{
+		Name:   FlagCompare,
+		Value:  "",
+		Usage:  FlagCompareUsage,
+		EnvVar: "DSLIM_XRAY_COMPARE",
+	},
+	FlagPolicy: cli.StringFlag{
+		Name:   FlagPolicy,
+		Value:  "",
+		Usage:  FlagPolicyUsage,
+		EnvVar: "DSLIM_XRAY_POLICY",
+	},
 }
 
 func cflag(name string) cli.Flag {
@@ -1,8 +1,12 @@
 package xray
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,6 +15,7 @@ import (
 	"github.com/docker-slim/docker-slim/internal/app/master/inspectors/image"
 	"github.com/docker-slim/docker-slim/internal/app/master/version"
 	"github.com/docker-slim/docker-slim/pkg/command"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/reverse"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
 	"github.com/docker-slim/docker-slim/pkg/report"
@@ -24,19 +29,46 @@ import (
 
 const appName = commands.AppName
 
+const (
+	fatDockerfileName  = "Dockerfile.fat"
+	wastedPathsMax     = 20
+	duplicateGroupsMax = 20
+	elfDebugObjectsMax = 20
+	compareFilesMax    = 20
+)
+
+var errCompareImageNotFound = errors.New("image not found")
+
 // Xray command exit codes
 const (
 	ecxOther = iota + 1
+	ecxPolicyLoadError
+	ecxPolicyViolation
+	ecxNoImage
 )
 
 // OnCommand implements the 'xray' docker-slim command
 func OnCommand(
 	gparams *commands.GenericParams,
 	targetRef string,
+	targetArchive string,
+	targetOCILayout string,
 	changes map[string]struct{},
 	layers map[string]struct{},
 	doAddImageManifest bool,
 	doAddImageConfig bool,
+	sbomFormat string,
+	sbomFile string,
+	doHashData bool,
+	doScanSecrets bool,
+	doElfInventory bool,
+	doCompressedSize bool,
+	extractPaths []string,
+	exportLayer string,
+	output string,
+	doUI bool,
+	compareRef string,
+	policyPath string,
 	doRmFileArtifacts bool,
 	ec *commands.ExecutionContext) {
 	const cmdName = command.Xray
@@ -50,130 +82,303 @@ func OnCommand(
 	cmdReport.TargetReference = targetRef
 
 	fmt.Printf("%s[%s]: state=started\n", appName, cmdName)
-	fmt.Printf("%s[%s]: info=params target=%v add-image-manifest=%v add-image-config=%v rm-file-artifacts=%v\n",
-		appName, cmdName, targetRef, doAddImageManifest, doAddImageConfig, doRmFileArtifacts)
-
-	client, err := dockerclient.New(gparams.ClientConfig)
-	if err == dockerclient.ErrNoDockerInfo {
-		exitMsg := "missing Docker connection info"
-		if gparams.InContainer && gparams.IsDSImage {
-			exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+	fmt.Printf("%s[%s]: info=params target=%v target-archive=%v target-oci-layout=%v add-image-manifest=%v add-image-config=%v sbom-format=%v hash-data=%v scan-secrets=%v elf-inventory=%v compressed-size=%v ui=%v compare=%v policy=%v rm-file-artifacts=%v\n",
+		appName, cmdName, targetRef, targetArchive, targetOCILayout, doAddImageManifest, doAddImageConfig, sbomFormat, doHashData, doScanSecrets, doElfInventory, doCompressedSize, doUI, compareRef, policyPath, doRmFileArtifacts)
+
+	var imagePolicy *dockerimage.ImagePolicy
+	if policyPath != "" {
+		var err error
+		imagePolicy, err = dockerimage.LoadImagePolicy(policyPath)
+		if err != nil {
+			fmt.Printf("%s[%s]: info=policy.error file='%s' message='%v'\n", appName, cmdName, policyPath, err)
+			fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+			commands.Exit(commands.ECTXray | ecxPolicyLoadError)
 		}
-		fmt.Printf("%s[%s]: info=docker.connect.error message='%s'\n", appName, cmdName, exitMsg)
-		fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
-		commands.Exit(commands.ECTCommon | commands.ECNoDockerConnectInfo)
-	}
-	errutil.FailOn(err)
 
-	if gparams.Debug {
-		version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
+		cmdReport.PolicyLocation = policyPath
 	}
 
-	imageInspector, err := image.NewInspector(client, targetRef)
-	errutil.FailOn(err)
+	var (
+		imageID          string
+		iaPath           string
+		artifactLocation string
+		doRmImageArchive = true
+		dockerfileInfo   *reverse.Dockerfile
+		imagePkg         *dockerimage.Package
+		err              error
+	)
+
+	if targetArchive != "" || targetOCILayout != "" {
+		fmt.Printf("%s[%s]: state=image.archive.inspection.start\n", appName, cmdName)
+
+		var archiveImage *dockerimage.ArchiveImage
+		if targetOCILayout != "" {
+			archiveImage, err = dockerimage.FindOCILayoutImage(targetOCILayout, targetRef)
+		} else {
+			archiveImage, err = dockerimage.FindArchiveImage(targetArchive, targetRef)
+		}
 
-	if imageInspector.NoImage() {
-		fmt.Printf("%s[%s]: info=target.image.error status=not.found image='%v' message='make sure the target image already exists locally'\n", appName, cmdName, targetRef)
-		fmt.Printf("%s[%s]: state=exited\n", appName, cmdName)
-		return
-	}
+		if err == dockerimage.ErrNoArchiveImage || err == dockerimage.ErrNoOCILayoutImage {
+			fmt.Printf("%s[%s]: info=target.image.error status=not.found image='%v' archive='%v' oci.layout='%v' message='make sure the target image is in the archive or OCI layout'\n",
+				appName, cmdName, targetRef, targetArchive, targetOCILayout)
+			fmt.Printf("%s[%s]: state=exited\n", appName, cmdName)
+			if imagePolicy != nil {
+				//the policy can't be checked (the policy checks must not pass silently)
+				commands.Exit(commands.ECTXray | ecxNoImage)
+			}
 
-	fmt.Printf("%s[%s]: state=image.api.inspection.start\n", appName, cmdName)
+			return
+		}
+		errutil.FailOn(err)
+
+		imageID = archiveImage.ID
+		localVolumePath, aLocation, statePath, stateKey := fsutil.PrepareImageStateDirs(gparams.StatePath, imageID)
+		artifactLocation = aLocation
+		logger.Debugf("localVolumePath=%v, artifactLocation=%v, statePath=%v, stateKey=%v", localVolumePath, artifactLocation, statePath, stateKey)
+
+		iaPath = filepath.Join(localVolumePath, "image", fmt.Sprintf("%s.tar", imageID))
+		switch {
+		case targetOCILayout != "":
+			err = dockerimage.SaveDockerArchiveFromOCILayout(targetOCILayout, archiveImage, iaPath)
+		case archiveImage.IsLoadable():
+			//using the target archive as is (it must not be removed)
+			iaPath, err = filepath.Abs(targetArchive)
+			doRmImageArchive = false
+		default:
+			err = dockerimage.SaveDockerArchive(targetArchive, archiveImage, iaPath)
+		}
+		errutil.FailOn(err)
 
-	logger.Info("inspecting 'fat' image metadata...")
-	err = imageInspector.Inspect()
-	errutil.FailOn(err)
+		imagePkg, err = dockerimage.LoadPackage(iaPath, imageID, false, doHashData)
+		errutil.FailOn(err)
 
-	localVolumePath, artifactLocation, statePath, stateKey := fsutil.PrepareImageStateDirs(gparams.StatePath, imageInspector.ImageInfo.ID)
-	imageInspector.ArtifactLocation = artifactLocation
-	logger.Debugf("localVolumePath=%v, artifactLocation=%v, statePath=%v, stateKey=%v", localVolumePath, artifactLocation, statePath, stateKey)
+		dockerfileInfo, err = reverse.DockerfileFromHistoryData(imagePkg.ImageHistory(imageID, archiveImage.RepoTags))
+		errutil.FailOn(err)
 
-	fmt.Printf("%s[%s]: info=image id=%v size.bytes=%v size.human='%v'\n",
-		appName, cmdName,
-		imageInspector.ImageInfo.ID,
-		imageInspector.ImageInfo.VirtualSize,
-		humanize.Bytes(uint64(imageInspector.ImageInfo.VirtualSize)))
+		err = reverse.SaveDockerfileData(filepath.Join(artifactLocation, fatDockerfileName), dockerfileInfo.Lines)
+		errutil.FailOn(err)
 
-	logger.Info("processing 'fat' image info...")
-	err = imageInspector.ProcessCollectedData()
-	errutil.FailOn(err)
+		var imageSize int64
+		for _, layer := range imagePkg.Layers {
+			imageSize += int64(layer.Stats.AllSize)
+		}
 
-	if imageInspector.DockerfileInfo != nil {
-		if imageInspector.DockerfileInfo.ExeUser != "" {
+		cmdReport.SourceImage = report.ImageMetadata{
+			AllNames:      archiveImage.RepoTags,
+			ID:            fmt.Sprintf("sha256:%s", imageID),
+			Size:          imageSize,
+			SizeHuman:     humanize.Bytes(uint64(imageSize)),
+			CreateTime:    imagePkg.Config.Created.UTC().Format(time.RFC3339),
+			Author:        imagePkg.Config.Author,
+			DockerVersion: imagePkg.Config.DockerVersion,
+			Architecture:  imagePkg.Config.Architecture,
+		}
+
+		if len(archiveImage.RepoTags) > 0 {
+			cmdReport.SourceImage.Name = archiveImage.RepoTags[0]
+		}
+
+		if imagePkg.Config.Config != nil {
+			cmdReport.SourceImage.User = imagePkg.Config.Config.User
+			for k := range imagePkg.Config.Config.ExposedPorts {
+				cmdReport.SourceImage.ExposedPorts = append(cmdReport.SourceImage.ExposedPorts, k)
+			}
+		}
+
+		cmdReport.ArtifactLocation = artifactLocation
+
+		fmt.Printf("%s[%s]: info=image id=%v size.bytes=%v size.human='%v'\n",
+			appName, cmdName,
+			cmdReport.SourceImage.ID,
+			cmdReport.SourceImage.Size,
+			cmdReport.SourceImage.SizeHuman)
+
+		fmt.Printf("%s[%s]: state=image.archive.inspection.done\n", appName, cmdName)
+	} else {
+		client, err := dockerclient.New(gparams.ClientConfig)
+		if err == dockerclient.ErrNoDockerInfo {
+			exitMsg := "missing Docker connection info"
+			if gparams.InContainer && gparams.IsDSImage {
+				exitMsg = "make sure to pass the Docker connect parameters to the docker-slim container"
+			}
+			fmt.Printf("%s[%s]: info=docker.connect.error message='%s'\n", appName, cmdName, exitMsg)
+			fmt.Printf("%s[%s]: state=exited version=%s location='%s'\n", appName, cmdName, v.Current(), fsutil.ExeDir())
+			commands.Exit(commands.ECTCommon | commands.ECNoDockerConnectInfo)
+		}
+		errutil.FailOn(err)
+
+		if gparams.Debug {
+			version.Print(prefix, logger, client, false, gparams.InContainer, gparams.IsDSImage)
+		}
+
+		imageInspector, err := image.NewInspector(client, targetRef)
+		errutil.FailOn(err)
+
+		if imageInspector.NoImage() {
+			fmt.Printf("%s[%s]: info=target.image.error status=not.found image='%v' message='make sure the target image already exists locally'\n", appName, cmdName, targetRef)
+			fmt.Printf("%s[%s]: state=exited\n", appName, cmdName)
+			if imagePolicy != nil {
+				//the policy can't be checked (the policy checks must not pass silently)
+				commands.Exit(commands.ECTXray | ecxNoImage)
+			}
+
+			return
+		}
+
+		fmt.Printf("%s[%s]: state=image.api.inspection.start\n", appName, cmdName)
+
+		logger.Info("inspecting 'fat' image metadata...")
+		err = imageInspector.Inspect()
+		errutil.FailOn(err)
+
+		localVolumePath, aLocation, statePath, stateKey := fsutil.PrepareImageStateDirs(gparams.StatePath, imageInspector.ImageInfo.ID)
+		artifactLocation = aLocation
+		imageInspector.ArtifactLocation = artifactLocation
+		logger.Debugf("localVolumePath=%v, artifactLocation=%v, statePath=%v, stateKey=%v", localVolumePath, artifactLocation, statePath, stateKey)
+
+		fmt.Printf("%s[%s]: info=image id=%v size.bytes=%v size.human='%v'\n",
+			appName, cmdName,
+			imageInspector.ImageInfo.ID,
+			imageInspector.ImageInfo.VirtualSize,
+			humanize.Bytes(uint64(imageInspector.ImageInfo.VirtualSize)))
+
+		logger.Info("processing 'fat' image info...")
+		err = imageInspector.ProcessCollectedData()
+		errutil.FailOn(err)
+
+		dockerfileInfo = imageInspector.DockerfileInfo
+
+		cmdReport.SourceImage = report.ImageMetadata{
+			AllNames:      imageInspector.ImageRecordInfo.RepoTags,
+			ID:            imageInspector.ImageRecordInfo.ID,
+			Size:          imageInspector.ImageInfo.VirtualSize,
+			SizeHuman:     humanize.Bytes(uint64(imageInspector.ImageInfo.VirtualSize)),
+			CreateTime:    imageInspector.ImageInfo.Created.UTC().Format(time.RFC3339),
+			Author:        imageInspector.ImageInfo.Author,
+			DockerVersion: imageInspector.ImageInfo.DockerVersion,
+			Architecture:  imageInspector.ImageInfo.Architecture,
+			User:          imageInspector.ImageInfo.Config.User,
+		}
+
+		if len(imageInspector.ImageRecordInfo.RepoTags) > 0 {
+			cmdReport.SourceImage.Name = imageInspector.ImageRecordInfo.RepoTags[0]
+		}
+
+		if len(imageInspector.ImageInfo.Config.ExposedPorts) > 0 {
+			for k := range imageInspector.ImageInfo.Config.ExposedPorts {
+				cmdReport.SourceImage.ExposedPorts = append(cmdReport.SourceImage.ExposedPorts, string(k))
+			}
+		}
+
+		cmdReport.ArtifactLocation = imageInspector.ArtifactLocation
+
+		fmt.Printf("%s[%s]: state=image.api.inspection.done\n", appName, cmdName)
+		fmt.Printf("%s[%s]: state=image.data.inspection.start\n", appName, cmdName)
+
+		imageID = dockerutil.CleanImageID(imageInspector.ImageInfo.ID)
+		iaName := fmt.Sprintf("%s.tar", imageID)
+		iaPath = filepath.Join(localVolumePath, "image", iaName)
+		err = dockerutil.SaveImage(client, imageID, iaPath, false, false)
+		errutil.FailOn(err)
+
+		imagePkg, err = dockerimage.LoadPackage(iaPath, imageID, false, doHashData)
+		errutil.FailOn(err)
+
+		fmt.Printf("%s[%s]: state=image.data.inspection.done\n", appName, cmdName)
+	}
+
+	if dockerfileInfo != nil {
+		if dockerfileInfo.ExeUser != "" {
 			fmt.Printf("%s[%s]: info=image.users exe='%v' all='%v'\n",
 				appName, cmdName,
-				imageInspector.DockerfileInfo.ExeUser,
-				strings.Join(imageInspector.DockerfileInfo.AllUsers, ","))
+				dockerfileInfo.ExeUser,
+				strings.Join(dockerfileInfo.AllUsers, ","))
 		}
 
-		if len(imageInspector.DockerfileInfo.ImageStack) > 0 {
-			cmdReport.ImageStack = imageInspector.DockerfileInfo.ImageStack
+		if len(dockerfileInfo.ImageStack) > 0 {
+			cmdReport.ImageStack = dockerfileInfo.ImageStack
 
-			for idx, imageInfo := range imageInspector.DockerfileInfo.ImageStack {
+			for idx, imageInfo := range dockerfileInfo.ImageStack {
 				fmt.Printf("%s[%s]: info=image.stack index=%v name='%v' id='%v' instructions=%v message='see report file for details'\n",
 					appName, cmdName, idx, imageInfo.FullName, imageInfo.ID, len(imageInfo.Instructions))
 			}
 		}
 
-		if len(imageInspector.DockerfileInfo.ExposedPorts) > 0 {
+		if len(dockerfileInfo.ExposedPorts) > 0 {
 			fmt.Printf("%s[%s]: info=image.exposed_ports list='%v'\n", appName, cmdName,
-				strings.Join(imageInspector.DockerfileInfo.ExposedPorts, ","))
+				strings.Join(dockerfileInfo.ExposedPorts, ","))
+		}
+
+		if len(dockerfileInfo.AllInstructions) == len(imagePkg.Config.History) {
+			for instIdx, instInfo := range dockerfileInfo.AllInstructions {
+				instInfo.Author = imagePkg.Config.History[instIdx].Author
+				instInfo.EmptyLayer = imagePkg.Config.History[instIdx].EmptyLayer
+				instInfo.LayerID = imagePkg.Config.History[instIdx].LayerID
+				instInfo.LayerIndex = imagePkg.Config.History[instIdx].LayerIndex
+				instInfo.LayerFSDiffID = imagePkg.Config.History[instIdx].LayerFSDiffID
+			}
+		} else {
+			logger.Debugf("history instruction set size mismatch - %v/%v ",
+				len(dockerfileInfo.AllInstructions),
+				len(imagePkg.Config.History))
 		}
 	}
 
-	cmdReport.SourceImage = report.ImageMetadata{
-		AllNames:      imageInspector.ImageRecordInfo.RepoTags,
-		ID:            imageInspector.ImageRecordInfo.ID,
-		Size:          imageInspector.ImageInfo.VirtualSize,
-		SizeHuman:     humanize.Bytes(uint64(imageInspector.ImageInfo.VirtualSize)),
-		CreateTime:    imageInspector.ImageInfo.Created.UTC().Format(time.RFC3339),
-		Author:        imageInspector.ImageInfo.Author,
-		DockerVersion: imageInspector.ImageInfo.DockerVersion,
-		Architecture:  imageInspector.ImageInfo.Architecture,
-		User:          imageInspector.ImageInfo.Config.User,
+	if doCompressedSize {
+		calculateBlobSizes(imagePkg, iaPath, appName, cmdName, cmdReport)
 	}
 
-	if len(imageInspector.ImageRecordInfo.RepoTags) > 0 {
-		cmdReport.SourceImage.Name = imageInspector.ImageRecordInfo.RepoTags[0]
+	printImagePackage(imagePkg, appName, cmdName, changes, layers, cmdReport)
+	printImageEfficiency(imagePkg, appName, cmdName, cmdReport)
+	printReclaimableSpace(imagePkg, dockerfileInfo, appName, cmdName, cmdReport)
+	printFileSecurity(imagePkg, iaPath, appName, cmdName, cmdReport)
+	printEntrypoint(imagePkg, iaPath, appName, cmdName, cmdReport)
+
+	if doHashData {
+		printDuplicates(imagePkg, appName, cmdName, cmdReport)
 	}
 
-	if len(imageInspector.ImageInfo.Config.ExposedPorts) > 0 {
-		for k := range imageInspector.ImageInfo.Config.ExposedPorts {
-			cmdReport.SourceImage.ExposedPorts = append(cmdReport.SourceImage.ExposedPorts, string(k))
-		}
+	if doElfInventory {
+		printElfInventory(imagePkg, iaPath, appName, cmdName, cmdReport)
+	}
+
+	if doScanSecrets {
+		scanSecrets(imagePkg, iaPath, appName, cmdName, cmdReport)
 	}
 
-	cmdReport.ArtifactLocation = imageInspector.ArtifactLocation
+	if len(extractPaths) > 0 || exportLayer != "" {
+		extractImageData(imagePkg, iaPath, imageID, extractPaths, exportLayer, output, appName, cmdName, cmdReport)
+	}
 
-	fmt.Printf("%s[%s]: state=image.api.inspection.done\n", appName, cmdName)
-	fmt.Printf("%s[%s]: state=image.data.inspection.start\n", appName, cmdName)
+	if imagePolicy != nil {
+		checkImagePolicy(imagePkg, iaPath, imagePolicy, appName, cmdName, cmdReport)
+	}
 
-	imageID := dockerutil.CleanImageID(imageInspector.ImageInfo.ID)
-	iaName := fmt.Sprintf("%s.tar", imageID)
-	iaPath := filepath.Join(localVolumePath, "image", iaName)
-	err = dockerutil.SaveImage(client, imageID, iaPath, false, false)
-	errutil.FailOn(err)
+	if sbomFormat != "" {
+		if sbomFile == "" {
+			sbomFile = filepath.Join(artifactLocation, dockerimage.SBOMFileName(sbomFormat))
+		}
 
-	imagePkg, err := dockerimage.LoadPackage(iaPath, imageID, false)
-	errutil.FailOn(err)
+		saveSBOM(imagePkg, iaPath, sbomFormat, sbomFile, appName, cmdName, cmdReport)
+	}
 
-	fmt.Printf("%s[%s]: state=image.data.inspection.done\n", appName, cmdName)
+	if compareRef != "" {
+		compareImages(gparams, imagePkg, imageID, targetArchive, targetOCILayout, compareRef, appName, cmdName, cmdReport)
+	}
 
-	if len(imageInspector.DockerfileInfo.AllInstructions) == len(imagePkg.Config.History) {
-		for instIdx, instInfo := range imageInspector.DockerfileInfo.AllInstructions {
-			instInfo.Author = imagePkg.Config.History[instIdx].Author
-			instInfo.EmptyLayer = imagePkg.Config.History[instIdx].EmptyLayer
-			instInfo.LayerID = imagePkg.Config.History[instIdx].LayerID
-			instInfo.LayerIndex = imagePkg.Config.History[instIdx].LayerIndex
-			instInfo.LayerFSDiffID = imagePkg.Config.History[instIdx].LayerFSDiffID
+	if doUI {
+		uiTitle := targetRef
+		switch {
+		case targetArchive != "":
+			uiTitle = targetArchive
+		case targetOCILayout != "":
+			uiTitle = targetOCILayout
 		}
-	} else {
-		logger.Debugf("history instruction set size mismatch - %v/%v ",
-			len(imageInspector.DockerfileInfo.AllInstructions),
-			len(imagePkg.Config.History))
-	}
 
-	printImagePackage(imagePkg, appName, cmdName, changes, layers, cmdReport)
+		if err := runLayerBrowser(imagePkg, dockerfileInfo, uiTitle); err != nil {
+			fmt.Printf("%s[%s]: info=ui.error message='%v'\n", appName, cmdName, err)
+		}
+	}
 
 	if doAddImageManifest {
 		cmdReport.RawImageManifest = imagePkg.Manifest
@@ -186,7 +391,7 @@ func OnCommand(
 	fmt.Printf("%s[%s]: state=completed\n", appName, cmdName)
 	cmdReport.State = command.StateCompleted
 
-	if doRmFileArtifacts {
+	if doRmFileArtifacts && doRmImageArchive {
 		logger.Info("removing temporary artifacts...")
 		err = fsutil.Remove(iaPath)
 		errutil.WarnOn(err)
@@ -206,6 +411,38 @@ func OnCommand(
 	if cmdReport.Save() {
 		fmt.Printf("%s[%s]: info=report file='%s'\n", appName, cmdName, cmdReport.ReportLocation())
 	}
+
+	if len(cmdReport.PolicyViolations) > 0 {
+		commands.Exit(commands.ECTXray | ecxPolicyViolation)
+	}
+}
+
+func checkImagePolicy(pkg *dockerimage.Package,
+	archivePath string,
+	policy *dockerimage.ImagePolicy,
+	appName string,
+	cmdName command.Type,
+	cmdReport *report.XrayCommand) {
+	violations, err := pkg.CheckPolicy(archivePath, policy)
+	errutil.FailOn(err)
+
+	cmdReport.PolicyViolations = violations
+
+	status := "passed"
+	if len(violations) > 0 {
+		status = "failed"
+	}
+
+	fmt.Printf("%s[%s]: info=image.policy status=%s violations=%v\n", appName, cmdName, status, len(violations))
+	for _, violation := range violations {
+		fmt.Printf("%s[%s]: info=image.policy.violation rule=%s message='%s'\n",
+			appName, cmdName, violation.Rule, violation.Message)
+
+		for _, path := range violation.Paths {
+			fmt.Printf("%s[%s]: info=image.policy.violation.path rule=%s path='%s'\n",
+				appName, cmdName, violation.Rule, path)
+		}
+	}
 }
 
 func printImagePackage(pkg *dockerimage.Package,
@@ -225,6 +462,14 @@ func printImagePackage(pkg *dockerimage.Package,
 				appName, cmdName, humanize.Bytes(uint64(layer.Stats.AllSize)), layer.Stats.AllSize)
 		}
 
+		if layer.Stats.BlobSize != 0 {
+			fmt.Printf("%s[%s]: info=layer.stats tar_size.human='%v' blob_size.human='%v' blob_size.bytes=%v zstd_blob_size.human='%v' zstd_blob_size.bytes=%v\n",
+				appName, cmdName,
+				humanize.Bytes(layer.Stats.TarSize),
+				humanize.Bytes(layer.Stats.BlobSize), layer.Stats.BlobSize,
+				humanize.Bytes(layer.Stats.ZstdBlobSize), layer.Stats.ZstdBlobSize)
+		}
+
 		if layer.Stats.ObjectCount != 0 {
 			fmt.Printf("%s[%s]: info=layer.stats object_count=%v\n", appName, cmdName, layer.Stats.ObjectCount)
 		}
@@ -364,3 +609,589 @@ func printObject(object *dockerimage.ObjectMetadata) {
 		fmt.Printf("\n")
 	}
 }
+
+func calculateBlobSizes(pkg *dockerimage.Package,
+	archivePath string,
+	appName string,
+	cmdName command.Type,
+	cmdReport *report.XrayCommand) {
+	fmt.Printf("%s[%s]: state=image.blob.size.start\n", appName, cmdName)
+
+	blobSizes, err := pkg.CalculateBlobSizes(archivePath)
+	errutil.FailOn(err)
+
+	cmdReport.BlobSizes = blobSizes
+	fmt.Printf("%s[%s]: info=image.blob.size tar_size.human='%v' tar_size.bytes=%v gzip_size.human='%v' gzip_size.bytes=%v zstd_size.human='%v' zstd_size.bytes=%v\n",
+		appName, cmdName,
+		humanize.Bytes(blobSizes.TarSize), blobSizes.TarSize,
+		humanize.Bytes(blobSizes.GzipSize), blobSizes.GzipSize,
+		humanize.Bytes(blobSizes.ZstdSize), blobSizes.ZstdSize)
+
+	fmt.Printf("%s[%s]: state=image.blob.size.done\n", appName, cmdName)
+}
+
+func printImageEfficiency(pkg *dockerimage.Package,
+	appName string,
+	cmdName command.Type,
+	cmdReport *report.XrayCommand) {
+	efficiency := pkg.AnalyzeEfficiency(wastedPathsMax)
+	cmdReport.ImageEfficiency = efficiency
+
+	fmt.Printf("%s[%s]: info=image.efficiency score=%.2f%% all_size.human='%v' wasted_size.human='%v' wasted_size.bytes=%v wasted_count=%v\n",
+		appName, cmdName,
+		efficiency.Score*100,
+		humanize.Bytes(efficiency.AllSize),
+		humanize.Bytes(efficiency.WastedSize),
+		efficiency.WastedSize,
+		efficiency.WastedCount)
+
+	for _, reason := range []string{dockerimage.WasteModified, dockerimage.WasteDeleted, dockerimage.WasteDuplicated} {
+		if size := efficiency.Totals[reason]; size > 0 {
+			fmt.Printf("%s[%s]: info=image.efficiency.totals reason=%s wasted_size.human='%v' wasted_size.bytes=%v\n",
+				appName, cmdName, reason, humanize.Bytes(size), size)
+		}
+	}
+
+	for _, layer := range efficiency.Layers {
+		if layer.WastedSize == 0 {
+			continue
+		}
+
+		fmt.Printf("%s[%s]: info=image.efficiency.layer index=%d id=%s wasted_size.human='%v' wasted_size.bytes=%v wasted_count=%v\n",
+			appName, cmdName, layer.Index, layer.ID, humanize.Bytes(layer.WastedSize), layer.WastedSize, layer.WastedCount)
+	}
+
+	for _, wp := range efficiency.WastedPaths {
+		fmt.Printf("%s[%s]: info=image.efficiency.wasted path='/%s' wasted_size.human='%v' wasted_size.bytes=%v count=%v layers=%v reasons=%v\n",
+			appName, cmdName, wp.Path, humanize.Bytes(wp.WastedSize), wp.WastedSize, wp.Count,
+			strings.Trim(fmt.Sprint(wp.Layers), "[]"), strings.Join(wp.Reasons, ","))
+	}
+}
+
+func printReclaimableSpace(pkg *dockerimage.Package,
+	dockerfileInfo *reverse.Dockerfile,
+	appName string,
+	cmdName command.Type,
+	cmdReport *report.XrayCommand) {
+	reclaimable := pkg.AnalyzeReclaimableSpace()
+	cmdReport.ReclaimableSpace = reclaimable
+
+	//the layer index is set for the instructions when the image history matches the layers
+	instructions := map[int]*reverse.InstructionInfo{}
+	if dockerfileInfo != nil {
+		for _, instInfo := range dockerfileInfo.AllInstructions {
+			if !instInfo.EmptyLayer && instInfo.LayerID != "" && instInfo.LayerIndex >= 0 {
+				instructions[instInfo.LayerIndex] = instInfo
+			}
+		}
+	}
+
+	fmt.Printf("%s[%s]: info=image.reclaimable size.human='%v' size.bytes=%v count=%v\n",
+		appName, cmdName, humanize.Bytes(reclaimable.Size), reclaimable.Size, reclaimable.Count)
+
+	for _, category := range reclaimable.Categories {
+		fmt.Printf("%s[%s]: info=image.reclaimable.category name=%s size.human='%v' size.bytes=%v count=%v\n",
+			appName, cmdName, category.Name, humanize.Bytes(category.Size), category.Size, category.Count)
+	}
+
+	for _, layer := range reclaimable.Layers {
+		var snippet string
+		if instInfo, ok := instructions[layer.Index]; ok {
+			layer.Instruction = instInfo.CommandAll
+			snippet = instInfo.CommandSnippet
+		}
+
+		fmt.Printf("%s[%s]: info=image.reclaimable.layer index=%d id=%s size.human='%v' size.bytes=%v count=%v instruction='%s'\n",
+			appName, cmdName, layer.Index, layer.ID, humanize.Bytes(layer.Size), layer.Size, layer.Count, snippet)
+
+		for _, category := range layer.Categories {
+			fmt.Printf("%s[%s]: info=image.reclaimable.layer.category index=%d name=%s size.human='%v' size.bytes=%v count=%v fix='%s'\n",
+				appName, cmdName, layer.Index, category.Name, humanize.Bytes(category.Size), category.Size, category.Count, category.Fix)
+		}
+	}
+}
+
+func printFileSecurity(pkg *dockerimage.Package,
+	archivePath string,
+	appName string,
+	cmdName command.Type,
+	cmdReport *report.XrayCommand) {
+	security, err := pkg.AnalyzeFileSecurity(archivePath)
+	errutil.FailOn(err)
+
+	cmdReport.FileSecurity = security
+
+	fmt.Printf("%s[%s]: info=image.security setuid=%v setgid=%v world_writable=%v unexpected_owner=%v capabilities=%v expected_uids=%v\n",
+		appName, cmdName,
+		len(security.Setuid),
+		len(security.Setgid),
+		len(security.WorldWritable),
+		len(security.UnexpectedOwner),
+		len(security.Capabilities),
+		strings.Trim(fmt.Sprint(security.ExpectedUIDs), "[]"))
+
+	printObjects := func(category string, objects []*dockerimage.SecurityObject) {
+		for _, object := range objects {
+			fmt.Printf("%s[%s]: info=image.security.%s layer=%v mode=%s uid=%v gid=%v path='%s'",
+				appName, cmdName, category, object.LayerIndex, object.ModeHuman, object.UID, object.GID, object.Path)
+			if object.Capabilities != "" {
+				fmt.Printf(" capabilities='%s'", object.Capabilities)
+			}
+
+			fmt.Printf("\n")
+		}
+	}
+
+	printObjects("setuid", security.Setuid)
+	printObjects("setgid", security.Setgid)
+	printObjects("world_writable", security.WorldWritable)
+	printObjects("unexpected_owner", security.UnexpectedOwner)
+	printObjects("capabilities", security.Capabilities)
+}
+
+func printEntrypoint(pkg *dockerimage.Package,
+	archivePath string,
+	appName string,
+	cmdName command.Type,
+	cmdReport *report.XrayCommand) {
+	info, err := pkg.ResolveEntrypoint(archivePath)
+	if err != nil {
+		errutil.WarnOn(err)
+		fmt.Printf("%s[%s]: info=image.entrypoint.error message='%v'\n", appName, cmdName, err)
+		return
+	}
+
+	cmdReport.Entrypoint = info
+
+	fmt.Printf("%s[%s]: info=image.entrypoint entrypoint='%s' cmd='%s' shell_form=%v workdir='%s'\n",
+		appName, cmdName,
+		strings.Join(info.Entrypoint, " "),
+		strings.Join(info.Cmd, " "),
+		info.ShellForm,
+		info.WorkingDir)
+
+	for idx, exe := range info.Chain {
+		fmt.Printf("%s[%s]: info=image.entrypoint.chain index=%v via=%s name='%s'",
+			appName, cmdName, idx, exe.Via, exe.Name)
+		if exe.Path != "" {
+			fmt.Printf(" path='%s' layer=%v type=%s", exe.Path, exe.LayerIndex, exe.Type)
+		}
+
+		if exe.Interpreter != "" {
+			fmt.Printf(" interpreter='%s'", exe.Interpreter)
+		}
+
+		fmt.Printf("\n")
+	}
+
+	if info.Error != "" {
+		fmt.Printf("%s[%s]: info=image.entrypoint.error message='%s'\n", appName, cmdName, info.Error)
+	}
+}
+
+func extractImageData(pkg *dockerimage.Package,
+	archivePath string,
+	imageID string,
+	extractPaths []string,
+	exportLayer string,
+	output string,
+	appName string,
+	cmdName command.Type,
+	cmdReport *report.XrayCommand) {
+	for _, name := range extractPaths {
+		result, err := pkg.ExtractFinalPath(archivePath, name, output)
+		if err == dockerimage.ErrPathNotFound {
+			fmt.Printf("%s[%s]: info=image.extract.error path='%s' message='%v'\n", appName, cmdName, name, err)
+			continue
+		}
+		errutil.FailOn(err)
+
+		cmdReport.ExtractedPaths = append(cmdReport.ExtractedPaths, &report.XrayExtractInfo{
+			Path:   name,
+			Output: output,
+			Result: result,
+		})
+
+		fmt.Printf("%s[%s]: info=image.extract path='%s' output='%s' objects=%v size.human='%v' size.bytes=%v skipped=%v\n",
+			appName, cmdName, name, output, result.Objects,
+			humanize.Bytes(result.Size), result.Size, result.Skipped)
+	}
+
+	if exportLayer == "" {
+		return
+	}
+
+	layer := pkg.FindLayer(exportLayer)
+	if layer == nil {
+		fmt.Printf("%s[%s]: info=image.export.layer.error layer='%s' message='%v'\n",
+			appName, cmdName, exportLayer, dockerimage.ErrLayerNotFound)
+		return
+	}
+
+	layerData, result, err := dockerimage.ExportLayer(archivePath, imageID, layer.ID, output)
+	errutil.FailOn(err)
+
+	cmdReport.ExportedLayer = &report.XrayExtractInfo{
+		Layer:  exportLayer,
+		Output: output,
+		Result: result,
+	}
+
+	fmt.Printf("%s[%s]: info=image.export.layer index=%v id='%s' output='%s' objects=%v size.human='%v' size.bytes=%v whiteouts=%v skipped=%v\n",
+		appName, cmdName, layerData.Index, layerData.ID, output, result.Objects,
+		humanize.Bytes(result.Size), result.Size, result.Whiteouts, result.Skipped)
+}
+
+func compareImages(gparams *commands.GenericParams,
+	pkg *dockerimage.Package,
+	imageID string,
+	targetArchive string,
+	targetOCILayout string,
+	compareRef string,
+	appName string,
+	cmdName command.Type,
+	cmdReport *report.XrayCommand) {
+	fmt.Printf("%s[%s]: state=image.compare.start\n", appName, cmdName)
+
+	//the compared image archive is saved in a temporary directory
+	//(the state directories for the image ID can belong to the target image)
+	tmpDir, err := ioutil.TempDir("", "docker-slim-compare")
+	if err != nil {
+		fmt.Printf("%s[%s]: info=image.compare.error image='%s' message='%v'\n", appName, cmdName, compareRef, err)
+		return
+	}
+
+	defer func() {
+		errutil.WarnOn(os.RemoveAll(tmpDir))
+	}()
+
+	comparePkg, err := loadCompareImage(gparams, pkg, imageID, targetArchive, targetOCILayout, compareRef, tmpDir)
+	if err != nil {
+		fmt.Printf("%s[%s]: info=image.compare.error image='%s' message='%v'\n", appName, cmdName, compareRef, err)
+		return
+	}
+
+	//the compared image is the baseline
+	comparison := dockerimage.CompareImages(comparePkg, pkg)
+	cmdReport.CompareReference = compareRef
+	cmdReport.Comparison = comparison
+
+	sizeDelta := humanize.Bytes(uint64(comparison.SizeDelta))
+	if comparison.SizeDelta < 0 {
+		sizeDelta = "-" + humanize.Bytes(uint64(-comparison.SizeDelta))
+	}
+
+	fmt.Printf("%s[%s]: info=image.compare image='%s' id=%s size.human='%v' target.size.human='%v' size.delta.human='%v' size.delta.bytes=%v\n",
+		appName, cmdName, compareRef, comparison.FromImageID,
+		humanize.Bytes(uint64(comparison.FromSize)),
+		humanize.Bytes(uint64(comparison.ToSize)),
+		sizeDelta, comparison.SizeDelta)
+
+	fmt.Printf("%s[%s]: info=image.compare.layers shared=%v target.unique=%v compared.unique=%v\n",
+		appName, cmdName, len(comparison.SharedLayers), len(comparison.ToLayers), len(comparison.FromLayers))
+
+	for _, layer := range comparison.SharedLayers {
+		fmt.Printf("%s[%s]: info=image.compare.layer status=shared target.index=%v compared.index=%v size.human='%v' fsdiff='%s'\n",
+			appName, cmdName, layer.ToIndex, layer.FromIndex, humanize.Bytes(layer.Size), layer.FSDiffID)
+	}
+
+	printComparedLayers(appName, cmdName, "target", comparison.ToLayers)
+	printComparedLayers(appName, cmdName, "compared", comparison.FromLayers)
+
+	files := &comparison.Files
+	fmt.Printf("%s[%s]: info=image.compare.files removed=%d removed_size.human='%v' modified=%d added=%d added_size.human='%v'\n",
+		appName, cmdName,
+		files.Summary.RemovedCount,
+		humanize.Bytes(uint64(files.Summary.RemovedSize)),
+		files.Summary.ModifiedCount,
+		files.Summary.AddedCount,
+		humanize.Bytes(uint64(files.Summary.AddedSize)))
+
+	printComparedFiles(appName, cmdName, dockerimage.DiffAdded, files.Added)
+	printComparedFiles(appName, cmdName, dockerimage.DiffModified, files.Kept)
+	printComparedFiles(appName, cmdName, dockerimage.DiffRemoved, files.Removed)
+
+	fmt.Printf("%s[%s]: info=image.compare.config changes=%d\n", appName, cmdName, len(comparison.Config))
+	for _, change := range comparison.Config {
+		fmt.Printf("%s[%s]: info=image.compare.config.change field=%s key='%s' change=%s from='%s' to='%s'\n",
+			appName, cmdName, change.Field, change.Key, change.Change, change.From, change.To)
+	}
+
+	fmt.Printf("%s[%s]: state=image.compare.done\n", appName, cmdName)
+}
+
+// loadCompareImage loads the compared image from the target image archive (or OCI layout)
+// or using the Docker daemon (the target image package is used if it's the same image;
+// the other images are saved in the temporary directory)
+func loadCompareImage(gparams *commands.GenericParams,
+	pkg *dockerimage.Package,
+	imageID string,
+	targetArchive string,
+	targetOCILayout string,
+	compareRef string,
+	tmpDir string) (*dockerimage.Package, error) {
+	if targetArchive != "" || targetOCILayout != "" {
+		var archiveImage *dockerimage.ArchiveImage
+		var err error
+		if targetOCILayout != "" {
+			archiveImage, err = dockerimage.FindOCILayoutImage(targetOCILayout, compareRef)
+		} else {
+			archiveImage, err = dockerimage.FindArchiveImage(targetArchive, compareRef)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if archiveImage.ID == imageID {
+			return pkg, nil
+		}
+
+		archivePath := filepath.Join(tmpDir, fmt.Sprintf("%s.tar", archiveImage.ID))
+		switch {
+		case targetOCILayout != "":
+			err = dockerimage.SaveDockerArchiveFromOCILayout(targetOCILayout, archiveImage, archivePath)
+		case archiveImage.IsLoadable():
+			archivePath, err = filepath.Abs(targetArchive)
+		default:
+			err = dockerimage.SaveDockerArchive(targetArchive, archiveImage, archivePath)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return dockerimage.LoadPackage(archivePath, archiveImage.ID, false, false)
+	}
+
+	client, err := dockerclient.New(gparams.ClientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	imageInspector, err := image.NewInspector(client, compareRef)
+	if err != nil {
+		return nil, err
+	}
+
+	if imageInspector.NoImage() {
+		return nil, errCompareImageNotFound
+	}
+
+	if err := imageInspector.Inspect(); err != nil {
+		return nil, err
+	}
+
+	compareID := dockerutil.CleanImageID(imageInspector.ImageInfo.ID)
+	if compareID == imageID {
+		return pkg, nil
+	}
+
+	archivePath := filepath.Join(tmpDir, fmt.Sprintf("%s.tar", compareID))
+	if err := dockerutil.SaveImage(client, compareID, archivePath, false, false); err != nil {
+		return nil, err
+	}
+
+	return dockerimage.LoadPackage(archivePath, compareID, false, false)
+}
+
+func printComparedLayers(appName string, cmdName command.Type, image string, layers []*dockerimage.ComparedLayer) {
+	for _, layer := range layers {
+		fmt.Printf("%s[%s]: info=image.compare.layer status=unique image=%s index=%v size.human='%v' id='%s' created_by='%s'\n",
+			appName, cmdName, image, layer.Index, humanize.Bytes(layer.Size), layer.ID, layer.CreatedBy)
+	}
+}
+
+// printComparedFiles prints the biggest changed files
+func printComparedFiles(appName string, cmdName command.Type, change string, objects []*dockerimage.DiffObject) {
+	var files []*dockerimage.DiffObject
+	for _, object := range objects {
+		if object.Type != "dir" {
+			files = append(files, object)
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Size > files[j].Size
+	})
+
+	if len(files) > compareFilesMax {
+		files = files[:compareFilesMax]
+	}
+
+	for _, object := range files {
+		fmt.Printf("%s[%s]: info=image.compare.file change=%s type=%s size.human='%v' layer=%v '%s'\n",
+			appName, cmdName, change, object.Type, humanize.Bytes(uint64(object.Size)), object.LayerIndex, object.Name)
+	}
+}
+
+func printElfInventory(pkg *dockerimage.Package,
+	archivePath string,
+	appName string,
+	cmdName command.Type,
+	cmdReport *report.XrayCommand) {
+	fmt.Printf("%s[%s]: state=image.elf.inspection.start\n", appName, cmdName)
+
+	inventory, err := pkg.InspectElfObjects(archivePath, nil)
+	errutil.FailOn(err)
+
+	cmdReport.ElfInventory = inventory
+
+	fmt.Printf("%s[%s]: info=image.elf count=%v executables=%v shared_libraries=%v static=%v dynamic=%v stripped=%v debug_size.human='%v' debug_size.bytes=%v unresolved=%v architectures=%s\n",
+		appName, cmdName,
+		inventory.Count,
+		inventory.Executables,
+		inventory.SharedLibraries,
+		inventory.Static,
+		inventory.Dynamic,
+		inventory.Stripped,
+		humanize.Bytes(inventory.DebugSize),
+		inventory.DebugSize,
+		inventory.UnresolvedCount,
+		strings.Join(inventory.Architectures, ","))
+
+	var debugObjects []*dockerimage.ElfObject
+	for _, object := range inventory.Objects {
+		if object.DebugSize > 0 {
+			debugObjects = append(debugObjects, object)
+		}
+
+		if object.InterpreterMissing {
+			fmt.Printf("%s[%s]: info=image.elf.unresolved path='%s' layer=%v type=%s interpreter='%s'\n",
+				appName, cmdName, object.Path, object.LayerIndex, object.Type, object.Interpreter)
+		}
+
+		for _, lib := range object.Unresolved {
+			fmt.Printf("%s[%s]: info=image.elf.unresolved path='%s' layer=%v type=%s library='%s'\n",
+				appName, cmdName, object.Path, object.LayerIndex, object.Type, lib)
+		}
+	}
+
+	sort.Slice(debugObjects, func(i, j int) bool {
+		return debugObjects[i].DebugSize > debugObjects[j].DebugSize
+	})
+
+	if len(debugObjects) > elfDebugObjectsMax {
+		debugObjects = debugObjects[:elfDebugObjectsMax]
+	}
+
+	for _, object := range debugObjects {
+		fmt.Printf("%s[%s]: info=image.elf.debug path='%s' layer=%v type=%s arch=%s stripped=%v debug_size.human='%v' debug_size.bytes=%v\n",
+			appName, cmdName, object.Path, object.LayerIndex, object.Type, object.Arch,
+			object.Stripped, humanize.Bytes(object.DebugSize), object.DebugSize)
+	}
+
+	fmt.Printf("%s[%s]: state=image.elf.inspection.done\n", appName, cmdName)
+}
+
+func printDuplicates(pkg *dockerimage.Package,
+	appName string,
+	cmdName command.Type,
+	cmdReport *report.XrayCommand) {
+	duplicates := pkg.FindDuplicates(duplicateGroupsMax)
+	cmdReport.Duplicates = duplicates
+
+	fmt.Printf("%s[%s]: info=image.duplicates groups=%v files=%v all_size.human='%v' reclaimable_size.human='%v' reclaimable_size.bytes=%v\n",
+		appName, cmdName,
+		duplicates.GroupCount,
+		duplicates.FileCount,
+		humanize.Bytes(duplicates.AllSize),
+		humanize.Bytes(duplicates.ReclaimableSize),
+		duplicates.ReclaimableSize)
+
+	for _, group := range duplicates.Groups {
+		fmt.Printf("%s[%s]: info=image.duplicates.group sha1=%s size.human='%v' count=%v reclaimable_size.human='%v' reclaimable_size.bytes=%v\n",
+			appName, cmdName, group.Sha1Hash, humanize.Bytes(group.Size), len(group.Files),
+			humanize.Bytes(group.ReclaimableSize), group.ReclaimableSize)
+
+		for _, file := range group.Files {
+			fmt.Printf("%s[%s]: info=image.duplicates.file sha1=%s layer=%v path='%s'\n",
+				appName, cmdName, group.Sha1Hash, file.LayerIndex, file.Path)
+		}
+	}
+}
+
+func scanSecrets(pkg *dockerimage.Package,
+	archivePath string,
+	appName string,
+	cmdName command.Type,
+	cmdReport *report.XrayCommand) {
+	fmt.Printf("%s[%s]: state=image.secrets.scan.start\n", appName, cmdName)
+
+	findings, err := pkg.ScanSecrets(archivePath)
+	errutil.FailOn(err)
+
+	cmdReport.Secrets = findings
+
+	var hiddenCount int
+	for _, finding := range findings {
+		if finding.Hidden {
+			hiddenCount++
+		}
+
+		fmt.Printf("%s[%s]: info=image.secrets.finding type=%s layer=%v hidden=%v path='%s' line=%v match='%s' description='%s'\n",
+			appName, cmdName, finding.Type, finding.LayerIndex, finding.Hidden,
+			finding.Path, finding.Line, finding.Match, finding.Description)
+	}
+
+	fmt.Printf("%s[%s]: info=image.secrets count=%v hidden=%v\n", appName, cmdName, len(findings), hiddenCount)
+	fmt.Printf("%s[%s]: state=image.secrets.scan.done\n", appName, cmdName)
+}
+
+func saveSBOM(pkg *dockerimage.Package,
+	archivePath string,
+	sbomFormat string,
+	sbomFile string,
+	appName string,
+	cmdName command.Type,
+	cmdReport *report.XrayCommand) {
+	fmt.Printf("%s[%s]: state=image.software.inspection.start\n", appName, cmdName)
+
+	inventory, err := pkg.LoadSoftwareInventory(archivePath, nil)
+	errutil.FailOn(err)
+
+	cmdReport.Software = inventory
+	if inventory.OS != nil {
+		fmt.Printf("%s[%s]: info=software.os id=%s version=%s name='%s'\n",
+			appName, cmdName, inventory.OS.ID, inventory.OS.VersionID, inventory.OS.PrettyName)
+	}
+
+	typeCounts := map[string]int{}
+	var typeNames []string
+	for _, swPkg := range inventory.Packages {
+		if _, ok := typeCounts[swPkg.Type]; !ok {
+			typeNames = append(typeNames, swPkg.Type)
+		}
+
+		typeCounts[swPkg.Type]++
+	}
+
+	fmt.Printf("%s[%s]: info=software.packages count=%d\n", appName, cmdName, len(inventory.Packages))
+	for _, typeName := range typeNames {
+		fmt.Printf("%s[%s]: info=software.packages type=%s count=%d\n", appName, cmdName, typeName, typeCounts[typeName])
+	}
+
+	for _, warning := range inventory.Warnings {
+		fmt.Printf("%s[%s]: info=software.warning message='%s'\n", appName, cmdName, warning)
+	}
+
+	fmt.Printf("%s[%s]: state=image.software.inspection.done\n", appName, cmdName)
+
+	target := dockerimage.SBOMTarget{
+		Name: cmdReport.SourceImage.Name,
+		ID:   cmdReport.SourceImage.ID,
+	}
+
+	data, err := dockerimage.FormatSBOM(inventory, sbomFormat, target)
+	errutil.FailOn(err)
+
+	sbomFile, err = filepath.Abs(sbomFile)
+	errutil.FailOn(err)
+
+	err = ioutil.WriteFile(sbomFile, data, 0644)
+	errutil.FailOn(err)
+
+	cmdReport.SBOMFormat = sbomFormat
+	cmdReport.SBOMLocation = sbomFile
+	fmt.Printf("%s[%s]: info=sbom format=%s file='%s'\n", appName, cmdName, sbomFormat, sbomFile)
+}
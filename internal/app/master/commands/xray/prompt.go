@@ -2,6 +2,7 @@ package xray
 
 import (
 	"github.com/docker-slim/docker-slim/internal/app/master/commands"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
 
 	"github.com/c-bata/go-prompt"
 )
@@ -14,17 +15,42 @@ var CommandSuggestion = prompt.Suggest{
 var CommandFlagSuggestions = &commands.FlagSuggestions{
 	Names: []prompt.Suggest{
 		{Text: commands.FullFlagName(commands.FlagTarget), Description: commands.FlagTargetUsage},
+		{Text: commands.FullFlagName(FlagTargetArchive), Description: FlagTargetArchiveUsage},
+		{Text: commands.FullFlagName(FlagTargetOCILayout), Description: FlagTargetOCILayoutUsage},
 		{Text: commands.FullFlagName(FlagChanges), Description: FlagChangesUsage},
 		{Text: commands.FullFlagName(FlagLayer), Description: FlagLayerUsage},
 		{Text: commands.FullFlagName(FlagAddImageManifest), Description: FlagAddImageManifestUsage},
 		{Text: commands.FullFlagName(FlagAddImageConfig), Description: FlagAddImageConfigUsage},
+		{Text: commands.FullFlagName(FlagSBOMFormat), Description: FlagSBOMFormatUsage},
+		{Text: commands.FullFlagName(FlagSBOMFile), Description: FlagSBOMFileUsage},
+		{Text: commands.FullFlagName(FlagHashData), Description: FlagHashDataUsage},
+		{Text: commands.FullFlagName(FlagScanSecrets), Description: FlagScanSecretsUsage},
+		{Text: commands.FullFlagName(FlagElfInventory), Description: FlagElfInventoryUsage},
+		{Text: commands.FullFlagName(FlagCompressedSize), Description: FlagCompressedSizeUsage},
+		{Text: commands.FullFlagName(FlagExtractPath), Description: FlagExtractPathUsage},
+		{Text: commands.FullFlagName(FlagExportLayer), Description: FlagExportLayerUsage},
+		{Text: commands.FullFlagName(FlagOutput), Description: FlagOutputUsage},
+		{Text: commands.FullFlagName(FlagUI), Description: FlagUIUsage},
+		{Text: commands.FullFlagName(FlagCompare), Description: FlagCompareUsage},
+		{Text: commands.FullFlagName(FlagPolicy), Description: FlagPolicyUsage},
 		{Text: commands.FullFlagName(commands.FlagRemoveFileArtifacts), Description: commands.FlagRemoveFileArtifactsUsage},
 	},
 	Values: map[string]commands.CompleteValue{
 		commands.FullFlagName(commands.FlagTarget):              commands.CompleteTarget,
+		commands.FullFlagName(FlagTargetArchive):                commands.CompleteFile,
+		commands.FullFlagName(FlagTargetOCILayout):              commands.CompleteFile,
 		commands.FullFlagName(FlagChanges):                      completeLayerChanges,
 		commands.FullFlagName(FlagAddImageManifest):             commands.CompleteBool,
 		commands.FullFlagName(FlagAddImageConfig):               commands.CompleteBool,
+		commands.FullFlagName(FlagSBOMFormat):                   completeSBOMFormat,
+		commands.FullFlagName(FlagSBOMFile):                     commands.CompleteFile,
+		commands.FullFlagName(FlagHashData):                     commands.CompleteBool,
+		commands.FullFlagName(FlagScanSecrets):                  commands.CompleteBool,
+		commands.FullFlagName(FlagElfInventory):                 commands.CompleteBool,
+		commands.FullFlagName(FlagCompressedSize):               commands.CompleteBool,
+		commands.FullFlagName(FlagOutput):                       commands.CompleteFile,
+		commands.FullFlagName(FlagUI):                           commands.CompleteBool,
+		commands.FullFlagName(FlagPolicy):                       commands.CompleteFile,
 		commands.FullFlagName(commands.FlagRemoveFileArtifacts): commands.CompleteBool,
 	},
 }
@@ -40,3 +66,12 @@ var layerChangeValues = []prompt.Suggest{
 func completeLayerChanges(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
 	return prompt.FilterHasPrefix(layerChangeValues, token, true)
 }
+
+var sbomFormatValues = []prompt.Suggest{
+	{Text: dockerimage.SBOMFormatCycloneDX, Description: "CycloneDX JSON"},
+	{Text: dockerimage.SBOMFormatSPDX, Description: "SPDX JSON"},
+}
+
+func completeSBOMFormat(ia *commands.InteractiveApp, token string, params prompt.Document) []prompt.Suggest {
+	return prompt.FilterHasPrefix(sbomFormatValues, token, true)
+}
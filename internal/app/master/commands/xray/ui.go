@@ -0,0 +1,620 @@
+package xray
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/reverse"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerimage"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	uiPollInterval      = 20 * time.Millisecond
+	uiDefaultRows       = 24
+	uiDefaultCols       = 80
+	uiInstructionRows   = 3
+	uiLayerMinRows      = 3
+	uiLayerIDSize       = 12
+	uiAltScreenOn       = "\x1b[?1049h"
+	uiAltScreenOff      = "\x1b[?1049l"
+	uiDirExpandedMark   = "[-] "
+	uiDirCollapsedMark  = "[+] "
+	uiFileMark          = "    "
+	uiTreeIndent        = "  "
+	uiHelpLine          = " tab: switch pane | up/down/pgup/pgdn: move | enter/space: expand/collapse dir | f: layer/filesystem view | e/c: expand/collapse all | q: quit"
+	uiNoInstructionInfo = "(no instruction info)"
+)
+
+type uiFocus int
+
+const (
+	uiFocusLayers uiFocus = iota
+	uiFocusFiles
+)
+
+// uiConsoleWriter is the console writer with the display attribute support
+// (provided by the VT100 based writers on all platforms)
+type uiConsoleWriter interface {
+	prompt.ConsoleWriter
+	SetDisplayAttributes(fg, bg prompt.Color, attrs ...prompt.DisplayAttribute)
+}
+
+type uiTreeNode struct {
+	name     string
+	object   *dockerimage.ObjectMetadata
+	change   dockerimage.ChangeType
+	isDir    bool
+	expanded bool
+	children []*uiTreeNode
+	index    map[string]*uiTreeNode
+}
+
+type uiTreeRow struct {
+	node  *uiTreeNode
+	depth int
+}
+
+// layerBrowser is a full-screen terminal UI to browse the image layers,
+// the instructions that created them and the layer objects
+type layerBrowser struct {
+	pkg          *dockerimage.Package
+	title        string
+	instructions map[int][]*reverse.InstructionInfo
+	in           prompt.ConsoleParser
+	out          uiConsoleWriter
+	rows         int
+	cols         int
+	focus        uiFocus
+	layerIdx     int
+	layerTop     int
+	fsView       bool //show the filesystem up to the selected layer (instead of the layer objects)
+	tree         *uiTreeNode
+	fileRows     []uiTreeRow
+	fileIdx      int
+	fileTop      int
+}
+
+// runLayerBrowser opens the layer browser UI (it returns when the user quits)
+func runLayerBrowser(pkg *dockerimage.Package, dockerfileInfo *reverse.Dockerfile, title string) error {
+	if len(pkg.Layers) == 0 {
+		return nil
+	}
+
+	in, err := newConsoleInput()
+	if err != nil {
+		return err
+	}
+
+	if err := in.Setup(); err != nil {
+		return err
+	}
+
+	defer in.TearDown()
+
+	out, ok := prompt.NewStdoutWriter().(uiConsoleWriter)
+	if !ok {
+		return fmt.Errorf("unsupported console writer")
+	}
+
+	b := &layerBrowser{
+		pkg:          pkg,
+		title:        title,
+		instructions: map[int][]*reverse.InstructionInfo{},
+		in:           in,
+		out:          out,
+	}
+
+	if dockerfileInfo != nil {
+		for _, imageInfo := range dockerfileInfo.ImageStack {
+			for _, instInfo := range imageInfo.Instructions {
+				//the layer info is not set if the image history doesn't match the layers
+				if instInfo.LayerID != "" && instInfo.LayerIndex >= 0 {
+					b.instructions[instInfo.LayerIndex] = append(b.instructions[instInfo.LayerIndex], instInfo)
+				}
+			}
+		}
+	}
+
+	b.out.WriteRawStr(uiAltScreenOn)
+	b.out.HideCursor()
+	defer func() {
+		b.out.SetColor(prompt.DefaultColor, prompt.DefaultColor, false)
+		b.out.ShowCursor()
+		b.out.WriteRawStr(uiAltScreenOff)
+		b.out.Flush()
+	}()
+
+	b.rows, b.cols = b.winSize()
+	b.buildTree()
+	b.render()
+
+	for {
+		data, err := in.Read()
+		if err != nil || len(data) == 0 {
+			//the console input is in the non-blocking mode
+			time.Sleep(uiPollInterval)
+			if rows, cols := b.winSize(); rows != b.rows || cols != b.cols {
+				b.rows, b.cols = rows, cols
+				b.render()
+			}
+
+			continue
+		}
+
+		if !b.handleKey(in.GetKey(data), string(data)) {
+			return nil
+		}
+
+		b.render()
+	}
+}
+
+func newConsoleInput() (in prompt.ConsoleParser, err error) {
+	//the console input parser panics when there's no terminal
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("no terminal - %v", r)
+		}
+	}()
+
+	return prompt.NewStandardInputParser(), nil
+}
+
+func (b *layerBrowser) winSize() (rows int, cols int) {
+	rows, cols = uiDefaultRows, uiDefaultCols
+	defer func() {
+		recover()
+	}()
+
+	if ws := b.in.GetWinSize(); ws != nil && ws.Row > 0 && ws.Col > 0 {
+		rows, cols = int(ws.Row), int(ws.Col)
+	}
+
+	return rows, cols
+}
+
+// handleKey processes the key press (returns false when the UI needs to be closed)
+func (b *layerBrowser) handleKey(key prompt.Key, chars string) bool {
+	switch {
+	case key == prompt.ControlC || key == prompt.Escape || chars == "q":
+		return false
+	case key == prompt.Tab || key == prompt.BackTab:
+		if b.focus == uiFocusLayers {
+			b.focus = uiFocusFiles
+		} else {
+			b.focus = uiFocusLayers
+		}
+	case key == prompt.Up || chars == "k":
+		b.move(-1)
+	case key == prompt.Down || chars == "j":
+		b.move(1)
+	case key == prompt.PageUp:
+		b.move(-b.pageSize())
+	case key == prompt.PageDown:
+		b.move(b.pageSize())
+	case key == prompt.Home:
+		b.move(-len(b.fileRows) - len(b.pkg.Layers))
+	case key == prompt.End:
+		b.move(len(b.fileRows) + len(b.pkg.Layers))
+	case chars == "f":
+		b.fsView = !b.fsView
+		b.buildTree()
+	case chars == "e":
+		setExpanded(b.tree, true)
+		b.flattenTree()
+	case chars == "c":
+		setExpanded(b.tree, false)
+		b.flattenTree()
+	case b.focus == uiFocusFiles && (key == prompt.Enter || key == prompt.ControlM || chars == " "):
+		b.toggleDir(nil)
+	case b.focus == uiFocusFiles && (key == prompt.Right || chars == "l"):
+		b.toggleDir(boolRef(true))
+	case b.focus == uiFocusFiles && (key == prompt.Left || chars == "h"):
+		b.toggleDir(boolRef(false))
+	}
+
+	return true
+}
+
+func boolRef(value bool) *bool {
+	return &value
+}
+
+func (b *layerBrowser) move(delta int) {
+	if b.focus == uiFocusLayers {
+		idx := clampIndex(b.layerIdx+delta, len(b.pkg.Layers))
+		if idx != b.layerIdx {
+			b.layerIdx = idx
+			b.buildTree()
+		}
+
+		return
+	}
+
+	b.fileIdx = clampIndex(b.fileIdx+delta, len(b.fileRows))
+}
+
+func clampIndex(idx, count int) int {
+	if idx >= count {
+		idx = count - 1
+	}
+
+	if idx < 0 {
+		idx = 0
+	}
+
+	return idx
+}
+
+func (b *layerBrowser) toggleDir(expanded *bool) {
+	if b.fileIdx >= len(b.fileRows) {
+		return
+	}
+
+	node := b.fileRows[b.fileIdx].node
+	if !node.isDir || len(node.children) == 0 {
+		return
+	}
+
+	if expanded != nil {
+		node.expanded = *expanded
+	} else {
+		node.expanded = !node.expanded
+	}
+
+	b.flattenTree()
+}
+
+// buildTree creates the file tree for the selected layer (the layer objects or
+// the filesystem up to the selected layer with the layer changes highlighted)
+func (b *layerBrowser) buildTree() {
+	b.tree = &uiTreeNode{isDir: true, expanded: true}
+	layer := b.pkg.Layers[b.layerIdx]
+
+	if b.fsView {
+		view := &dockerimage.Package{Layers: b.pkg.Layers[:b.layerIdx+1]}
+		for name, object := range view.FinalObjects() {
+			change := dockerimage.ChangeUnknown
+			if object.LayerIndex == b.layerIdx {
+				change = object.Change
+			}
+
+			b.addTreeObject(name, object.ObjectMetadata, change)
+		}
+	}
+
+	for _, object := range layer.Objects {
+		if b.fsView && object.Change != dockerimage.ChangeDelete {
+			continue
+		}
+
+		name := strings.TrimPrefix(filepath.Clean(object.Name), "/")
+		if name == "." || strings.HasPrefix(filepath.Base(name), dockerimage.WhiteoutPrefix) {
+			continue
+		}
+
+		b.addTreeObject(name, object, object.Change)
+	}
+
+	sortTree(b.tree)
+	b.fileIdx = 0
+	b.fileTop = 0
+	b.flattenTree()
+}
+
+func (b *layerBrowser) addTreeObject(name string, object *dockerimage.ObjectMetadata, change dockerimage.ChangeType) {
+	node := b.tree
+	for _, part := range strings.Split(name, "/") {
+		if node.index == nil {
+			node.index = map[string]*uiTreeNode{}
+		}
+
+		child, ok := node.index[part]
+		if !ok {
+			child = &uiTreeNode{
+				name:     part,
+				isDir:    true,
+				expanded: true,
+			}
+
+			node.index[part] = child
+			node.children = append(node.children, child)
+		}
+
+		node = child
+	}
+
+	node.object = object
+	node.change = change
+	node.isDir = object.Mode.IsDir() || len(node.children) > 0
+}
+
+func sortTree(node *uiTreeNode) {
+	sort.Slice(node.children, func(i, j int) bool {
+		return node.children[i].name < node.children[j].name
+	})
+
+	for _, child := range node.children {
+		sortTree(child)
+	}
+}
+
+func setExpanded(node *uiTreeNode, expanded bool) {
+	for _, child := range node.children {
+		if child.isDir {
+			child.expanded = expanded
+			setExpanded(child, expanded)
+		}
+	}
+}
+
+func (b *layerBrowser) flattenTree() {
+	b.fileRows = b.fileRows[:0]
+	var walk func(node *uiTreeNode, depth int)
+	walk = func(node *uiTreeNode, depth int) {
+		for _, child := range node.children {
+			b.fileRows = append(b.fileRows, uiTreeRow{node: child, depth: depth})
+			if child.isDir && child.expanded {
+				walk(child, depth+1)
+			}
+		}
+	}
+
+	walk(b.tree, 0)
+	b.fileIdx = clampIndex(b.fileIdx, len(b.fileRows))
+}
+
+// layout returns the number of the visible layer rows and file rows
+func (b *layerBrowser) layout() (int, int) {
+	//header, layers title, instruction title and rows, files title, footer
+	fixedRows := 5 + uiInstructionRows
+	layerRows := (b.rows - fixedRows) / 3
+	if layerRows < uiLayerMinRows {
+		layerRows = uiLayerMinRows
+	}
+
+	if layerRows > len(b.pkg.Layers) {
+		layerRows = len(b.pkg.Layers)
+	}
+
+	fileRows := b.rows - fixedRows - layerRows
+	if fileRows < 1 {
+		fileRows = 1
+	}
+
+	return layerRows, fileRows
+}
+
+func (b *layerBrowser) pageSize() int {
+	layerRows, fileRows := b.layout()
+	if b.focus == uiFocusLayers {
+		return layerRows
+	}
+
+	return fileRows
+}
+
+func (b *layerBrowser) render() {
+	layerRows, fileRows := b.layout()
+	row := 1
+
+	var allSize uint64
+	for _, layer := range b.pkg.Layers {
+		allSize += layer.Stats.AllSize
+	}
+
+	b.writeLine(row, fmt.Sprintf(" docker-slim xray | %s | layers: %d | size: %s",
+		b.title, len(b.pkg.Layers), humanize.Bytes(allSize)),
+		prompt.DefaultColor, prompt.DefaultColor, prompt.DisplayReverse)
+	row++
+
+	b.writeLine(row, b.paneTitle("Layers", b.focus == uiFocusLayers), prompt.Cyan, prompt.DefaultColor, prompt.DisplayBold)
+	row++
+
+	b.layerTop = scrollTop(b.layerIdx, b.layerTop, layerRows)
+	for idx := b.layerTop; idx < b.layerTop+layerRows; idx++ {
+		if idx >= len(b.pkg.Layers) {
+			b.writeLine(row, "", prompt.DefaultColor, prompt.DefaultColor, prompt.DisplayReset)
+			row++
+			continue
+		}
+
+		layer := b.pkg.Layers[idx]
+		layerID := layer.ID
+		if len(layerID) > uiLayerIDSize {
+			layerID = layerID[:uiLayerIDSize]
+		}
+
+		var snippet string
+		if instructions := b.instructions[idx]; len(instructions) > 0 {
+			snippet = instructions[len(instructions)-1].CommandSnippet
+		}
+
+		line := fmt.Sprintf(" %3d  %-12s  %9s  %s", idx, layerID, humanize.Bytes(layer.Stats.AllSize), snippet)
+		attr := prompt.DisplayReset
+		if idx == b.layerIdx {
+			attr = b.selectedAttr(uiFocusLayers)
+		}
+
+		b.writeLine(row, line, prompt.DefaultColor, prompt.DefaultColor, attr)
+		row++
+	}
+
+	b.writeLine(row, b.paneTitle("Instruction", false), prompt.Cyan, prompt.DefaultColor, prompt.DisplayBold)
+	row++
+
+	instructionLines := b.instructionLines()
+	for idx := 0; idx < uiInstructionRows; idx++ {
+		var line string
+		if idx < len(instructionLines) {
+			line = instructionLines[idx]
+		}
+
+		b.writeLine(row, line, prompt.DefaultColor, prompt.DefaultColor, prompt.DisplayReset)
+		row++
+	}
+
+	filesTitle := fmt.Sprintf("Files - layer %d changes", b.layerIdx)
+	if b.fsView {
+		filesTitle = fmt.Sprintf("Files - filesystem at layer %d", b.layerIdx)
+	}
+
+	filesTitle = fmt.Sprintf("%s (added: green, modified: yellow, deleted: red)", filesTitle)
+	b.writeLine(row, b.paneTitle(filesTitle, b.focus == uiFocusFiles), prompt.Cyan, prompt.DefaultColor, prompt.DisplayBold)
+	row++
+
+	b.fileTop = scrollTop(b.fileIdx, b.fileTop, fileRows)
+	for idx := b.fileTop; idx < b.fileTop+fileRows; idx++ {
+		if idx >= len(b.fileRows) {
+			b.writeLine(row, "", prompt.DefaultColor, prompt.DefaultColor, prompt.DisplayReset)
+			row++
+			continue
+		}
+
+		treeRow := b.fileRows[idx]
+		attr := prompt.DisplayReset
+		if idx == b.fileIdx {
+			attr = b.selectedAttr(uiFocusFiles)
+		}
+
+		b.writeLine(row, treeRowLine(treeRow), changeColor(treeRow.node.change), prompt.DefaultColor, attr)
+		row++
+	}
+
+	b.writeLine(row, uiHelpLine, prompt.DefaultColor, prompt.DefaultColor, prompt.DisplayReverse)
+	b.out.Flush()
+}
+
+func (b *layerBrowser) paneTitle(title string, focused bool) string {
+	if focused {
+		return fmt.Sprintf("> %s", title)
+	}
+
+	return fmt.Sprintf("  %s", title)
+}
+
+func (b *layerBrowser) selectedAttr(focus uiFocus) prompt.DisplayAttribute {
+	if b.focus == focus {
+		return prompt.DisplayReverse
+	}
+
+	return prompt.DisplayBold
+}
+
+func (b *layerBrowser) instructionLines() []string {
+	instructions := b.instructions[b.layerIdx]
+	if len(instructions) == 0 {
+		return []string{" " + uiNoInstructionInfo}
+	}
+
+	var lines []string
+	for _, instInfo := range instructions {
+		text := strings.Join(strings.Fields(instInfo.CommandAll), " ")
+		width := b.cols - 1
+		if width < 1 {
+			width = 1
+		}
+
+		for len(text) > 0 {
+			chunk := []rune(text)
+			if len(chunk) > width {
+				chunk = chunk[:width]
+			}
+
+			lines = append(lines, " "+string(chunk))
+			text = text[len(string(chunk)):]
+		}
+	}
+
+	if len(lines) > uiInstructionRows {
+		lines = lines[:uiInstructionRows]
+		last := []rune(lines[uiInstructionRows-1])
+		if len(last) > 3 {
+			lines[uiInstructionRows-1] = string(last[:len(last)-3]) + "..."
+		}
+	}
+
+	return lines
+}
+
+func (b *layerBrowser) writeLine(row int, text string, fg, bg prompt.Color, attr prompt.DisplayAttribute) {
+	runes := []rune(text)
+	if len(runes) > b.cols {
+		runes = runes[:b.cols]
+	}
+
+	line := string(runes)
+	if len(runes) < b.cols {
+		line += strings.Repeat(" ", b.cols-len(runes))
+	}
+
+	b.out.CursorGoTo(row, 1)
+	b.out.SetDisplayAttributes(fg, bg, attr)
+	b.out.WriteStr(line)
+	b.out.SetDisplayAttributes(prompt.DefaultColor, prompt.DefaultColor, prompt.DisplayReset)
+}
+
+// scrollTop keeps the selected row visible
+func scrollTop(selected, top, visible int) int {
+	if selected < top {
+		return selected
+	}
+
+	if selected >= top+visible {
+		return selected - visible + 1
+	}
+
+	return top
+}
+
+func treeRowLine(treeRow uiTreeRow) string {
+	node := treeRow.node
+	mark := uiFileMark
+	if node.isDir && len(node.children) > 0 {
+		mark = uiDirCollapsedMark
+		if node.expanded {
+			mark = uiDirExpandedMark
+		}
+	}
+
+	var size string
+	var suffix string
+	if node.object != nil {
+		if !node.isDir {
+			size = humanize.Bytes(uint64(node.object.Size))
+		}
+
+		if node.object.LinkTarget != "" {
+			suffix = fmt.Sprintf(" -> %s", node.object.LinkTarget)
+		}
+	}
+
+	if node.isDir {
+		suffix = "/" + suffix
+	}
+
+	if node.change == dockerimage.ChangeDelete {
+		size = "deleted"
+	}
+
+	return fmt.Sprintf(" %9s  %s%s%s%s", size, strings.Repeat(uiTreeIndent, treeRow.depth), mark, node.name, suffix)
+}
+
+func changeColor(change dockerimage.ChangeType) prompt.Color {
+	switch change {
+	case dockerimage.ChangeAdd:
+		return prompt.Green
+	case dockerimage.ChangeModify:
+		return prompt.Yellow
+	case dockerimage.ChangeDelete:
+		return prompt.Red
+	default:
+		return prompt.DefaultColor
+	}
+}
@@ -97,6 +97,7 @@ type artifactStore struct {
 	resolve       map[string]struct{}
 	linkMap       map[string]*report.ArtifactProps
 	fileMap       map[string]*report.ArtifactProps
+	included      map[string]*report.IncludedArtifact
 	cmd           *command.StartMonitor
 }
 
@@ -116,6 +117,7 @@ func newArtifactStore(storeLocation string,
 		resolve:       map[string]struct{}{},
 		linkMap:       map[string]*report.ArtifactProps{},
 		fileMap:       map[string]*report.ArtifactProps{},
+		included:      map[string]*report.IncludedArtifact{},
 		cmd:           cmd,
 	}
 
@@ -147,6 +149,20 @@ func (p *artifactStore) getArtifactFlags(artifactFileName string) map[string]boo
 	return flags
 }
 
+// addIncluded records the artifacts saved without an observed file access
+// (the first keep reason is used when the artifact is included more than once)
+func (p *artifactStore) addIncluded(artifactFileName, reason string, isDir bool) {
+	if _, ok := p.included[artifactFileName]; ok {
+		return
+	}
+
+	p.included[artifactFileName] = &report.IncludedArtifact{
+		FilePath: artifactFileName,
+		Reason:   reason,
+		IsDir:    isDir,
+	}
+}
+
 func (p *artifactStore) prepareArtifact(artifactFileName string) {
 	srcLinkFileInfo, err := os.Lstat(artifactFileName)
 	if err != nil {
@@ -407,6 +423,8 @@ copyLinks:
 			//if err := cpFile(passwdFilePath, passwdFileTargetPath); err != nil {
 			if err := fsutil.CopyRegularFile(p.cmd.KeepPerms, passwdFilePath, passwdFileTargetPath, true); err != nil {
 				log.Warn("sensor: monitor - error copying user info file =>", err)
+			} else {
+				p.addIncluded(passwdFilePath, report.KeepReasonAppUser, false)
 			}
 		} else {
 			if os.IsNotExist(err) {
@@ -429,6 +447,10 @@ copyIncludes:
 			if len(errs) > 0 {
 				log.Warnf("CopyDir(%v,%v) copy errors: %+v", inPath, dstPath, errs)
 			}
+
+			if err == nil {
+				p.addIncluded(inPath, report.KeepReasonIncludePath, true)
+			}
 		} else {
 			for _, xpattern := range excludePatterns {
 				found, err := doublestar.Match(xpattern, inPath)
@@ -445,6 +467,8 @@ copyIncludes:
 
 			if err := fsutil.CopyFile(p.cmd.KeepPerms, inPath, dstPath, true); err != nil {
 				log.Warnf("CopyFile(%v,%v) error: %v", inPath, dstPath, err)
+			} else {
+				p.addIncluded(inPath, report.KeepReasonIncludePath, false)
 			}
 		}
 	}
@@ -463,6 +487,8 @@ copyIncludes:
 			dstPath := fmt.Sprintf("%s/files%s", p.storeLocation, apath)
 			if err := fsutil.CopyFile(p.cmd.KeepPerms, apath, dstPath, true); err != nil {
 				log.Warnf("CopyFile(%v,%v) error: %v", apath, dstPath, err)
+			} else {
+				p.addIncluded(apath, report.KeepReasonIncludeExe, false)
 			}
 		}
 	}
@@ -481,6 +507,8 @@ copyIncludes:
 			dstPath := fmt.Sprintf("%s/files%s", p.storeLocation, bpath)
 			if err := fsutil.CopyFile(p.cmd.KeepPerms, bpath, dstPath, true); err != nil {
 				log.Warnf("CopyFile(%v,%v) error: %v", bpath, dstPath, err)
+			} else {
+				p.addIncluded(bpath, report.KeepReasonIncludeBin, false)
 			}
 		}
 	}
@@ -495,6 +523,8 @@ copyIncludes:
 				dstPath := fmt.Sprintf("%s/files%s", p.storeLocation, spath)
 				if err := fsutil.CopyFile(p.cmd.KeepPerms, spath, dstPath, true); err != nil {
 					log.Warnf("CopyFile(%v,%v) error: %v", spath, dstPath, err)
+				} else {
+					p.addIncluded(spath, report.KeepReasonIncludeShell, false)
 				}
 			}
 		} else {
@@ -565,6 +595,16 @@ func (p *artifactStore) saveReport() {
 		creport.Image.Files = append(creport.Image.Files, p.rawNames[fname])
 	}
 
+	var includedNames []string
+	for fname := range p.included {
+		includedNames = append(includedNames, fname)
+	}
+
+	sort.Strings(includedNames)
+	for _, fname := range includedNames {
+		creport.Image.Included = append(creport.Image.Included, p.included[fname])
+	}
+
 	artifactDirName := defaultArtifactDirName
 	reportName := defaultReportName
 
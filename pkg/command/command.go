@@ -9,6 +9,7 @@ const (
 	Containerize Type = "containerize"
 	Convert      Type = "convert"
 	Edit         Type = "edit"
+	Diff         Type = "diff"
 	Version      Type = "version"
 	Update       Type = "update"
 )
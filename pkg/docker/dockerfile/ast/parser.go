@@ -308,6 +308,7 @@ func Parse(rwc io.Reader) (*Result, error) {
 
 		var hasEmptyContinuationLine bool
 		for !isEndOfLine && scanner.Scan() {
+			lines = append(lines, string(scanner.Bytes()))
 			bytesRead, err := processLine(d, scanner.Bytes(), false)
 			if err != nil {
 				return nil, err
@@ -3,6 +3,7 @@ package parser
 
 import (
 	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -17,9 +18,16 @@ var (
 	ErrInvalidDockerfile = errors.New("invalid Dockerfile")
 )
 
+// IgnoreDirective is the comment directive to ignore lint checks for the next instruction
+// (e.g., "# docker-slim:ignore ID.20012 ID.20017" or "# docker-slim:ignore" for all checks)
+const IgnoreDirective = "docker-slim:ignore"
+
+// IgnoreAllChecks is the ignored check ID value when the ignore directive has no check IDs
+const IgnoreAllChecks = "*"
+
 //TODO:
 //* support incremental, partial and instruction level parsing
-//* support parsing from reader and from string
+//* support parsing from string
 
 func FromFile(fpath string) (*spec.Dockerfile, error) {
 	fo, err := os.Open(fpath)
@@ -29,7 +37,20 @@ func FromFile(fpath string) (*spec.Dockerfile, error) {
 
 	defer fo.Close()
 
-	astParsed, err := ast.Parse(fo)
+	dockerfile, err := FromReader(fo)
+	if err != nil {
+		return nil, err
+	}
+
+	dockerfile.Name = filepath.Base(fpath)
+	dockerfile.Location = filepath.Dir(fpath)
+	return dockerfile, nil
+}
+
+// FromReader parses the Dockerfile instructions from the reader
+// (the Dockerfile name and location are not set)
+func FromReader(reader io.Reader) (*spec.Dockerfile, error) {
+	astParsed, err := ast.Parse(reader)
 	if err != nil {
 		return nil, err
 	}
@@ -39,9 +60,8 @@ func FromFile(fpath string) (*spec.Dockerfile, error) {
 	}
 
 	dockerfile := spec.NewDockerfile()
-	dockerfile.Name = filepath.Base(fpath)
-	dockerfile.Location = filepath.Dir(fpath)
 	dockerfile.Lines = astParsed.Lines
+	dockerfile.EscapeToken = astParsed.EscapeToken
 
 	if astParsed.AST.StartLine > -1 && len(astParsed.AST.Children) > 0 {
 		var currentStage *spec.BuildStage
@@ -71,6 +91,8 @@ func FromFile(fpath string) (*spec.Dockerfile, error) {
 				inst.Errors = append(inst.Errors, node.Errors...)
 			}
 
+			inst.IgnoredChecks = ignoredChecks(dockerfile.Lines, inst.StartLine)
+
 			if inst.Name == instruction.Onbuild &&
 				node.Next != nil &&
 				len(node.Next.Children) > 0 {
@@ -330,3 +352,38 @@ func GetRefName(ref string) string {
 
 	return ref
 }
+
+// ignoredChecks returns the check IDs from the ignore directives
+// in the comment lines right before the instruction
+func ignoredChecks(lines []string, startLine int) []string {
+	var ids []string
+	for idx := startLine - 2; idx >= 0 && idx < len(lines); idx-- {
+		line := strings.TrimSpace(lines[idx])
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		if !strings.HasPrefix(line, IgnoreDirective) {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, IgnoreDirective)
+		if line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			//a different directive with the same prefix
+			continue
+		}
+
+		checkIDs := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == ','
+		})
+
+		if len(checkIDs) == 0 {
+			checkIDs = append(checkIDs, IgnoreAllChecks)
+		}
+
+		ids = append(ids, checkIDs...)
+	}
+
+	return ids
+}
@@ -84,6 +84,12 @@ func DockerfileFromHistory(apiClient *docker.Client, imageID string) (*Dockerfil
 		return nil, err
 	}
 
+	return DockerfileFromHistoryData(imageHistory)
+}
+
+// DockerfileFromHistoryData recreates Dockerfile information from container image history records
+// (the records are in the 'History' API order: the latest record is first)
+func DockerfileFromHistoryData(imageHistory []docker.ImageHistory) (*Dockerfile, error) {
 	var out Dockerfile
 
 	log.Debugf("\n\nIMAGE HISTORY =>\n%#v\n\n", imageHistory)
@@ -61,6 +61,7 @@ type Dockerfile struct {
 	Name                  string
 	Location              string
 	Lines                 []string
+	EscapeToken           rune
 	FromArgs              map[string]string //all "FROM" ARGs
 	Stages                []*BuildStage
 	StagesByName          map[string]*BuildStage
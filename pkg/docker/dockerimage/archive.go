@@ -0,0 +1,491 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/fsouza/go-dockerclient"
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+var (
+	ErrNoArchiveImage   = errors.New("no image in archive")
+	ErrNoOCILayoutImage = errors.New("no image in OCI layout")
+)
+
+const (
+	annotationContainerdImageName = "io.containerd.image.name"
+	configObjectFileExt           = ".json"
+	defaultTag                    = "latest"
+	defaultPlatformOS             = "linux"
+	historyNoID                   = "<missing>"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ArchiveImage is an image in an image archive or an OCI image layout
+type ArchiveImage struct {
+	ID       string
+	RepoTags []string
+	Manifest *ManifestObject
+}
+
+// IsLoadable returns true if the archive image can be loaded with LoadPackage as is
+// (the newer 'docker save' archives store the image config and layers as OCI blobs)
+func (ai *ArchiveImage) IsLoadable() bool {
+	if ai.Manifest == nil ||
+		ai.Manifest.Config != fmt.Sprintf("%s%s", ai.ID, configObjectFileExt) {
+		return false
+	}
+
+	for _, layerPath := range ai.Manifest.Layers {
+		if !strings.HasSuffix(layerPath, layerSuffix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FindArchiveImage finds the target image in a 'docker save' archive
+// using its reference or ID (the first image is selected if the reference is empty)
+func FindArchiveImage(archivePath, ref string) (*ArchiveImage, error) {
+	data, err := FileDataFromTar(archivePath, manifestFileName)
+	if err != nil {
+		log.Errorf("dockerimage.FindArchiveImage: error reading manifest file from archive(%v/%v) - %v", archivePath, manifestFileName, err)
+		return nil, err
+	}
+
+	var manifests []ManifestObject
+	if err := json.Unmarshal(data, &manifests); err != nil {
+		log.Errorf("dockerimage.FindArchiveImage: error decoding manifest file - %v", err)
+		return nil, err
+	}
+
+	for _, m := range manifests {
+		imageID := strings.TrimSuffix(filepath.Base(m.Config), configObjectFileExt)
+		if ref != "" &&
+			!isImageRef(ref, m.RepoTags) &&
+			!isImageID(ref, imageID) {
+			continue
+		}
+
+		manifest := m
+		return &ArchiveImage{
+			ID:       imageID,
+			RepoTags: m.RepoTags,
+			Manifest: &manifest,
+		}, nil
+	}
+
+	return nil, ErrNoArchiveImage
+}
+
+// SaveDockerArchive saves the archive image in a 'docker save' archive
+// with the layout expected by LoadPackage (the layers are uncompressed)
+func SaveDockerArchive(archivePath string, image *ArchiveImage, outputPath string) error {
+	if image == nil || image.Manifest == nil {
+		return ErrNoArchiveImage
+	}
+
+	blobReader := func(blobPath string) (io.ReadCloser, error) {
+		return FileReaderFromTar(archivePath, filepath.Clean(blobPath))
+	}
+
+	_, err := writeDockerArchive(outputPath, image.RepoTags, image.Manifest.Config, image.Manifest.Layers, blobReader)
+	return err
+}
+
+// FindOCILayoutImage finds the target image in an OCI image layout directory
+// using its reference name (the first image is selected if the reference is empty;
+// the image for the current architecture is selected in the multi-platform images)
+func FindOCILayoutImage(layoutDir, ref string) (*ArchiveImage, error) {
+	indexData, err := ioutil.ReadFile(filepath.Join(layoutDir, OCIIndexFileName))
+	if err != nil {
+		log.Errorf("dockerimage.FindOCILayoutImage: error reading index (%v) - %v", layoutDir, err)
+		return nil, err
+	}
+
+	var index OCIIndex
+	if err := json.Unmarshal(indexData, &index); err != nil {
+		log.Errorf("dockerimage.FindOCILayoutImage: error decoding index - %v", err)
+		return nil, err
+	}
+
+	for _, desc := range index.Manifests {
+		refName := desc.Annotations[AnnotationOCIRefName]
+		imageName := desc.Annotations[annotationContainerdImageName]
+		if ref != "" &&
+			ref != refName &&
+			!isImageRef(ref, []string{imageName}) &&
+			!isImageID(ref, digestHex(desc.Digest)) {
+			continue
+		}
+
+		manifest, err := loadOCIManifest(layoutDir, desc)
+		if err != nil {
+			return nil, err
+		}
+
+		image := &ArchiveImage{
+			ID: digestHex(manifest.Config.Digest),
+			Manifest: &ManifestObject{
+				Config: ociBlobPath(manifest.Config.Digest),
+			},
+		}
+
+		switch {
+		case imageName != "":
+			image.RepoTags = []string{imageName}
+		case strings.Contains(refName, ":") || strings.Contains(refName, "/"):
+			image.RepoTags = []string{refName}
+		case refName != "":
+			image.RepoTags = []string{fmt.Sprintf("%s:%s", filepath.Base(filepath.Clean(layoutDir)), refName)}
+		}
+
+		for _, layer := range manifest.Layers {
+			image.Manifest.Layers = append(image.Manifest.Layers, ociBlobPath(layer.Digest))
+		}
+
+		image.Manifest.RepoTags = image.RepoTags
+		return image, nil
+	}
+
+	return nil, ErrNoOCILayoutImage
+}
+
+// SaveDockerArchiveFromOCILayout saves the OCI layout image in a 'docker save' archive
+// with the layout expected by LoadPackage (the layers are uncompressed)
+func SaveDockerArchiveFromOCILayout(layoutDir string, image *ArchiveImage, outputPath string) error {
+	if image == nil || image.Manifest == nil {
+		return ErrNoOCILayoutImage
+	}
+
+	blobReader := func(blobPath string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(layoutDir, blobPath))
+	}
+
+	_, err := writeDockerArchive(outputPath, image.RepoTags, image.Manifest.Config, image.Manifest.Layers, blobReader)
+	return err
+}
+
+// ImageHistory returns the image history records in the 'History' API format
+// (used to reverse engineer the Dockerfile without the Docker API)
+func (p *Package) ImageHistory(imageID string, repoTags []string) []docker.ImageHistory {
+	if p.Config == nil {
+		return nil
+	}
+
+	var records []docker.ImageHistory
+	var layerIdx int
+	for _, history := range p.Config.History {
+		record := docker.ImageHistory{
+			ID:        historyNoID,
+			Created:   history.Created.Unix(),
+			CreatedBy: history.CreatedBy,
+			Comment:   history.Comment,
+		}
+
+		if !history.EmptyLayer {
+			if layerIdx < len(p.Layers) {
+				record.Size = int64(p.Layers[layerIdx].Stats.AllSize)
+			}
+
+			layerIdx++
+		}
+
+		//the latest record is first
+		records = append([]docker.ImageHistory{record}, records...)
+	}
+
+	if len(records) > 0 {
+		records[0].ID = fmt.Sprintf("sha256:%s", imageID)
+		records[0].Tags = repoTags
+	}
+
+	return records
+}
+
+// layerBlobReader opens the image config or layer blob
+type layerBlobReader func(blobPath string) (io.ReadCloser, error)
+
+func writeDockerArchive(outputPath string,
+	repoTags []string,
+	configPath string,
+	layerPaths []string,
+	blobReader layerBlobReader) (string, error) {
+	configReader, err := blobReader(configPath)
+	if err != nil {
+		log.Errorf("dockerimage.writeDockerArchive: error opening config object (%v) - %v", configPath, err)
+		return "", err
+	}
+
+	configData, err := ioutil.ReadAll(configReader)
+	configReader.Close()
+	if err != nil {
+		return "", err
+	}
+
+	var imageConfig ConfigObject
+	if err := json.Unmarshal(configData, &imageConfig); err != nil {
+		log.Errorf("dockerimage.writeDockerArchive: error decoding config object - %v", err)
+		return "", err
+	}
+
+	configHash := sha256.Sum256(configData)
+	imageID := hex.EncodeToString(configHash[:])
+
+	dir := filepath.Dir(outputPath)
+	if !fsutil.DirExists(dir) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+	}
+
+	ofile, err := os.Create(outputPath)
+	if err != nil {
+		return "", err
+	}
+
+	defer ofile.Close()
+
+	tw := tar.NewWriter(ofile)
+	manifest := ManifestObject{
+		Config:   fmt.Sprintf("%s%s", imageID, configObjectFileExt),
+		RepoTags: repoTags,
+	}
+
+	if err := writeTarFile(tw, manifest.Config, configData); err != nil {
+		return "", err
+	}
+
+	layerIDs := map[string]struct{}{}
+	for idx, layerPath := range layerPaths {
+		layerID := digestHex(filepath.Base(layerPath))
+		if imageConfig.RootFS != nil && idx < len(imageConfig.RootFS.DiffIDs) {
+			layerID = digestHex(imageConfig.RootFS.DiffIDs[idx])
+		}
+
+		//the same layer data can be used more than once
+		if _, ok := layerIDs[layerID]; ok {
+			layerID = fmt.Sprintf("%s-%d", layerID, idx)
+		}
+
+		layerIDs[layerID] = struct{}{}
+
+		manifest.Layers = append(manifest.Layers, fmt.Sprintf("%s%s", layerID, layerSuffix))
+		if err := writeArchiveLayer(tw, dir, manifest.Layers[idx], layerPath, blobReader); err != nil {
+			log.Errorf("dockerimage.writeDockerArchive: error saving layer (%v) - %v", layerPath, err)
+			return "", err
+		}
+	}
+
+	manifestData, err := json.Marshal([]ManifestObject{manifest})
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeTarFile(tw, manifestFileName, manifestData); err != nil {
+		return "", err
+	}
+
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+
+	return imageID, nil
+}
+
+// writeArchiveLayer saves the uncompressed layer data
+// (the data is saved to a temporary file first because the tar header needs the data size)
+func writeArchiveLayer(tw *tar.Writer,
+	tmpDir string,
+	name string,
+	layerPath string,
+	blobReader layerBlobReader) error {
+	layerReader, err := blobReader(layerPath)
+	if err != nil {
+		return err
+	}
+
+	defer layerReader.Close()
+
+	dataReader, err := uncompressedReader(layerReader)
+	if err != nil {
+		return err
+	}
+
+	defer dataReader.Close()
+
+	tmpFile, err := ioutil.TempFile(tmpDir, ".layer-")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+	}()
+
+	size, err := io.Copy(tmpFile, dataReader)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	hdr := &tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		Typeflag: tar.TypeReg,
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, tmpFile)
+	return err
+}
+
+// uncompressedReader detects the layer data compression (gzip or zstd)
+func uncompressedReader(reader io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(reader)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+
+		return zr.IOReadCloser(), nil
+	}
+
+	return ioutil.NopCloser(br), nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		Typeflag: tar.TypeReg,
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err := tw.Write(data)
+	return err
+}
+
+// loadOCIManifest loads the image manifest (selecting the platform manifest for the image indexes)
+func loadOCIManifest(layoutDir string, desc OCIDescriptor) (*OCIManifest, error) {
+	for depth := 0; depth < maxLinkDepth; depth++ {
+		data, err := ioutil.ReadFile(filepath.Join(layoutDir, ociBlobPath(desc.Digest)))
+		if err != nil {
+			log.Errorf("dockerimage.loadOCIManifest: error reading manifest (%v) - %v", desc.Digest, err)
+			return nil, err
+		}
+
+		var index OCIIndex
+		if err := json.Unmarshal(data, &index); err != nil {
+			return nil, err
+		}
+
+		if len(index.Manifests) == 0 {
+			var manifest OCIManifest
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				return nil, err
+			}
+
+			if manifest.Config.Digest == "" {
+				return nil, ErrNoOCILayoutImage
+			}
+
+			return &manifest, nil
+		}
+
+		desc = selectPlatformManifest(index.Manifests)
+	}
+
+	return nil, ErrNoOCILayoutImage
+}
+
+func selectPlatformManifest(manifests []OCIDescriptor) OCIDescriptor {
+	for _, desc := range manifests {
+		if desc.Platform != nil &&
+			desc.Platform.OS == defaultPlatformOS &&
+			desc.Platform.Architecture == runtime.GOARCH {
+			return desc
+		}
+	}
+
+	return manifests[0]
+}
+
+func ociBlobPath(digest string) string {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 {
+		return filepath.Join(OCIBlobsDirName, digestAlgorithm, digest)
+	}
+
+	return filepath.Join(OCIBlobsDirName, parts[0], parts[1])
+}
+
+func digestHex(digest string) string {
+	if idx := strings.Index(digest, ":"); idx != -1 {
+		return digest[idx+1:]
+	}
+
+	return digest
+}
+
+func isImageRef(ref string, repoTags []string) bool {
+	if !strings.Contains(filepath.Base(ref), ":") {
+		ref = fmt.Sprintf("%s:%s", ref, defaultTag)
+	}
+
+	for _, tag := range repoTags {
+		if tag == ref || strings.HasSuffix(tag, "/"+ref) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isImageID(ref, imageID string) bool {
+	ref = digestHex(ref)
+	return imageID != "" && len(ref) >= 12 && strings.HasPrefix(imageID, ref)
+}
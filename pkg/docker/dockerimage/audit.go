@@ -0,0 +1,106 @@
+package dockerimage
+
+import (
+	"os"
+)
+
+// Audited file types
+const (
+	AuditFileTypeFile    = "file"
+	AuditFileTypeSymlink = "symlink"
+	AuditFileTypeOther   = "other"
+)
+
+// FilesAudit lists the files removed from the image and the files retained in the minified image
+// (the directories are not included)
+type FilesAudit struct {
+	RemovedCount  int            `json:"removed_count"`
+	RemovedSize   uint64         `json:"removed_size"`
+	RetainedCount int            `json:"retained_count"`
+	RetainedSize  uint64         `json:"retained_size"`
+	Removed       []*AuditedFile `json:"removed"`
+	Retained      []*AuditedFile `json:"retained"`
+}
+
+// AuditedFile describes a file in the original image filesystem
+type AuditedFile struct {
+	Path           string `json:"path"`
+	Type           string `json:"type"`
+	Size           int64  `json:"size"`
+	LayerIndex     int    `json:"layer_index"` //the layer with the file version in the final image filesystem
+	LayerID        string `json:"layer_id"`
+	Package        string `json:"package,omitempty"` //the OS package that owns the file (if known)
+	PackageVersion string `json:"package_version,omitempty"`
+	Reason         string `json:"reason,omitempty"` //the keep reason for the retained files
+}
+
+// AuditFiles compares the final image filesystem with the minified filesystem.
+// The retained files map the absolute paths of the minified filesystem objects to their keep reasons.
+func (p *Package) AuditFiles(archivePath string, retained map[string]string) (*FilesAudit, error) {
+	objects := p.FinalObjects()
+	packages, err := p.LoadSystemPackages(archivePath, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := map[string]*SoftwarePackage{}
+	for _, pkg := range packages {
+		for _, name := range pkg.Files {
+			if _, ok := owners[name]; !ok {
+				owners[name] = pkg
+			}
+		}
+	}
+
+	audit := &FilesAudit{}
+	for _, name := range sortedObjectNames(objects) {
+		object := objects[name]
+		if object.Mode.IsDir() {
+			continue
+		}
+
+		info := &AuditedFile{
+			Path:       "/" + name,
+			Type:       auditFileType(object.Mode),
+			LayerIndex: object.LayerIndex,
+		}
+
+		if info.Type == AuditFileTypeFile && object.Size > 0 {
+			info.Size = object.Size
+		}
+
+		if object.LayerIndex >= 0 && object.LayerIndex < len(p.Layers) {
+			info.LayerID = p.Layers[object.LayerIndex].ID
+		}
+
+		if pkg, ok := owners[name]; ok {
+			info.Package = pkg.ID()
+			info.PackageVersion = pkg.Version
+		}
+
+		if reason, ok := retained[info.Path]; ok {
+			info.Reason = reason
+			audit.Retained = append(audit.Retained, info)
+			audit.RetainedCount++
+			audit.RetainedSize += uint64(info.Size)
+			continue
+		}
+
+		audit.Removed = append(audit.Removed, info)
+		audit.RemovedCount++
+		audit.RemovedSize += uint64(info.Size)
+	}
+
+	return audit, nil
+}
+
+func auditFileType(mode os.FileMode) string {
+	switch {
+	case mode.IsRegular():
+		return AuditFileTypeFile
+	case mode&os.ModeSymlink != 0:
+		return AuditFileTypeSymlink
+	default:
+		return AuditFileTypeOther
+	}
+}
@@ -0,0 +1,132 @@
+package dockerimage
+
+import (
+	"compress/gzip"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+)
+
+// BlobSizes contains the image layer data sizes (the uncompressed layer tar size,
+// the gzip compressed size, which is the registry storage and the pull size,
+// and the zstd compressed size for the registries and the runtimes that support zstd layers)
+type BlobSizes struct {
+	TarSize  uint64           `json:"tar_size"`
+	GzipSize uint64           `json:"gzip_size"`
+	ZstdSize uint64           `json:"zstd_size"`
+	Layers   []*LayerBlobSize `json:"layers,omitempty"`
+}
+
+// LayerBlobSize contains the layer data sizes
+type LayerBlobSize struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	TarSize  uint64 `json:"tar_size"`
+	GzipSize uint64 `json:"gzip_size"`
+	ZstdSize uint64 `json:"zstd_size"`
+}
+
+// CalculateBlobSizes compresses the layer data (using the default gzip compression level
+// like 'docker push' and the default zstd compression level) to get the compressed layer sizes
+// (the layer stats are also updated)
+func (p *Package) CalculateBlobSizes(archivePath string) (*BlobSizes, error) {
+	result := &BlobSizes{}
+	for _, layer := range p.Layers {
+		result.Layers = append(result.Layers, &LayerBlobSize{
+			Index: layer.Index,
+			ID:    layer.ID,
+		})
+	}
+
+	//the layers are compressed in parallel
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var layerErr error
+	workers := make(chan struct{}, runtime.NumCPU())
+	for idx, layer := range p.Layers {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(info *LayerBlobSize, layerPath string) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+
+			if err := layerBlobSize(archivePath, layerPath, info); err != nil {
+				log.Errorf("dockerimage.Package.CalculateBlobSizes: error compressing layer(%v) - %v", layerPath, err)
+				errOnce.Do(func() {
+					layerErr = err
+				})
+			}
+		}(result.Layers[idx], layer.Path)
+	}
+
+	wg.Wait()
+	if layerErr != nil {
+		return nil, layerErr
+	}
+
+	for idx, layer := range p.Layers {
+		info := result.Layers[idx]
+		layer.Stats.TarSize = info.TarSize
+		layer.Stats.BlobSize = info.GzipSize
+		layer.Stats.ZstdBlobSize = info.ZstdSize
+		result.TarSize += info.TarSize
+		result.GzipSize += info.GzipSize
+		result.ZstdSize += info.ZstdSize
+	}
+
+	return result, nil
+}
+
+// layerBlobSize compresses the layer data with gzip and zstd at the same time
+func layerBlobSize(archivePath, layerPath string, info *LayerBlobSize) error {
+	layerReader, err := FileReaderFromTar(archivePath, layerPath)
+	if err != nil {
+		return err
+	}
+
+	defer layerReader.Close()
+
+	var gzipCompressed, zstdCompressed byteCounter
+	gw := gzip.NewWriter(&gzipCompressed)
+
+	//the layers are already compressed in parallel (one encoder goroutine per layer)
+	zw, err := zstd.NewWriter(&zstdCompressed,
+		zstd.WithEncoderLevel(zstd.SpeedDefault),
+		zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return err
+	}
+
+	size, err := io.Copy(io.MultiWriter(gw, zw), layerReader)
+	if err != nil {
+		zw.Close()
+		return err
+	}
+
+	if err := gw.Close(); err != nil {
+		zw.Close()
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	info.TarSize = uint64(size)
+	info.GzipSize = uint64(gzipCompressed)
+	info.ZstdSize = uint64(zstdCompressed)
+	return nil
+}
+
+// byteCounter is a writer that only counts the written bytes
+type byteCounter uint64
+
+func (c *byteCounter) Write(data []byte) (int, error) {
+	*c += byteCounter(len(data))
+	return len(data), nil
+}
@@ -0,0 +1,169 @@
+package dockerimage
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ImageComparison is the layer by layer comparison of two images
+// (the 'from' image is the baseline and the 'to' image is compared with it)
+type ImageComparison struct {
+	FromImageID  string           `json:"from_image_id"`
+	ToImageID    string           `json:"to_image_id"`
+	FromSize     int64            `json:"from_size"`
+	ToSize       int64            `json:"to_size"`
+	SizeDelta    int64            `json:"size_delta"` //positive if the 'to' image is bigger
+	SharedLayers []*SharedLayer   `json:"shared_layers,omitempty"`
+	FromLayers   []*ComparedLayer `json:"from_layers,omitempty"` //the layers only in the 'from' image
+	ToLayers     []*ComparedLayer `json:"to_layers,omitempty"`   //the layers only in the 'to' image
+	Files        ObjectsDiff      `json:"files"`                 //the kept objects include only the modified objects
+	Config       []*ConfigChange  `json:"config,omitempty"`      //including the history changes
+}
+
+// SharedLayer is a layer with the same data in both images (matched using the layer FS diff IDs)
+type SharedLayer struct {
+	FSDiffID  string `json:"fsdiff_id"`
+	FromIndex int    `json:"from_index"`
+	ToIndex   int    `json:"to_index"`
+	Size      uint64 `json:"size"`
+}
+
+// ComparedLayer is a layer that exists only in one of the compared images
+type ComparedLayer struct {
+	Index     int    `json:"index"`
+	ID        string `json:"id"`
+	FSDiffID  string `json:"fsdiff_id,omitempty"`
+	Size      uint64 `json:"size"`
+	CreatedBy string `json:"created_by,omitempty"` //the history instruction that created the layer
+}
+
+// CompareImages matches the shared layers using their FS diff IDs, finds the layers
+// unique to each image and diffs the final image filesystems and the image configs
+func CompareImages(from, to *Package) *ImageComparison {
+	result := &ImageComparison{
+		FromImageID: imagePackageID(from),
+		ToImageID:   imagePackageID(to),
+	}
+
+	toLayers := map[string]*Layer{}
+	for _, layer := range to.Layers {
+		result.ToSize += int64(layer.Stats.AllSize)
+		if layer.FSDiffID != "" {
+			toLayers[layer.FSDiffID] = layer
+		}
+	}
+
+	sharedLayers := map[*Layer]struct{}{}
+	for _, layer := range from.Layers {
+		result.FromSize += int64(layer.Stats.AllSize)
+		if toLayer, ok := toLayers[layer.FSDiffID]; ok && layer.FSDiffID != "" {
+			sharedLayers[toLayer] = struct{}{}
+			result.SharedLayers = append(result.SharedLayers, &SharedLayer{
+				FSDiffID:  layer.FSDiffID,
+				FromIndex: layer.Index,
+				ToIndex:   toLayer.Index,
+				Size:      layer.Stats.AllSize,
+			})
+
+			continue
+		}
+
+		result.FromLayers = append(result.FromLayers, newComparedLayer(from, layer))
+	}
+
+	for _, layer := range to.Layers {
+		if _, ok := sharedLayers[layer]; !ok {
+			result.ToLayers = append(result.ToLayers, newComparedLayer(to, layer))
+		}
+	}
+
+	result.SizeDelta = result.ToSize - result.FromSize
+
+	diff := &ImageDiff{}
+	diffObjects(from.FinalObjects(), to.FinalObjects(), diff)
+	result.Files = diff.Files
+
+	//the unmodified objects are not interesting in the image comparison
+	var modified []*DiffObject
+	for _, object := range result.Files.Kept {
+		if object.Modified {
+			modified = append(modified, object)
+		}
+	}
+
+	result.Files.Kept = modified
+
+	result.Config = diffConfig(containerConfig(from), containerConfig(to))
+	result.Config = append(result.Config, diffHistory(imageHistory(from), imageHistory(to))...)
+	return result
+}
+
+func imagePackageID(pkg *Package) string {
+	if pkg.Manifest == nil {
+		return ""
+	}
+
+	return strings.TrimSuffix(pkg.Manifest.Config, configObjectFileExt)
+}
+
+func newComparedLayer(pkg *Package, layer *Layer) *ComparedLayer {
+	info := &ComparedLayer{
+		Index:    layer.Index,
+		ID:       layer.ID,
+		FSDiffID: layer.FSDiffID,
+		Size:     layer.Stats.AllSize,
+	}
+
+	if pkg.Config != nil {
+		for _, record := range pkg.Config.History {
+			if !record.EmptyLayer && record.LayerIndex == layer.Index && record.LayerID == layer.ID {
+				info.CreatedBy = strings.TrimSpace(record.CreatedBy)
+				break
+			}
+		}
+	}
+
+	return info
+}
+
+func imageHistory(pkg *Package) []string {
+	var history []string
+	if pkg.Config != nil {
+		for _, record := range pkg.Config.History {
+			history = append(history, strings.TrimSpace(record.CreatedBy))
+		}
+	}
+
+	return history
+}
+
+// diffHistory compares the image history instructions after the common history prefix
+// (the instructions after the first different instruction are reported as removed or added
+// because the following image layers are rebuilt)
+func diffHistory(from, to []string) []*ConfigChange {
+	var prefixSize int
+	for prefixSize < len(from) && prefixSize < len(to) && from[prefixSize] == to[prefixSize] {
+		prefixSize++
+	}
+
+	var changes []*ConfigChange
+	for idx := prefixSize; idx < len(from); idx++ {
+		changes = append(changes, &ConfigChange{
+			Field:  ConfigFieldHistory,
+			Key:    strconv.Itoa(idx),
+			Change: DiffRemoved,
+			From:   from[idx],
+		})
+	}
+
+	for idx := prefixSize; idx < len(to); idx++ {
+		changes = append(changes, &ConfigChange{
+			Field:  ConfigFieldHistory,
+			Key:    strconv.Itoa(idx),
+			Change: DiffAdded,
+			To:     to[idx],
+		})
+	}
+
+	return changes
+}
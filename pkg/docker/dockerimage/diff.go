@@ -0,0 +1,466 @@
+package dockerimage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Image diff change types
+const (
+	DiffRemoved  = "removed"
+	DiffKept     = "kept"
+	DiffAdded    = "added"
+	DiffModified = "modified"
+	DiffPartial  = "partial" //some of the package files are kept
+)
+
+// Image diff config field names
+const (
+	ConfigFieldEnv          = "env"
+	ConfigFieldExposedPorts = "exposed_ports"
+	ConfigFieldEntrypoint   = "entrypoint"
+	ConfigFieldCmd          = "cmd"
+	ConfigFieldWorkingDir   = "working_dir"
+	ConfigFieldUser         = "user"
+	ConfigFieldVolumes      = "volumes"
+	ConfigFieldLabels       = "labels"
+	ConfigFieldStopSignal   = "stop_signal"
+	ConfigFieldHealthcheck  = "healthcheck"
+	ConfigFieldShell        = "shell"
+	ConfigFieldOnBuild      = "onbuild"
+	ConfigFieldHistory      = "history" //used in the image comparison
+)
+
+const (
+	exeModeBits = 0111
+)
+
+// ImageDiff is the semantic diff between two images
+type ImageDiff struct {
+	Files    ObjectsDiff     `json:"files"`
+	Binaries ObjectsDiff     `json:"binaries"`
+	Packages PackagesDiff    `json:"packages"`
+	Config   []*ConfigChange `json:"config,omitempty"`
+}
+
+// ObjectsDiff describes the filesystem object changes
+type ObjectsDiff struct {
+	Summary ObjectsDiffSummary `json:"summary"`
+	Removed []*DiffObject      `json:"removed,omitempty"`
+	Kept    []*DiffObject      `json:"kept,omitempty"`
+	Added   []*DiffObject      `json:"added,omitempty"`
+}
+
+// ObjectsDiffSummary contains the filesystem object change counters
+type ObjectsDiffSummary struct {
+	RemovedCount  int   `json:"removed_count"`
+	RemovedSize   int64 `json:"removed_size"`
+	KeptCount     int   `json:"kept_count"`
+	KeptSize      int64 `json:"kept_size"`
+	ModifiedCount int   `json:"modified_count"` //kept objects with different metadata
+	AddedCount    int   `json:"added_count"`
+	AddedSize     int64 `json:"added_size"`
+}
+
+// DiffObject is a filesystem object in the image diff
+// (the 'from' image object for the removed objects and the 'to' image object otherwise)
+type DiffObject struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Size       int64  `json:"size"`
+	Mode       string `json:"mode"`
+	UID        int    `json:"uid"`
+	GID        int    `json:"gid"`
+	LinkTarget string `json:"link_target,omitempty"`
+	LayerIndex int    `json:"layer_index"`
+	Modified   bool   `json:"modified,omitempty"`
+}
+
+// PackagesDiff describes the OS package changes
+// (the package file lists from the 'from' image are used to find the kept package files,
+// so the results are available even if the package database is removed in the 'to' image)
+type PackagesDiff struct {
+	Removed []*PackageChange `json:"removed,omitempty"`
+	Partial []*PackageChange `json:"partial,omitempty"`
+	Kept    []*PackageChange `json:"kept,omitempty"`
+	Added   []*PackageChange `json:"added,omitempty"`
+}
+
+// PackageChange describes an OS package change
+type PackageChange struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Version     string `json:"version,omitempty"`
+	ToVersion   string `json:"to_version,omitempty"` //set if the version is different in the 'to' image
+	FileCount   int    `json:"file_count"`
+	KeptCount   int    `json:"kept_count"`
+	RemovedSize int64  `json:"removed_size"`
+}
+
+// ConfigChange describes an image config change
+type ConfigChange struct {
+	Field  string `json:"field"`
+	Key    string `json:"key,omitempty"` //env var name, port, label, volume or history index
+	Change string `json:"change"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+// ImageDiffSource contains the loaded image data for the diff
+type ImageDiffSource struct {
+	Package  *Package
+	Objects  map[string]*FSObject
+	Packages []*SoftwarePackage
+}
+
+// NewImageDiffSource loads the final filesystem objects and the OS packages for the image package
+func NewImageDiffSource(archivePath string, pkg *Package) (*ImageDiffSource, error) {
+	source := &ImageDiffSource{
+		Package: pkg,
+		Objects: pkg.FinalObjects(),
+	}
+
+	var err error
+	source.Packages, err = pkg.LoadSystemPackages(archivePath, source.Objects)
+	if err != nil {
+		return nil, err
+	}
+
+	return source, nil
+}
+
+// DiffImages creates the semantic diff between the 'from' and 'to' images
+func DiffImages(from, to *ImageDiffSource) *ImageDiff {
+	diff := &ImageDiff{}
+	diffObjects(from.Objects, to.Objects, diff)
+	diff.Packages = diffPackages(from, to)
+	diff.Config = diffConfig(containerConfig(from.Package), containerConfig(to.Package))
+	return diff
+}
+
+// IsBinary returns true if the object is an executable regular file
+func IsBinary(object *ObjectMetadata) bool {
+	return object.Mode.IsRegular() && object.Mode&exeModeBits != 0
+}
+
+// ObjectTypeName returns the object type name (dir, file, symlink, etc)
+func ObjectTypeName(object *ObjectMetadata) string {
+	mode := object.Mode
+	switch {
+	case mode.IsDir():
+		return "dir"
+	case mode.IsRegular():
+		return "file"
+	case mode&os.ModeSymlink != 0:
+		return "symlink"
+	case mode&os.ModeNamedPipe != 0:
+		return "pipe"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeDevice != 0:
+		return "device"
+	default:
+		return "other"
+	}
+}
+
+func newDiffObject(name string, object *FSObject) *DiffObject {
+	return &DiffObject{
+		Name:       "/" + name,
+		Type:       ObjectTypeName(object.ObjectMetadata),
+		Size:       object.Size,
+		Mode:       object.Mode.String(),
+		UID:        object.UID,
+		GID:        object.GID,
+		LinkTarget: object.LinkTarget,
+		LayerIndex: object.LayerIndex,
+	}
+}
+
+func isModifiedObject(from, to *FSObject) bool {
+	return from.Size != to.Size ||
+		from.Mode != to.Mode ||
+		from.UID != to.UID ||
+		from.GID != to.GID ||
+		from.LinkTarget != to.LinkTarget
+}
+
+func diffObjects(from, to map[string]*FSObject, diff *ImageDiff) {
+	for _, name := range sortedObjectNames(from) {
+		fromObject := from[name]
+		isBinary := IsBinary(fromObject.ObjectMetadata)
+		toObject, ok := to[name]
+		if !ok {
+			object := newDiffObject(name, fromObject)
+			diff.Files.addRemoved(object)
+			if isBinary {
+				diff.Binaries.addRemoved(object)
+			}
+
+			continue
+		}
+
+		object := newDiffObject(name, toObject)
+		object.Modified = isModifiedObject(fromObject, toObject)
+		diff.Files.addKept(object)
+		if isBinary || IsBinary(toObject.ObjectMetadata) {
+			diff.Binaries.addKept(object)
+		}
+	}
+
+	for _, name := range sortedObjectNames(to) {
+		if _, ok := from[name]; ok {
+			continue
+		}
+
+		toObject := to[name]
+		object := newDiffObject(name, toObject)
+		diff.Files.addAdded(object)
+		if IsBinary(toObject.ObjectMetadata) {
+			diff.Binaries.addAdded(object)
+		}
+	}
+}
+
+func (d *ObjectsDiff) addRemoved(object *DiffObject) {
+	d.Removed = append(d.Removed, object)
+	d.Summary.RemovedCount++
+	d.Summary.RemovedSize += object.Size
+}
+
+func (d *ObjectsDiff) addKept(object *DiffObject) {
+	d.Kept = append(d.Kept, object)
+	d.Summary.KeptCount++
+	d.Summary.KeptSize += object.Size
+	if object.Modified {
+		d.Summary.ModifiedCount++
+	}
+}
+
+func (d *ObjectsDiff) addAdded(object *DiffObject) {
+	d.Added = append(d.Added, object)
+	d.Summary.AddedCount++
+	d.Summary.AddedSize += object.Size
+}
+
+func diffPackages(from, to *ImageDiffSource) PackagesDiff {
+	var diff PackagesDiff
+	toPackages := map[string]*SoftwarePackage{}
+	for _, pkg := range to.Packages {
+		toPackages[pkg.ID()] = pkg
+	}
+
+	fromPackages := map[string]struct{}{}
+	for _, pkg := range from.Packages {
+		fromPackages[pkg.ID()] = struct{}{}
+		change := &PackageChange{
+			Type:    pkg.Type,
+			Name:    pkg.Name,
+			Version: pkg.Version,
+		}
+
+		toPkg, hasPackageRecord := toPackages[pkg.ID()]
+		if hasPackageRecord && toPkg.Version != pkg.Version {
+			change.ToVersion = toPkg.Version
+		}
+
+		for _, name := range pkg.Files {
+			object, ok := from.Objects[name]
+			if !ok || object.Mode.IsDir() {
+				continue
+			}
+
+			change.FileCount++
+			if _, ok := to.Objects[name]; ok {
+				change.KeptCount++
+			} else {
+				change.RemovedSize += object.Size
+			}
+		}
+
+		switch {
+		case change.FileCount == 0:
+			//no file info (using the package database records)
+			if hasPackageRecord {
+				diff.Kept = append(diff.Kept, change)
+			} else {
+				diff.Removed = append(diff.Removed, change)
+			}
+		case change.KeptCount == 0:
+			diff.Removed = append(diff.Removed, change)
+		case change.KeptCount < change.FileCount:
+			diff.Partial = append(diff.Partial, change)
+		default:
+			diff.Kept = append(diff.Kept, change)
+		}
+	}
+
+	for _, pkg := range to.Packages {
+		if _, ok := fromPackages[pkg.ID()]; ok {
+			continue
+		}
+
+		change := &PackageChange{
+			Type:    pkg.Type,
+			Name:    pkg.Name,
+			Version: pkg.Version,
+		}
+
+		for _, name := range pkg.Files {
+			if object, ok := to.Objects[name]; ok && !object.Mode.IsDir() {
+				change.FileCount++
+				change.KeptCount++
+			}
+		}
+
+		diff.Added = append(diff.Added, change)
+	}
+
+	return diff
+}
+
+func containerConfig(pkg *Package) *ContainerConfig {
+	if pkg == nil || pkg.Config == nil || pkg.Config.Config == nil {
+		return &ContainerConfig{}
+	}
+
+	return pkg.Config.Config
+}
+
+func diffConfig(from, to *ContainerConfig) []*ConfigChange {
+	var changes []*ConfigChange
+	changes = append(changes, diffMaps(ConfigFieldEnv, envMap(from.Env), envMap(to.Env))...)
+	changes = append(changes, diffMaps(ConfigFieldExposedPorts, setMap(from.ExposedPorts), setMap(to.ExposedPorts))...)
+	changes = append(changes, diffValues(ConfigFieldEntrypoint, jsonValue(from.Entrypoint), jsonValue(to.Entrypoint))...)
+	changes = append(changes, diffValues(ConfigFieldCmd, jsonValue(from.Cmd), jsonValue(to.Cmd))...)
+	changes = append(changes, diffValues(ConfigFieldWorkingDir, from.WorkingDir, to.WorkingDir)...)
+	changes = append(changes, diffValues(ConfigFieldUser, from.User, to.User)...)
+	changes = append(changes, diffMaps(ConfigFieldVolumes, setMap(from.Volumes), setMap(to.Volumes))...)
+	changes = append(changes, diffMaps(ConfigFieldLabels, from.Labels, to.Labels)...)
+	changes = append(changes, diffValues(ConfigFieldStopSignal, from.StopSignal, to.StopSignal)...)
+	changes = append(changes, diffValues(ConfigFieldHealthcheck, jsonValue(from.Healthcheck), jsonValue(to.Healthcheck))...)
+	changes = append(changes, diffValues(ConfigFieldShell, jsonValue(from.Shell), jsonValue(to.Shell))...)
+	changes = append(changes, diffValues(ConfigFieldOnBuild, jsonValue(from.OnBuild), jsonValue(to.OnBuild))...)
+	return changes
+}
+
+func diffValues(field, from, to string) []*ConfigChange {
+	change := &ConfigChange{
+		Field: field,
+		From:  from,
+		To:    to,
+	}
+
+	switch {
+	case from == to:
+		return nil
+	case from == "":
+		change.Change = DiffAdded
+	case to == "":
+		change.Change = DiffRemoved
+	default:
+		change.Change = DiffModified
+	}
+
+	return []*ConfigChange{change}
+}
+
+func diffMaps(field string, from, to map[string]string) []*ConfigChange {
+	var changes []*ConfigChange
+	for _, key := range sortedKeys(from) {
+		toValue, ok := to[key]
+		switch {
+		case !ok:
+			changes = append(changes, &ConfigChange{
+				Field:  field,
+				Key:    key,
+				Change: DiffRemoved,
+				From:   from[key],
+			})
+		case toValue != from[key]:
+			changes = append(changes, &ConfigChange{
+				Field:  field,
+				Key:    key,
+				Change: DiffModified,
+				From:   from[key],
+				To:     toValue,
+			})
+		}
+	}
+
+	for _, key := range sortedKeys(to) {
+		if _, ok := from[key]; ok {
+			continue
+		}
+
+		changes = append(changes, &ConfigChange{
+			Field:  field,
+			Key:    key,
+			Change: DiffAdded,
+			To:     to[key],
+		})
+	}
+
+	return changes
+}
+
+func envMap(env []string) map[string]string {
+	values := map[string]string{}
+	for _, kv := range env {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 2 {
+			values[parts[0]] = parts[1]
+		} else {
+			values[parts[0]] = ""
+		}
+	}
+
+	return values
+}
+
+func setMap(set map[string]struct{}) map[string]string {
+	values := map[string]string{}
+	for k := range set {
+		values[k] = ""
+	}
+
+	return values
+}
+
+// jsonValue returns the JSON encoded value (empty for nil and empty values)
+func jsonValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	switch string(data) {
+	case "null", "[]", "{}":
+		return ""
+	}
+
+	return string(data)
+}
+
+func sortedKeys(values map[string]string) []string {
+	var keys []string
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedObjectNames(objects map[string]*FSObject) []string {
+	var names []string
+	for name := range objects {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -4,6 +4,8 @@ import (
 	"archive/tar"
 	"bytes"
 	"container/heap"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -30,6 +32,7 @@ type Package struct {
 	Layers          []*Layer
 	LayerIDRefs     map[string]*Layer
 	LayerRootFSRefs map[string]*Layer
+	Software        *SoftwareInventory //set by LoadSoftwareInventory
 }
 
 type LayerReport struct {
@@ -64,7 +67,9 @@ type Layer struct {
 }
 
 type LayerStats struct {
-	//BlobSize         uint64 `json:"blob_size"`
+	BlobSize         uint64 `json:"blob_size,omitempty"`      //gzip compressed layer data size (set by CalculateBlobSizes)
+	ZstdBlobSize     uint64 `json:"zstd_blob_size,omitempty"` //zstd compressed layer data size (set by CalculateBlobSizes)
+	TarSize          uint64 `json:"tar_size,omitempty"`       //uncompressed layer data size (set by CalculateBlobSizes)
 	AllSize          uint64 `json:"all_size"`
 	ObjectCount      uint64 `json:"object_count"`
 	DirCount         uint64 `json:"dir_count"`
@@ -142,17 +147,19 @@ func (ct *ChangeType) UnmarshalJSON(b []byte) error {
 }
 
 type ObjectMetadata struct {
-	Change     ChangeType  `json:"change,omitempty"`
-	Name       string      `json:"name,omitempty"`
-	Size       int64       `json:"size,omitempty"`
-	SizeHuman  string      `json:"size_human,omitempty"`
-	Mode       os.FileMode `json:"mode,omitempty"`
-	ModeHuman  string      `json:"mode_human,omitempty"`
-	UID        int         `json:"uid,omitempty"`
-	GID        int         `json:"gid,omitempty"`
-	ModTime    time.Time   `json:"mod_time,omitempty"`
-	ChangeTime time.Time   `json:"change_time,omitempty"`
-	LinkTarget string      `json:"link_target,omitempty"`
+	Change       ChangeType  `json:"change,omitempty"`
+	Name         string      `json:"name,omitempty"`
+	Size         int64       `json:"size,omitempty"`
+	SizeHuman    string      `json:"size_human,omitempty"`
+	Mode         os.FileMode `json:"mode,omitempty"`
+	ModeHuman    string      `json:"mode_human,omitempty"`
+	UID          int         `json:"uid,omitempty"`
+	GID          int         `json:"gid,omitempty"`
+	ModTime      time.Time   `json:"mod_time,omitempty"`
+	ChangeTime   time.Time   `json:"change_time,omitempty"`
+	LinkTarget   string      `json:"link_target,omitempty"`
+	Sha1Hash     string      `json:"sha1_hash,omitempty"` //set when the file data hashing is enabled
+	Capabilities string      `json:"capabilities,omitempty"`
 }
 
 type Changeset struct {
@@ -183,7 +190,7 @@ func newLayer(id string) *Layer {
 	return &layer
 }
 
-func LoadPackage(archivePath, imageID string, skipObjects bool) (*Package, error) {
+func LoadPackage(archivePath, imageID string, skipObjects bool, doHashData bool) (*Package, error) {
 	imageID = dockerutil.CleanImageID(imageID)
 
 	configObjectFileName := fmt.Sprintf("%s.json", imageID)
@@ -249,7 +256,7 @@ func LoadPackage(archivePath, imageID string, skipObjects bool) (*Package, error
 			case strings.HasSuffix(hdr.Name, layerSuffix):
 				parts := strings.Split(hdr.Name, "/")
 				layerID := parts[0]
-				layer, err := layerFromStream(tar.NewReader(tr), layerID)
+				layer, err := layerFromStream(tar.NewReader(tr), layerID, doHashData)
 				if err != nil {
 					log.Errorf("dockerimage.LoadPackage: error reading layer from archive(%v/%v) - %v", archivePath, hdr.Name, err)
 					return nil, err
@@ -323,6 +330,7 @@ func LoadPackage(archivePath, imageID string, skipObjects bool) (*Package, error
 
 		if pkg.Config.RootFS != nil && idx < len(pkg.Config.RootFS.DiffIDs) {
 			diffID := pkg.Config.RootFS.DiffIDs[idx]
+			layer.FSDiffID = diffID
 			pkg.LayerRootFSRefs[diffID] = layer
 		} else {
 			log.Debugf("dockerimage.LoadPackage: no FS diff for layer index %v", idx)
@@ -361,7 +369,7 @@ func LoadPackage(archivePath, imageID string, skipObjects bool) (*Package, error
 	return pkg, nil
 }
 
-func layerFromStream(tr *tar.Reader, layerID string) (*Layer, error) {
+func layerFromStream(tr *tar.Reader, layerID string, doHashData bool) (*Layer, error) {
 	layer := newLayer(layerID)
 
 	for {
@@ -392,6 +400,10 @@ func layerFromStream(tr *tar.Reader, layerID string) (*Layer, error) {
 			ChangeTime: hdr.ChangeTime,
 		}
 
+		if value, ok := hdr.PAXRecords[paxXattrPrefix+xattrCapability]; ok {
+			object.Capabilities = DecodeFileCapabilities([]byte(value))
+		}
+
 		layer.Objects = append(layer.Objects, object)
 		layer.References[object.Name] = object
 
@@ -434,6 +446,16 @@ func layerFromStream(tr *tar.Reader, layerID string) (*Layer, error) {
 			if isDeleted {
 				layer.Stats.DeletedFileCount++
 			}
+
+			if doHashData && !isDeleted && object.Size > 0 {
+				hasher := sha1.New()
+				if _, err := io.Copy(hasher, tr); err != nil {
+					log.Errorf("layerFromStream: error hashing layer(%v) object(%v) - %v", layerID, object.Name, err)
+					return nil, err
+				}
+
+				object.Sha1Hash = hex.EncodeToString(hasher.Sum(nil))
+			}
 		case tar.TypeDir:
 			layer.Stats.DirCount++
 			if uint64(object.Size) > layer.Stats.MaxDirSize {
@@ -458,40 +480,63 @@ type TarReadCloser struct {
 	io.Closer
 }
 
+// FileReaderFromTar returns a reader for the file in the tar file.
+// It follows the symlinks and the hard links in the tar file
+// (used for the shared layers in 'docker save' archives).
+// The caller needs to close the returned reader.
 func FileReaderFromTar(tarPath, filePath string) (io.ReadCloser, error) {
-	tfile, err := os.Open(tarPath)
-	if err != nil {
-		log.Errorf("dockerimage.FileReaderFromTar: os.Open error - %v", err)
-		return nil, err
-	}
-
-	defer tfile.Close()
-	tr := tar.NewReader(tfile)
-
-	for {
-		hdr, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-
+	filePath = filepath.Clean(filePath)
+	for depth := 0; depth < maxLinkDepth; depth++ {
+		tfile, err := os.Open(tarPath)
 		if err != nil {
+			log.Errorf("dockerimage.FileReaderFromTar: os.Open error - %v", err)
 			return nil, err
 		}
 
-		if hdr == nil || hdr.Name == "" {
-			continue
-		}
+		tr := tar.NewReader(tfile)
+		var linkTarget string
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				tfile.Close()
+				return nil, err
+			}
+
+			if hdr == nil || hdr.Name == "" {
+				continue
+			}
+
+			if filepath.Clean(hdr.Name) != filePath {
+				continue
+			}
 
-		hdr.Name = filepath.Clean(hdr.Name)
-		if hdr.Name == filePath {
 			switch hdr.Typeflag {
-			case tar.TypeReg, tar.TypeSymlink, tar.TypeLink:
+			case tar.TypeReg:
 				return TarReadCloser{
 					Reader: tr,
 					Closer: tfile,
 				}, nil
+			case tar.TypeSymlink:
+				linkTarget = filepath.Clean(filepath.Join(filepath.Dir(filePath), hdr.Linkname))
+			case tar.TypeLink:
+				linkTarget = filepath.Clean(hdr.Linkname)
 			}
+
+			if linkTarget != "" {
+				break
+			}
+		}
+
+		tfile.Close()
+		if linkTarget == "" {
+			break
 		}
+
+		filePath = linkTarget
 	}
 
 	return nil, fmt.Errorf("no file - %s", filePath)
@@ -534,13 +579,81 @@ func FileDataFromTar(tarPath, filePath string) ([]byte, error) {
 }
 
 func LoadManifestObject(archivePath, imageID string) (*ManifestObject, error) {
-	return nil, nil
+	imageID = dockerutil.CleanImageID(imageID)
+	configObjectFileName := fmt.Sprintf("%s.json", imageID)
+
+	data, err := FileDataFromTar(archivePath, manifestFileName)
+	if err != nil {
+		log.Errorf("dockerimage.LoadManifestObject: error reading manifest file from archive(%v/%v) - %v", archivePath, manifestFileName, err)
+		return nil, err
+	}
+
+	var manifests []ManifestObject
+	if err := json.Unmarshal(data, &manifests); err != nil {
+		log.Errorf("dockerimage.LoadManifestObject: error decoding manifest file from archive(%v/%v) - %v", archivePath, manifestFileName, err)
+		return nil, err
+	}
+
+	for _, m := range manifests {
+		if m.Config == configObjectFileName {
+			manifest := m
+			return &manifest, nil
+		}
+	}
+
+	return nil, fmt.Errorf("dockerimage.LoadManifestObject: missing manifest object for image ID - %v", imageID)
 }
 
 func LoadConfigObject(archivePath, imageID string) (*ConfigObject, error) {
-	return nil, nil
+	imageID = dockerutil.CleanImageID(imageID)
+	configObjectFileName := fmt.Sprintf("%s.json", imageID)
+
+	data, err := FileDataFromTar(archivePath, configObjectFileName)
+	if err != nil {
+		log.Errorf("dockerimage.LoadConfigObject: error reading config object from archive(%v/%v) - %v", archivePath, configObjectFileName, err)
+		return nil, err
+	}
+
+	var imageConfig ConfigObject
+	if err := json.Unmarshal(data, &imageConfig); err != nil {
+		log.Errorf("dockerimage.LoadConfigObject: error decoding config object from archive(%v/%v) - %v", archivePath, configObjectFileName, err)
+		return nil, err
+	}
+
+	return &imageConfig, nil
 }
 
+// LoadLayer loads the image layer metadata (the layer is selected using its ID or its path in the archive)
 func LoadLayer(archivePath, imageID, layerID string) (*Layer, error) {
-	return nil, nil
+	manifest, err := LoadManifestObject(archivePath, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, layerPath := range manifest.Layers {
+		parts := strings.Split(layerPath, "/")
+		if parts[0] != layerID && layerPath != layerID {
+			continue
+		}
+
+		layerReader, err := FileReaderFromTar(archivePath, layerPath)
+		if err != nil {
+			log.Errorf("dockerimage.LoadLayer: error reading layer from archive(%v/%v) - %v", archivePath, layerPath, err)
+			return nil, err
+		}
+
+		defer layerReader.Close()
+
+		layer, err := layerFromStream(tar.NewReader(layerReader), parts[0], false)
+		if err != nil {
+			log.Errorf("dockerimage.LoadLayer: error reading layer from archive(%v/%v) - %v", archivePath, layerPath, err)
+			return nil, err
+		}
+
+		layer.Index = idx
+		layer.Path = layerPath
+		return layer, nil
+	}
+
+	return nil, ErrLayerNotFound
 }
@@ -0,0 +1,94 @@
+package dockerimage
+
+import (
+	"sort"
+)
+
+// DuplicateFiles describes the files with identical data in the image layers
+type DuplicateFiles struct {
+	GroupCount      int               `json:"group_count"`
+	FileCount       int               `json:"file_count"`
+	AllSize         uint64            `json:"all_size"`
+	ReclaimableSize uint64            `json:"reclaimable_size"`
+	Groups          []*DuplicateGroup `json:"groups,omitempty"`
+}
+
+// DuplicateGroup is a set of the identical files
+// (the reclaimable size is the size of all files except one copy)
+type DuplicateGroup struct {
+	Sha1Hash        string           `json:"sha1_hash"`
+	Size            uint64           `json:"size"`
+	AllSize         uint64           `json:"all_size"`
+	ReclaimableSize uint64           `json:"reclaimable_size"`
+	Files           []*DuplicateFile `json:"files"`
+}
+
+// DuplicateFile is a file location in the image layers
+type DuplicateFile struct {
+	Path       string `json:"path"`
+	LayerIndex int    `json:"layer_index"`
+}
+
+// FindDuplicates groups the regular files with the same data across all image layers
+// (the file data hashes are required, see LoadPackage). The groups are ranked by
+// the reclaimable size (maxGroups limits the number of the returned groups;
+// zero or less returns all of them). The totals always include all groups.
+func (p *Package) FindDuplicates(maxGroups int) *DuplicateFiles {
+	result := &DuplicateFiles{}
+	groups := map[string]*DuplicateGroup{}
+
+	for idx, layer := range p.Layers {
+		for _, object := range layer.Objects {
+			if object.Change == ChangeDelete ||
+				object.Sha1Hash == "" ||
+				object.Size <= 0 ||
+				!object.Mode.IsRegular() {
+				continue
+			}
+
+			group, ok := groups[object.Sha1Hash]
+			if !ok {
+				group = &DuplicateGroup{
+					Sha1Hash: object.Sha1Hash,
+					Size:     uint64(object.Size),
+				}
+
+				groups[object.Sha1Hash] = group
+			}
+
+			group.AllSize += uint64(object.Size)
+			group.Files = append(group.Files, &DuplicateFile{
+				Path:       "/" + objectPath(object.Name),
+				LayerIndex: idx,
+			})
+		}
+	}
+
+	for _, group := range groups {
+		if len(group.Files) < 2 {
+			continue
+		}
+
+		group.ReclaimableSize = group.AllSize - group.Size
+
+		result.GroupCount++
+		result.FileCount += len(group.Files)
+		result.AllSize += group.AllSize
+		result.ReclaimableSize += group.ReclaimableSize
+		result.Groups = append(result.Groups, group)
+	}
+
+	sort.Slice(result.Groups, func(i, j int) bool {
+		if result.Groups[i].ReclaimableSize != result.Groups[j].ReclaimableSize {
+			return result.Groups[i].ReclaimableSize > result.Groups[j].ReclaimableSize
+		}
+
+		return result.Groups[i].Sha1Hash < result.Groups[j].Sha1Hash
+	})
+
+	if maxGroups > 0 && len(result.Groups) > maxGroups {
+		result.Groups = result.Groups[:maxGroups]
+	}
+
+	return result
+}
@@ -0,0 +1,415 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerutil"
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+const (
+	legacyRepositoriesFileName = "repositories"
+	configKeyCreated           = "created"
+	configKeyConfig            = "config"
+	configKeyRootFS            = "rootfs"
+	configKeyHistory           = "history"
+)
+
+// LayerChanges describes the filesystem changes for a new image layer
+type LayerChanges struct {
+	AddPaths    map[string]string //map[DST_IMAGE_PATH]SRC_LOCAL_PATH
+	RemovePaths []string
+}
+
+// ImageEdits describes the changes for a new image based on an existing image
+type ImageEdits struct {
+	Config    *ContainerConfig //nil to keep the original runtime config
+	LayerPath string           //new layer tarball (empty if there are no filesystem changes)
+	CreatedBy string
+	Comment   string
+	RepoTags  []string
+}
+
+//data structures from https://github.com/moby/moby/blob/master/image/image.go
+//(without the extra fields in XHistory)
+
+type historyRecord struct {
+	Created    time.Time `json:"created"`
+	Author     string    `json:"author,omitempty"`
+	CreatedBy  string    `json:"created_by,omitempty"`
+	Comment    string    `json:"comment,omitempty"`
+	EmptyLayer bool      `json:"empty_layer,omitempty"`
+}
+
+// WriteLayer creates a new layer tarball with the selected filesystem changes
+func WriteLayer(changes *LayerChanges, outputPath string) error {
+	ofile, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+
+	defer ofile.Close()
+
+	tw := tar.NewWriter(ofile)
+
+	for _, rmPath := range changes.RemovePaths {
+		name := strings.TrimPrefix(filepath.Clean(rmPath), "/")
+		if name == "" || name == "." {
+			continue
+		}
+
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     filepath.Join(filepath.Dir(name), WhiteoutPrefix+filepath.Base(name)),
+			Mode:     0600,
+			ModTime:  time.Unix(0, 0),
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+	}
+
+	//the added paths are sorted to get the same layer digest for the same changes
+	var dstPaths []string
+	for dstPath := range changes.AddPaths {
+		dstPaths = append(dstPaths, dstPath)
+	}
+
+	sort.Strings(dstPaths)
+
+	for _, dstPath := range dstPaths {
+		srcPath := changes.AddPaths[dstPath]
+		dstPath = strings.TrimPrefix(filepath.Clean(dstPath), "/")
+		err := filepath.Walk(srcPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			relPath, err := filepath.Rel(srcPath, path)
+			if err != nil {
+				return err
+			}
+
+			return addLayerObject(tw, path, filepath.Join(dstPath, relPath), info)
+		})
+
+		if err != nil {
+			log.Errorf("dockerimage.WriteLayer: error adding %v - %v", srcPath, err)
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+func addLayerObject(tw *tar.Writer, srcPath, name string, info os.FileInfo) error {
+	var linkTarget string
+	if info.Mode()&os.ModeSymlink != 0 {
+		var err error
+		if linkTarget, err = os.Readlink(srcPath); err != nil {
+			return err
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(info, linkTarget)
+	if err != nil {
+		return err
+	}
+
+	//the new objects are owned by root (same as the files added with COPY/ADD)
+	hdr.Name = name
+	hdr.Uid = 0
+	hdr.Gid = 0
+	hdr.Uname = ""
+	hdr.Gname = ""
+	if info.IsDir() {
+		hdr.Name = name + "/"
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	sfile, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+
+	defer sfile.Close()
+
+	_, err = io.Copy(tw, sfile)
+	return err
+}
+
+// SaveEditedImage creates a new 'docker save' archive with the edited image (returns the new image ID)
+func SaveEditedImage(archivePath, imageID, outputPath string, edits *ImageEdits) (string, error) {
+	imageID = dockerutil.CleanImageID(imageID)
+	manifest, err := LoadManifestObject(archivePath, imageID)
+	if err != nil {
+		return "", err
+	}
+
+	configData, err := FileDataFromTar(archivePath, manifest.Config)
+	if err != nil {
+		log.Errorf("dockerimage.SaveEditedImage: error reading config object from archive(%v/%v) - %v", archivePath, manifest.Config, err)
+		return "", err
+	}
+
+	//using raw config fields to preserve the config data we don't know about
+	var configFields map[string]json.RawMessage
+	if err := json.Unmarshal(configData, &configFields); err != nil {
+		log.Errorf("dockerimage.SaveEditedImage: error decoding config object - %v", err)
+		return "", err
+	}
+
+	created := time.Now().UTC()
+	if err := setRawField(configFields, configKeyCreated, created); err != nil {
+		return "", err
+	}
+
+	if edits.Config != nil {
+		if err := setRawField(configFields, configKeyConfig, edits.Config); err != nil {
+			return "", err
+		}
+	}
+
+	var layerName string
+	if edits.LayerPath != "" {
+		diffID, err := fileDigest(edits.LayerPath)
+		if err != nil {
+			return "", err
+		}
+
+		var rootFS RootFS
+		if raw, ok := configFields[configKeyRootFS]; ok {
+			if err := json.Unmarshal(raw, &rootFS); err != nil {
+				return "", err
+			}
+		}
+
+		if rootFS.Type == "" {
+			rootFS.Type = TypeLayers
+		}
+
+		rootFS.DiffIDs = append(rootFS.DiffIDs, fmt.Sprintf("%s:%s", digestAlgorithm, diffID))
+		if err := setRawField(configFields, configKeyRootFS, &rootFS); err != nil {
+			return "", err
+		}
+
+		layerName = fmt.Sprintf("%s%s", diffID, layerSuffix)
+	}
+
+	var history []json.RawMessage
+	if raw, ok := configFields[configKeyHistory]; ok {
+		if err := json.Unmarshal(raw, &history); err != nil {
+			return "", err
+		}
+	}
+
+	record, err := json.Marshal(&historyRecord{
+		Created:    created,
+		CreatedBy:  edits.CreatedBy,
+		Comment:    edits.Comment,
+		EmptyLayer: edits.LayerPath == "",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	history = append(history, record)
+	if err := setRawField(configFields, configKeyHistory, history); err != nil {
+		return "", err
+	}
+
+	newConfigData, err := json.Marshal(configFields)
+	if err != nil {
+		return "", err
+	}
+
+	configHash := sha256.Sum256(newConfigData)
+	newImageID := hex.EncodeToString(configHash[:])
+
+	newManifest := ManifestObject{
+		Config:   fmt.Sprintf("%s.json", newImageID),
+		RepoTags: edits.RepoTags,
+		Layers:   append([]string{}, manifest.Layers...),
+	}
+
+	if layerName != "" {
+		newManifest.Layers = append(newManifest.Layers, layerName)
+	}
+
+	newManifestData, err := json.Marshal([]ManifestObject{newManifest})
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(outputPath)
+	if !fsutil.DirExists(dir) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+	}
+
+	ofile, err := os.Create(outputPath)
+	if err != nil {
+		return "", err
+	}
+
+	defer ofile.Close()
+
+	tw := tar.NewWriter(ofile)
+	skipNames := map[string]struct{}{
+		manifestFileName:           {},
+		legacyRepositoriesFileName: {},
+		manifest.Config:            {},
+	}
+
+	if err := copyArchiveObjects(archivePath, tw, skipNames); err != nil {
+		log.Errorf("dockerimage.SaveEditedImage: error copying archive objects - %v", err)
+		return "", err
+	}
+
+	if layerName != "" {
+		if err := addArchiveFile(tw, layerName, edits.LayerPath); err != nil {
+			return "", err
+		}
+	}
+
+	if err := addArchiveData(tw, newManifest.Config, newConfigData); err != nil {
+		return "", err
+	}
+
+	if err := addArchiveData(tw, manifestFileName, newManifestData); err != nil {
+		return "", err
+	}
+
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+
+	return newImageID, nil
+}
+
+func setRawField(fields map[string]json.RawMessage, key string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	fields[key] = raw
+	return nil
+}
+
+func fileDigest(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func copyArchiveObjects(archivePath string, tw *tar.Writer, skipNames map[string]struct{}) error {
+	afile, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+
+	defer afile.Close()
+
+	tr := tar.NewReader(afile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if hdr == nil || hdr.Name == "" {
+			continue
+		}
+
+		if _, ok := skipNames[filepath.Clean(hdr.Name)]; ok {
+			continue
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := io.Copy(tw, tr); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func addArchiveFile(tw *tar.Writer, name, filePath string) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     filepath.Dir(name) + "/",
+		Mode:     0755,
+		ModTime:  info.ModTime(),
+	}); err != nil {
+		return err
+	}
+
+	if err := addLayerObject(tw, filePath, name, info); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func addArchiveData(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err := tw.Write(data)
+	return err
+}
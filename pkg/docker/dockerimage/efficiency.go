@@ -0,0 +1,228 @@
+package dockerimage
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Wasted space reasons
+const (
+	WasteModified   = "modified"
+	WasteDeleted    = "deleted"
+	WasteDuplicated = "duplicated"
+)
+
+// ImageEfficiency describes how much of the image data is shadowed by the later layers
+type ImageEfficiency struct {
+	Score       float64           `json:"score"`
+	AllSize     uint64            `json:"all_size"`
+	WastedSize  uint64            `json:"wasted_size"`
+	WastedCount int               `json:"wasted_count"`
+	Layers      []*LayerWaste     `json:"layers"`
+	WastedPaths []*WastedPath     `json:"wasted_paths,omitempty"`
+	Totals      map[string]uint64 `json:"totals,omitempty"`
+}
+
+// LayerWaste is the layer data shadowed by the later layers
+type LayerWaste struct {
+	Index       int    `json:"index"`
+	ID          string `json:"id"`
+	WastedSize  uint64 `json:"wasted_size"`
+	WastedCount int    `json:"wasted_count"`
+}
+
+// WastedPath is a path with the object versions shadowed by the later layers
+type WastedPath struct {
+	Path       string   `json:"path"`
+	WastedSize uint64   `json:"wasted_size"`
+	Count      int      `json:"count"`
+	Layers     []int    `json:"layers"`
+	Reasons    []string `json:"reasons"`
+}
+
+type fsVersion struct {
+	object     *ObjectMetadata
+	layerIndex int
+}
+
+// AnalyzeEfficiency correlates the objects across the image layers to find the data
+// that is not visible in the final image filesystem (the objects modified, whited-out
+// or added again by the later layers). The wasted paths are ranked by the wasted size
+// (maxPaths limits the number of the returned paths; zero or less returns all of them).
+func (p *Package) AnalyzeEfficiency(maxPaths int) *ImageEfficiency {
+	result := &ImageEfficiency{
+		Score:  1,
+		Totals: map[string]uint64{},
+	}
+
+	paths := map[string]*WastedPath{}
+	current := map[string]*fsVersion{}
+
+	shadow := func(name string, version *fsVersion, reason string) {
+		result.Layers[version.layerIndex].WastedCount++
+		result.WastedCount++
+
+		size := uint64(0)
+		if version.object.Size > 0 {
+			size = uint64(version.object.Size)
+		}
+
+		result.Layers[version.layerIndex].WastedSize += size
+		result.WastedSize += size
+		result.Totals[reason] += size
+
+		wp, ok := paths[name]
+		if !ok {
+			wp = &WastedPath{Path: name}
+			paths[name] = wp
+		}
+
+		wp.WastedSize += size
+		wp.Count++
+		wp.Layers = appendUniqueInt(wp.Layers, version.layerIndex)
+		wp.Reasons = appendUniqueString(wp.Reasons, reason)
+	}
+
+	//hides all objects under the directory (and the directory itself if withDir is set)
+	hideTree := func(dir string, withDir bool, reason string) {
+		if withDir {
+			if version, ok := current[dir]; ok {
+				shadow(dir, version, reason)
+				delete(current, dir)
+			}
+		}
+
+		prefix := dir + "/"
+		for name, version := range current {
+			if strings.HasPrefix(name, prefix) {
+				shadow(name, version, reason)
+				delete(current, name)
+			}
+		}
+	}
+
+	for idx, layer := range p.Layers {
+		result.Layers = append(result.Layers, &LayerWaste{
+			Index: idx,
+			ID:    layer.ID,
+		})
+
+		//the whiteouts only apply to the lower layers,
+		//so they are processed before the layer objects
+		for _, object := range layer.Objects {
+			if object.Change != ChangeDelete {
+				continue
+			}
+
+			name := objectPath(object.Name)
+			if name == "" {
+				continue
+			}
+
+			objectBase := filepath.Base(name)
+			switch {
+			case objectBase == whiteoutOpaqueDirMarker:
+				hideTree(filepath.Dir(name), false, WasteDeleted)
+			case strings.HasPrefix(objectBase, WhiteoutPrefix):
+				//other whiteout meta objects
+			default:
+				hideTree(name, true, WasteDeleted)
+			}
+		}
+
+		for _, object := range layer.Objects {
+			if object.Change == ChangeDelete {
+				continue
+			}
+
+			if object.Size > 0 {
+				result.AllSize += uint64(object.Size)
+			}
+
+			name := objectPath(object.Name)
+			if name == "" {
+				continue
+			}
+
+			if prev, ok := current[name]; ok {
+				switch {
+				case prev.object.Mode.IsDir() && object.Mode.IsDir():
+					//directories are merged
+				case prev.object.Mode.IsDir():
+					hideTree(name, false, WasteModified)
+					shadow(name, prev, WasteModified)
+				case isSameObjectData(prev.object, object):
+					shadow(name, prev, WasteDuplicated)
+				default:
+					shadow(name, prev, WasteModified)
+				}
+			}
+
+			current[name] = &fsVersion{
+				object:     object,
+				layerIndex: idx,
+			}
+		}
+	}
+
+	if result.AllSize > 0 {
+		result.Score = float64(result.AllSize-result.WastedSize) / float64(result.AllSize)
+	}
+
+	for _, wp := range paths {
+		if wp.WastedSize == 0 {
+			//empty files, links and directories
+			continue
+		}
+
+		result.WastedPaths = append(result.WastedPaths, wp)
+	}
+
+	sort.Slice(result.WastedPaths, func(i, j int) bool {
+		if result.WastedPaths[i].WastedSize != result.WastedPaths[j].WastedSize {
+			return result.WastedPaths[i].WastedSize > result.WastedPaths[j].WastedSize
+		}
+
+		return result.WastedPaths[i].Path < result.WastedPaths[j].Path
+	})
+
+	if maxPaths > 0 && len(result.WastedPaths) > maxPaths {
+		result.WastedPaths = result.WastedPaths[:maxPaths]
+	}
+
+	return result
+}
+
+// isSameObjectData checks if the later object version likely has the same data
+// (without the content digests the same type and size is used as an approximation)
+func isSameObjectData(prev, object *ObjectMetadata) bool {
+	if prev.Sha1Hash != "" && object.Sha1Hash != "" {
+		return prev.Sha1Hash == object.Sha1Hash
+	}
+
+	return prev.Size > 0 &&
+		prev.Size == object.Size &&
+		prev.Mode.IsRegular() &&
+		object.Mode.IsRegular()
+}
+
+func appendUniqueInt(list []int, value int) []int {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+
+	return append(list, value)
+}
+
+func appendUniqueString(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+
+	return append(list, value)
+}
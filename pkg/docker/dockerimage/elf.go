@@ -0,0 +1,481 @@
+package dockerimage
+
+import (
+	"bufio"
+	"bytes"
+	"debug/elf"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	elfHeaderMinSize     = 52
+	elfMaxInMemorySize   = 16 * 1024 * 1024 //the bigger ELF objects are parsed from temporary files
+	elfDebugSectionPfx   = ".debug"
+	elfZDebugSectionPfx  = ".zdebug"
+	elfSymtabSection     = ".symtab"
+	elfOriginVar         = "$ORIGIN"
+	elfOriginVarBraces   = "${ORIGIN}"
+	ldSoConfPath         = "etc/ld.so.conf"
+	ldSoConfDirPath      = "etc/ld.so.conf.d"
+	ldSoConfInclude      = "include"
+	ldMuslPathPrefix     = "etc/ld-musl-"
+	ldMuslPathExt        = ".path"
+	envLdLibraryPathName = "LD_LIBRARY_PATH"
+)
+
+// ELF object types
+const (
+	ElfTypeExecutable    = "executable"
+	ElfTypeSharedLibrary = "shared-library"
+	ElfTypeRelocatable   = "relocatable"
+	ElfTypeCore          = "core"
+	ElfTypeOther         = "other"
+)
+
+var elfDefaultLibDirs = []string{
+	"lib",
+	"lib64",
+	"lib32",
+	"usr/lib",
+	"usr/lib64",
+	"usr/lib32",
+	"usr/local/lib",
+}
+
+var elfMultiarchNames = map[elf.Machine]string{
+	elf.EM_X86_64:  "x86_64-linux-gnu",
+	elf.EM_386:     "i386-linux-gnu",
+	elf.EM_AARCH64: "aarch64-linux-gnu",
+	elf.EM_ARM:     "arm-linux-gnueabihf",
+	elf.EM_PPC64:   "powerpc64le-linux-gnu",
+	elf.EM_S390:    "s390x-linux-gnu",
+	elf.EM_MIPS:    "mips64el-linux-gnuabi64",
+	elf.EM_RISCV:   "riscv64-linux-gnu",
+}
+
+var elfArchNames = map[elf.Machine]string{
+	elf.EM_X86_64:  "amd64",
+	elf.EM_386:     "386",
+	elf.EM_AARCH64: "arm64",
+	elf.EM_ARM:     "arm",
+	elf.EM_PPC64:   "ppc64",
+	elf.EM_S390:    "s390x",
+	elf.EM_MIPS:    "mips",
+	elf.EM_RISCV:   "riscv64",
+}
+
+// ElfInventory describes the ELF objects (executables and shared libraries)
+// in the final image filesystem
+type ElfInventory struct {
+	Count             int          `json:"count"`
+	Executables       int          `json:"executables"`
+	SharedLibraries   int          `json:"shared_libraries"`
+	Static            int          `json:"static"`
+	Dynamic           int          `json:"dynamic"`
+	Stripped          int          `json:"stripped"`
+	DebugSize         uint64       `json:"debug_size"`
+	UnresolvedCount   int          `json:"unresolved_count"` //number of the objects with missing libraries or interpreters
+	Objects           []*ElfObject `json:"objects,omitempty"`
+	Architectures     []string     `json:"architectures,omitempty"`
+	LibrarySearchDirs []string     `json:"library_search_dirs,omitempty"`
+}
+
+// ElfObject is an ELF object in the final image filesystem
+type ElfObject struct {
+	Path               string   `json:"path"`
+	LayerIndex         int      `json:"layer_index"`
+	Size               int64    `json:"size"`
+	Class              string   `json:"class"`
+	Arch               string   `json:"arch"`
+	Type               string   `json:"type"`
+	Static             bool     `json:"static"`
+	Interpreter        string   `json:"interpreter,omitempty"`
+	InterpreterMissing bool     `json:"interpreter_missing,omitempty"`
+	SOName             string   `json:"soname,omitempty"`
+	Needed             []string `json:"needed,omitempty"`
+	RunPath            []string `json:"run_path,omitempty"`
+	Stripped           bool     `json:"stripped"`
+	DebugSize          uint64   `json:"debug_size,omitempty"`
+	Unresolved         []string `json:"unresolved,omitempty"` //DT_NEEDED libraries not found in the final image filesystem
+	machine            elf.Machine
+	useRunPath         bool
+}
+
+// InspectElfObjects parses the ELF objects in the final image filesystem
+// and checks if their interpreters and DT_NEEDED libraries are present
+// (using the RPATH/RUNPATH, LD_LIBRARY_PATH from the image config,
+// the 'ld.so.conf' and musl 'ld-musl-*.path' directories and the default library directories)
+func (p *Package) InspectElfObjects(archivePath string, objects map[string]*FSObject) (*ElfInventory, error) {
+	if objects == nil {
+		objects = p.FinalObjects()
+	}
+
+	var names []string
+	for name, object := range objects {
+		if object.Mode.IsRegular() &&
+			(object.Size >= elfHeaderMinSize || isLdConfigFile(name)) {
+			names = append(names, name)
+		}
+	}
+
+	var elfObjects []*ElfObject
+	configData := map[string][]byte{}
+	err := p.ReadFinalFiles(archivePath, objects, names,
+		func(name string, reader io.Reader) error {
+			if isLdConfigFile(name) {
+				data, err := ioutil.ReadAll(reader)
+				if err != nil {
+					return err
+				}
+
+				configData[name] = data
+				return nil
+			}
+
+			magic := make([]byte, len(elfMagic))
+			if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != elfMagic {
+				return nil
+			}
+
+			object, err := readElfObject(io.MultiReader(bytes.NewReader(magic), reader), objects[name].Size)
+			if err != nil {
+				return err
+			}
+
+			if object == nil {
+				//not a valid ELF object
+				return nil
+			}
+
+			object.Path = "/" + name
+			object.LayerIndex = objects[name].LayerIndex
+			object.Size = objects[name].Size
+			elfObjects = append(elfObjects, object)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ElfInventory{
+		LibrarySearchDirs: libraryConfigDirs(configData),
+	}
+
+	var envLibDirs []string
+	if p.Config != nil && p.Config.Config != nil {
+		for _, kv := range p.Config.Config.Env {
+			if strings.HasPrefix(kv, envLdLibraryPathName+"=") {
+				envLibDirs = splitPathList(kv[len(envLdLibraryPathName)+1:])
+			}
+		}
+	}
+
+	archs := map[string]struct{}{}
+	for _, object := range elfObjects {
+		result.Count++
+		switch object.Type {
+		case ElfTypeExecutable:
+			result.Executables++
+		case ElfTypeSharedLibrary:
+			result.SharedLibraries++
+		}
+
+		if object.Static {
+			result.Static++
+		} else {
+			result.Dynamic++
+		}
+
+		if object.Stripped {
+			result.Stripped++
+		}
+
+		result.DebugSize += object.DebugSize
+		archs[object.Arch] = struct{}{}
+
+		if object.Interpreter != "" {
+			if _, found := ResolveFinalPath(objects, object.Interpreter); found == nil {
+				object.InterpreterMissing = true
+			}
+		}
+
+		searchDirs := object.librarySearchDirs(envLibDirs, result.LibrarySearchDirs)
+		for _, lib := range object.Needed {
+			if !findLibrary(objects, lib, searchDirs) {
+				object.Unresolved = append(object.Unresolved, lib)
+			}
+		}
+
+		if object.InterpreterMissing || len(object.Unresolved) > 0 {
+			result.UnresolvedCount++
+		}
+	}
+
+	for arch := range archs {
+		result.Architectures = append(result.Architectures, arch)
+	}
+
+	sort.Strings(result.Architectures)
+	sort.Slice(elfObjects, func(i, j int) bool {
+		return elfObjects[i].Path < elfObjects[j].Path
+	})
+
+	result.Objects = elfObjects
+	return result, nil
+}
+
+// readElfObject reads and parses the ELF object data (returns nil if it's not a valid ELF object)
+func readElfObject(reader io.Reader, size int64) (*ElfObject, error) {
+	var object *ElfObject
+	err := withElfData(reader, size, func(data io.ReaderAt) error {
+		if parsed, err := parseElfObject(data); err == nil {
+			object = parsed
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return object, nil
+}
+
+// withElfData calls the handler with the ELF object data.
+// The big objects are saved in temporary files to limit the memory usage.
+func withElfData(reader io.Reader, size int64, handler func(data io.ReaderAt) error) error {
+	if size <= elfMaxInMemorySize {
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+
+		return handler(bytes.NewReader(data))
+	}
+
+	tmpFile, err := ioutil.TempFile("", "docker-slim-elf-")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+	}()
+
+	if _, err := io.Copy(tmpFile, reader); err != nil {
+		return err
+	}
+
+	return handler(tmpFile)
+}
+
+// ParseElfObject parses the ELF object data
+func ParseElfObject(data []byte) (*ElfObject, error) {
+	return parseElfObject(bytes.NewReader(data))
+}
+
+func parseElfObject(reader io.ReaderAt) (*ElfObject, error) {
+	ef, err := elf.NewFile(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ef.Close()
+
+	object := &ElfObject{
+		Class:    ef.Class.String(),
+		Arch:     elfArchName(ef.Machine),
+		Stripped: ef.Section(elfSymtabSection) == nil,
+		machine:  ef.Machine,
+	}
+
+	var hasDynamic bool
+	for _, prog := range ef.Progs {
+		switch prog.Type {
+		case elf.PT_INTERP:
+			interp, err := ioutil.ReadAll(prog.Open())
+			if err == nil {
+				object.Interpreter = strings.TrimRight(string(interp), "\x00")
+			}
+		case elf.PT_DYNAMIC:
+			hasDynamic = true
+		}
+	}
+
+	for _, section := range ef.Sections {
+		if strings.HasPrefix(section.Name, elfDebugSectionPfx) ||
+			strings.HasPrefix(section.Name, elfZDebugSectionPfx) {
+			object.DebugSize += section.Size
+		}
+	}
+
+	if hasDynamic {
+		object.Needed, _ = ef.DynString(elf.DT_NEEDED)
+		if sonames, _ := ef.DynString(elf.DT_SONAME); len(sonames) > 0 {
+			object.SOName = sonames[0]
+		}
+
+		//RPATH is ignored when RUNPATH is set
+		if runPath, _ := ef.DynString(elf.DT_RUNPATH); len(runPath) > 0 {
+			object.useRunPath = true
+			for _, value := range runPath {
+				object.RunPath = append(object.RunPath, splitPathList(value)...)
+			}
+		} else if rpath, _ := ef.DynString(elf.DT_RPATH); len(rpath) > 0 {
+			for _, value := range rpath {
+				object.RunPath = append(object.RunPath, splitPathList(value)...)
+			}
+		}
+	}
+
+	switch ef.Type {
+	case elf.ET_EXEC:
+		object.Type = ElfTypeExecutable
+	case elf.ET_DYN:
+		//PIE executables are ET_DYN objects with an interpreter
+		//(some shared libraries like libc also have an interpreter, but they also have a SONAME)
+		if object.Interpreter != "" && object.SOName == "" {
+			object.Type = ElfTypeExecutable
+		} else {
+			object.Type = ElfTypeSharedLibrary
+		}
+	case elf.ET_REL:
+		object.Type = ElfTypeRelocatable
+	case elf.ET_CORE:
+		object.Type = ElfTypeCore
+	default:
+		object.Type = ElfTypeOther
+	}
+
+	object.Static = object.Interpreter == "" && len(object.Needed) == 0
+	return object, nil
+}
+
+// librarySearchDirs returns the library directories in the dynamic loader search order
+func (o *ElfObject) librarySearchDirs(envDirs []string, configDirs []string) []string {
+	origin := filepath.Dir(o.Path)
+	expand := func(dirs []string) []string {
+		var expanded []string
+		for _, dir := range dirs {
+			dir = strings.Replace(dir, elfOriginVarBraces, origin, -1)
+			dir = strings.Replace(dir, elfOriginVar, origin, -1)
+			expanded = append(expanded, dir)
+		}
+
+		return expanded
+	}
+
+	var dirs []string
+	if !o.useRunPath {
+		dirs = append(dirs, expand(o.RunPath)...)
+	}
+
+	dirs = append(dirs, envDirs...)
+	if o.useRunPath {
+		dirs = append(dirs, expand(o.RunPath)...)
+	}
+
+	dirs = append(dirs, configDirs...)
+	if multiarch, ok := elfMultiarchNames[o.machine]; ok {
+		dirs = append(dirs,
+			filepath.Join("lib", multiarch),
+			filepath.Join("usr/lib", multiarch))
+	}
+
+	return append(dirs, elfDefaultLibDirs...)
+}
+
+func findLibrary(objects map[string]*FSObject, lib string, searchDirs []string) bool {
+	if strings.Contains(lib, "/") {
+		_, object := ResolveFinalPath(objects, lib)
+		return object != nil
+	}
+
+	for _, dir := range searchDirs {
+		if _, object := ResolveFinalPath(objects, filepath.Join(dir, lib)); object != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isLdConfigFile(name string) bool {
+	return name == ldSoConfPath ||
+		(filepath.Dir(name) == ldSoConfDirPath && strings.HasSuffix(name, ".conf")) ||
+		(strings.HasPrefix(name, ldMuslPathPrefix) && strings.HasSuffix(name, ldMuslPathExt))
+}
+
+// libraryConfigDirs returns the library directories from the 'ld.so.conf' files
+// (including the files from 'ld.so.conf.d') and the musl 'ld-musl-*.path' files
+func libraryConfigDirs(configData map[string][]byte) []string {
+	var names []string
+	for name := range configData {
+		names = append(names, name)
+	}
+
+	//'ld.so.conf' is processed first and the included files are processed in the name order
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == ldSoConfPath || names[j] == ldSoConfPath {
+			return names[i] == ldSoConfPath
+		}
+
+		return names[i] < names[j]
+	})
+
+	var dirs []string
+	seen := map[string]struct{}{}
+	for _, name := range names {
+		scanner := bufio.NewScanner(bytes.NewReader(configData[name]))
+		for scanner.Scan() {
+			line := scanner.Text()
+			if idx := strings.Index(line, "#"); idx != -1 {
+				line = line[:idx]
+			}
+
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, ldSoConfInclude+" ") {
+				//the included files are always processed
+				continue
+			}
+
+			for _, dir := range strings.FieldsFunc(line, func(r rune) bool {
+				return r == ':' || r == ',' || r == ' ' || r == '\t'
+			}) {
+				if _, ok := seen[dir]; ok {
+					continue
+				}
+
+				seen[dir] = struct{}{}
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	return dirs
+}
+
+func splitPathList(value string) []string {
+	var dirs []string
+	for _, dir := range strings.Split(value, ":") {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+func elfArchName(machine elf.Machine) string {
+	if name, ok := elfArchNames[machine]; ok {
+		return name
+	}
+
+	return strings.ToLower(strings.TrimPrefix(machine.String(), "EM_"))
+}
@@ -0,0 +1,388 @@
+package dockerimage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultPathEnv       = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+	envPathName          = "PATH"
+	shebangPrefix        = "#!"
+	shebangMaxSize       = 256
+	execChainMaxSize     = 10
+	envCommandName       = "env"
+	envSplitStringOption = "-S"
+	shellCommandOption   = "-c"
+	shellExecCommand     = "exec"
+)
+
+// Executable types
+const (
+	ExecutableTypeELF    = "elf"
+	ExecutableTypeScript = "script"
+	ExecutableTypeOther  = "other"
+)
+
+// How the executable is launched
+const (
+	ExecViaCommand = "command" //ENTRYPOINT/CMD
+	ExecViaShebang = "shebang" //script interpreter
+	ExecViaEnv     = "env"     //'env' command
+	ExecViaShell   = "shell"   //'sh -c' command
+	ExecViaLoader  = "loader"  //ELF interpreter (dynamic loader)
+)
+
+var ErrNoEntrypoint = errors.New("no ENTRYPOINT or CMD")
+
+var shellNames = map[string]struct{}{
+	"sh":   {},
+	"bash": {},
+	"ash":  {},
+	"dash": {},
+	"zsh":  {},
+	"ksh":  {},
+}
+
+var shellBuiltins = map[string]struct{}{
+	":":      {},
+	".":      {},
+	"[":      {},
+	"cd":     {},
+	"echo":   {},
+	"eval":   {},
+	"export": {},
+	"false":  {},
+	"printf": {},
+	"read":   {},
+	"set":    {},
+	"source": {},
+	"test":   {},
+	"trap":   {},
+	"true":   {},
+	"ulimit": {},
+	"umask":  {},
+	"unset":  {},
+	"wait":   {},
+}
+
+// EntrypointInfo describes what the container launches (the ENTRYPOINT and CMD
+// resolved using the image PATH and the final image filesystem)
+type EntrypointInfo struct {
+	Entrypoint []string          `json:"entrypoint,omitempty"`
+	Cmd        []string          `json:"cmd,omitempty"`
+	Command    []string          `json:"command,omitempty"` //the effective command (ENTRYPOINT + CMD)
+	ShellForm  bool              `json:"shell_form,omitempty"`
+	WorkingDir string            `json:"working_dir,omitempty"`
+	SearchPath []string          `json:"search_path,omitempty"`
+	Chain      []*ExecutableInfo `json:"chain,omitempty"` //the executables in the launch order
+	Error      string            `json:"error,omitempty"`
+}
+
+// ExecutableInfo is an executable in the container launch chain
+type ExecutableInfo struct {
+	Name        string `json:"name"`           //the executable name or path as referenced
+	Via         string `json:"via"`            //how the executable is launched
+	Path        string `json:"path,omitempty"` //the resolved path (after following the symlinks)
+	LayerIndex  int    `json:"layer_index"`
+	Type        string `json:"type,omitempty"`
+	Interpreter string `json:"interpreter,omitempty"` //the shebang line for scripts or the ELF interpreter
+}
+
+// ResolveEntrypoint resolves the ENTRYPOINT and CMD executables following the symlinks,
+// the script interpreters (including the 'env' based shebangs), the ELF interpreters
+// and the 'sh -c' commands (the chain stops at the first error)
+func (p *Package) ResolveEntrypoint(archivePath string) (*EntrypointInfo, error) {
+	info := &EntrypointInfo{}
+	var env []string
+	if p.Config != nil && p.Config.Config != nil {
+		info.Entrypoint = p.Config.Config.Entrypoint
+		info.Cmd = p.Config.Config.Cmd
+		info.WorkingDir = p.Config.Config.WorkingDir
+		env = p.Config.Config.Env
+	}
+
+	//CMD provides the ENTRYPOINT parameters when both are set
+	info.Command = append(append([]string{}, info.Entrypoint...), info.Cmd...)
+	if len(info.Command) == 0 {
+		info.Error = ErrNoEntrypoint.Error()
+		return info, nil
+	}
+
+	info.ShellForm = isShellCommand(info.Command)
+
+	pathEnv := defaultPathEnv
+	for _, kv := range env {
+		if strings.HasPrefix(kv, envPathName+"=") {
+			pathEnv = kv[len(envPathName)+1:]
+		}
+	}
+
+	info.SearchPath = splitPathList(pathEnv)
+
+	workDir := info.WorkingDir
+	if workDir == "" {
+		workDir = "/"
+	}
+
+	objects := p.FinalObjects()
+	argv := info.Command
+	via := ExecViaCommand
+	for len(argv) > 0 {
+		if len(info.Chain) >= execChainMaxSize {
+			info.Error = "too many interpreter levels"
+			break
+		}
+
+		exe := &ExecutableInfo{
+			Name: argv[0],
+			Via:  via,
+		}
+
+		info.Chain = append(info.Chain, exe)
+
+		//the shebang interpreters are not looked up in PATH
+		name, object := findExecutable(objects, argv[0], workDir, info.SearchPath, via != ExecViaShebang)
+		if object == nil {
+			switch {
+			case via == ExecViaShebang:
+				info.Error = fmt.Sprintf("interpreter %s missing", argv[0])
+			case !strings.Contains(argv[0], "/"):
+				info.Error = fmt.Sprintf("executable %s not found in PATH", argv[0])
+			default:
+				info.Error = fmt.Sprintf("executable %s not found", argv[0])
+			}
+
+			break
+		}
+
+		exe.Path = "/" + name
+		exe.LayerIndex = object.LayerIndex
+		if !object.Mode.IsRegular() {
+			info.Error = fmt.Sprintf("%s is not a regular file", exe.Path)
+			break
+		}
+
+		if object.Mode&exeModeBits == 0 {
+			info.Error = fmt.Sprintf("%s is not executable", exe.Path)
+			break
+		}
+
+		data, elfObject, err := p.readExecutable(archivePath, objects, name)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case bytes.HasPrefix(data, []byte(elfMagic)):
+			exe.Type = ExecutableTypeELF
+			if elfObject == nil {
+				info.Error = fmt.Sprintf("%s is not a valid ELF object", exe.Path)
+				break
+			}
+
+			exe.Interpreter = elfObject.Interpreter
+			if exe.Interpreter != "" {
+				loader := &ExecutableInfo{
+					Name: exe.Interpreter,
+					Via:  ExecViaLoader,
+					Type: ExecutableTypeELF,
+				}
+
+				info.Chain = append(info.Chain, loader)
+				name, object := ResolveFinalPath(objects, exe.Interpreter)
+				if object == nil {
+					info.Error = fmt.Sprintf("ELF interpreter %s missing", exe.Interpreter)
+					break
+				}
+
+				loader.Path = "/" + name
+				loader.LayerIndex = object.LayerIndex
+			}
+
+			//following the commands launched by 'env' and 'sh -c'
+			//(the executable name is used because of the multi-call binaries like busybox)
+			switch {
+			case filepath.Base(argv[0]) == envCommandName:
+				via = ExecViaEnv
+				argv = envCommand(argv[1:])
+			case isShellCommand(argv):
+				via = ExecViaShell
+				argv = shellCommand(argv[2])
+			default:
+				argv = nil
+			}
+		case bytes.HasPrefix(data, []byte(shebangPrefix)):
+			exe.Type = ExecutableTypeScript
+			interpreter, interpreterArgs := parseShebang(data)
+			if interpreter == "" {
+				info.Error = fmt.Sprintf("%s has an empty shebang line", exe.Path)
+				break
+			}
+
+			exe.Interpreter = strings.Join(append([]string{interpreter}, interpreterArgs...), " ")
+
+			//the kernel passes the script path after the interpreter arguments
+			via = ExecViaShebang
+			argv = append(append([]string{interpreter}, interpreterArgs...),
+				append([]string{exe.Path}, argv[1:]...)...)
+		default:
+			exe.Type = ExecutableTypeOther
+			info.Error = fmt.Sprintf("%s has an unknown executable format", exe.Path)
+		}
+
+		if info.Error != "" {
+			break
+		}
+	}
+
+	return info, nil
+}
+
+// readExecutable reads the executable header (enough for the shebang line)
+// and parses the ELF objects without keeping the whole executable in memory
+func (p *Package) readExecutable(archivePath string,
+	objects map[string]*FSObject,
+	name string) ([]byte, *ElfObject, error) {
+	var header []byte
+	var elfObject *ElfObject
+	err := p.ReadFinalFiles(archivePath, objects, []string{name},
+		func(name string, reader io.Reader) error {
+			var err error
+			header, err = ioutil.ReadAll(io.LimitReader(reader, shebangMaxSize))
+			if err != nil {
+				return err
+			}
+
+			if !bytes.HasPrefix(header, []byte(elfMagic)) {
+				return nil
+			}
+
+			elfObject, err = readElfObject(io.MultiReader(bytes.NewReader(header), reader), objects[name].Size)
+			return err
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return header, elfObject, nil
+}
+
+// findExecutable resolves the executable using the final image filesystem
+func findExecutable(objects map[string]*FSObject,
+	name string,
+	workDir string,
+	searchPath []string,
+	usePath bool) (string, *FSObject) {
+	if strings.Contains(name, "/") || !usePath {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(workDir, name)
+		}
+
+		return ResolveFinalPath(objects, name)
+	}
+
+	for _, dir := range searchPath {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workDir, dir)
+		}
+
+		resolved, object := ResolveFinalPath(objects, filepath.Join(dir, name))
+		if object != nil && object.Mode.IsRegular() && object.Mode&exeModeBits != 0 {
+			return resolved, object
+		}
+	}
+
+	return "", nil
+}
+
+// parseShebang returns the script interpreter and its arguments
+// (the kernel passes everything after the interpreter as one argument)
+func parseShebang(data []byte) (string, []string) {
+	if len(data) > shebangMaxSize {
+		data = data[:shebangMaxSize]
+	}
+
+	line := string(data[len(shebangPrefix):])
+	if idx := strings.IndexByte(line, '\n'); idx != -1 {
+		line = line[:idx]
+	}
+
+	line = strings.TrimSpace(line)
+	fields := strings.SplitN(line, " ", 2)
+	if len(fields) == 0 || fields[0] == "" {
+		return "", nil
+	}
+
+	interpreter := strings.TrimSpace(fields[0])
+	if len(fields) == 1 || strings.TrimSpace(fields[1]) == "" {
+		return interpreter, nil
+	}
+
+	return interpreter, []string{strings.TrimSpace(fields[1])}
+}
+
+// envCommand returns the command launched by 'env'
+// (skipping the options and the environment variable assignments)
+func envCommand(args []string) []string {
+	for idx := 0; idx < len(args); idx++ {
+		arg := args[idx]
+		switch {
+		case arg == envSplitStringOption:
+			continue
+		case strings.HasPrefix(arg, envSplitStringOption+" "):
+			//'#!/usr/bin/env -S cmd args' shebangs
+			return envCommand(append(strings.Fields(arg[len(envSplitStringOption):]), args[idx+1:]...))
+		case strings.HasPrefix(arg, "-"):
+			continue
+		case strings.Contains(arg, "="):
+			continue
+		default:
+			return args[idx:]
+		}
+	}
+
+	return nil
+}
+
+// shellCommand returns the first command in the 'sh -c' command string
+// (nothing is returned for the shell builtins and the commands that need the shell expansion)
+func shellCommand(command string) []string {
+	fields := strings.Fields(command)
+	for idx, field := range fields {
+		if field == shellExecCommand {
+			continue
+		}
+
+		if strings.Contains(field, "=") && !strings.HasPrefix(field, "=") {
+			continue
+		}
+
+		if strings.ContainsAny(field, "$`;|&<>(){}\"'") {
+			return nil
+		}
+
+		if _, ok := shellBuiltins[field]; ok {
+			return nil
+		}
+
+		return fields[idx:]
+	}
+
+	return nil
+}
+
+// isShellCommand checks if the command is a 'sh -c' command
+func isShellCommand(argv []string) bool {
+	if len(argv) < 3 || argv[1] != shellCommandOption {
+		return false
+	}
+
+	_, ok := shellNames[filepath.Base(argv[0])]
+	return ok
+}
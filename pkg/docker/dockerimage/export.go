@@ -0,0 +1,319 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/util/fsutil"
+)
+
+const (
+	digestAlgorithm = "sha256"
+	maxLinkDepth    = 10
+)
+
+// SaveOCILayout converts the target image in a 'docker save' archive to an OCI image layout directory
+func SaveOCILayout(archivePath, imageID, outputDir, refName string) (*OCIManifest, error) {
+	manifest, err := LoadManifestObject(archivePath, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	configData, err := FileDataFromTar(archivePath, manifest.Config)
+	if err != nil {
+		log.Errorf("dockerimage.SaveOCILayout: error reading config object from archive(%v/%v) - %v", archivePath, manifest.Config, err)
+		return nil, err
+	}
+
+	var imageConfig ConfigObject
+	if err := json.Unmarshal(configData, &imageConfig); err != nil {
+		log.Errorf("dockerimage.SaveOCILayout: error decoding config object - %v", err)
+		return nil, err
+	}
+
+	blobsDir := filepath.Join(outputDir, OCIBlobsDirName, digestAlgorithm)
+	if err := os.MkdirAll(blobsDir, 0755); err != nil {
+		return nil, err
+	}
+
+	ociManifest := &OCIManifest{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeOCIManifest,
+	}
+
+	ociManifest.Config, err = writeBlob(blobsDir, MediaTypeOCIConfig, bytes.NewReader(configData))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, layerPath := range manifest.Layers {
+		layerReader, err := FileReaderFromTar(archivePath, layerPath)
+		if err != nil {
+			log.Errorf("dockerimage.SaveOCILayout: error reading layer from archive(%v/%v) - %v", archivePath, layerPath, err)
+			return nil, err
+		}
+
+		layerDesc, err := writeBlob(blobsDir, MediaTypeOCILayer, layerReader)
+		layerReader.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		ociManifest.Layers = append(ociManifest.Layers, layerDesc)
+	}
+
+	manifestData, err := json.Marshal(ociManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestDesc, err := writeBlob(blobsDir, MediaTypeOCIManifest, bytes.NewReader(manifestData))
+	if err != nil {
+		return nil, err
+	}
+
+	manifestDesc.Platform = &OCIPlatform{
+		Architecture: imageConfig.Architecture,
+		OS:           imageConfig.OS,
+		Variant:      imageConfig.Variant,
+	}
+
+	if refName != "" {
+		manifestDesc.Annotations = map[string]string{
+			AnnotationOCIRefName: refName,
+		}
+	}
+
+	index := &OCIIndex{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeOCIIndex,
+		Manifests:     []OCIDescriptor{manifestDesc},
+	}
+
+	if err := writeJSONFile(filepath.Join(outputDir, OCIIndexFileName), index); err != nil {
+		return nil, err
+	}
+
+	layout := &OCILayout{Version: OCIImageLayoutVersion}
+	if err := writeJSONFile(filepath.Join(outputDir, OCILayoutFileName), layout); err != nil {
+		return nil, err
+	}
+
+	return ociManifest, nil
+}
+
+// SaveRootFS flattens the target image layers in a 'docker save' archive to a single rootfs tarball
+func SaveRootFS(archivePath, imageID, outputPath string) error {
+	manifest, err := LoadManifestObject(archivePath, imageID)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(outputPath)
+	if !fsutil.DirExists(dir) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	ofile, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+
+	defer ofile.Close()
+
+	tw := tar.NewWriter(ofile)
+
+	//the layers are processed starting from the top layer,
+	//so the first object version we see is the one we keep
+	seen := map[string]struct{}{}
+	nonDirs := map[string]struct{}{}
+	deleted := map[string]struct{}{}
+	opaque := map[string]struct{}{}
+
+	for idx := len(manifest.Layers) - 1; idx >= 0; idx-- {
+		layerPath := manifest.Layers[idx]
+		layerReader, err := FileReaderFromTar(archivePath, layerPath)
+		if err != nil {
+			log.Errorf("dockerimage.SaveRootFS: error reading layer from archive(%v/%v) - %v", archivePath, layerPath, err)
+			return err
+		}
+
+		layerDeleted, layerOpaque, err := flattenLayer(tar.NewReader(layerReader), tw, seen, nonDirs, deleted, opaque)
+		layerReader.Close()
+		if err != nil {
+			log.Errorf("dockerimage.SaveRootFS: error processing layer(%v) - %v", layerPath, err)
+			return err
+		}
+
+		//whiteouts only hide the objects from the lower layers
+		for name := range layerDeleted {
+			deleted[name] = struct{}{}
+		}
+
+		for name := range layerOpaque {
+			opaque[name] = struct{}{}
+		}
+	}
+
+	return tw.Close()
+}
+
+func flattenLayer(tr *tar.Reader,
+	tw *tar.Writer,
+	seen map[string]struct{},
+	nonDirs map[string]struct{},
+	deleted map[string]struct{},
+	opaque map[string]struct{}) (map[string]struct{}, map[string]struct{}, error) {
+	layerDeleted := map[string]struct{}{}
+	layerOpaque := map[string]struct{}{}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if hdr == nil || hdr.Name == "" {
+			continue
+		}
+
+		name := filepath.Clean(hdr.Name)
+		if name == "." || name == "/" {
+			continue
+		}
+
+		name = strings.TrimPrefix(name, "/")
+		objectDir := filepath.Dir(name)
+		objectBase := filepath.Base(name)
+
+		switch {
+		case objectBase == WhiteoutOpaqueDir:
+			layerOpaque[objectDir] = struct{}{}
+			continue
+		case strings.HasPrefix(objectBase, WhiteoutMetaPrefix):
+			continue
+		case strings.HasPrefix(objectBase, WhiteoutPrefix):
+			layerDeleted[filepath.Join(objectDir, objectBase[len(WhiteoutPrefix):])] = struct{}{}
+			continue
+		}
+
+		if _, ok := seen[name]; ok {
+			continue
+		}
+
+		if isHiddenObject(name, nonDirs, deleted, opaque) {
+			continue
+		}
+
+		seen[name] = struct{}{}
+		if hdr.Typeflag != tar.TypeDir {
+			nonDirs[name] = struct{}{}
+		} else {
+			name = name + "/"
+		}
+
+		hdr.Name = name
+		if hdr.Typeflag == tar.TypeLink {
+			hdr.Linkname = strings.TrimPrefix(filepath.Clean(hdr.Linkname), "/")
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, nil, err
+		}
+
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := io.Copy(tw, tr); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return layerDeleted, layerOpaque, nil
+}
+
+func isHiddenObject(name string,
+	nonDirs map[string]struct{},
+	deleted map[string]struct{},
+	opaque map[string]struct{}) bool {
+	if _, ok := deleted[name]; ok {
+		return true
+	}
+
+	for dir := filepath.Dir(name); dir != "." && dir != "/"; dir = filepath.Dir(dir) {
+		if _, ok := deleted[dir]; ok {
+			return true
+		}
+
+		if _, ok := opaque[dir]; ok {
+			return true
+		}
+
+		if _, ok := nonDirs[dir]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeBlob(blobsDir, mediaType string, reader io.Reader) (OCIDescriptor, error) {
+	var desc OCIDescriptor
+	tmpFile, err := ioutil.TempFile(blobsDir, ".blob-")
+	if err != nil {
+		return desc, err
+	}
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmpFile, hasher), reader)
+	tmpFile.Close()
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return desc, err
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	blobPath := filepath.Join(blobsDir, hash)
+	if fsutil.Exists(blobPath) {
+		os.Remove(tmpFile.Name())
+	} else {
+		if err := os.Rename(tmpFile.Name(), blobPath); err != nil {
+			os.Remove(tmpFile.Name())
+			return desc, err
+		}
+
+		if err := os.Chmod(blobPath, 0644); err != nil {
+			return desc, err
+		}
+	}
+
+	desc.MediaType = mediaType
+	desc.Digest = fmt.Sprintf("%s:%s", digestAlgorithm, hash)
+	desc.Size = size
+	return desc, nil
+}
+
+func writeJSONFile(filePath string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filePath, raw, 0644)
+}
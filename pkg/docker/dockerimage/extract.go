@@ -0,0 +1,470 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const tarFileExt = ".tar"
+
+var (
+	ErrLayerNotFound  = errors.New("layer not found")
+	ErrPathNotFound   = errors.New("path not found in the final image filesystem")
+	ErrUnsafeOutput   = errors.New("unsafe output path")
+	errSkippedOutput  = errors.New("skipped object type")
+	errMissingLinkDst = errors.New("missing hardlink target")
+)
+
+// ExtractResult describes the extracted image filesystem objects
+type ExtractResult struct {
+	Objects   int    `json:"objects"`
+	Size      uint64 `json:"size"`
+	Skipped   int    `json:"skipped,omitempty"`   //devices, pipes and hardlinks without targets
+	Whiteouts int    `json:"whiteouts,omitempty"` //not extracted
+	dirModes  map[string]os.FileMode
+}
+
+// FindLayer returns the image layer selected by its index or ID (nil if there's no layer)
+func (p *Package) FindLayer(ref string) *Layer {
+	if idx, err := strconv.Atoi(ref); err == nil {
+		if idx >= 0 && idx < len(p.Layers) {
+			return p.Layers[idx]
+		}
+
+		return nil
+	}
+
+	for _, layer := range p.Layers {
+		if layer.ID == ref || strings.HasPrefix(layer.ID, ref) {
+			return layer
+		}
+	}
+
+	return nil
+}
+
+// ExtractFinalPath saves the object from the final image filesystem to the output directory
+// (the whole directory tree for directories). The whiteouts are applied, so the extracted
+// objects are the object versions visible in the final image filesystem.
+func (p *Package) ExtractFinalPath(archivePath, name, outputDir string) (*ExtractResult, error) {
+	objects := p.FinalObjects()
+	name = objectPath(name)
+	if name == "" {
+		return nil, ErrPathNotFound
+	}
+
+	if _, ok := objects[name]; !ok {
+		//the parent directories can be symlinks (e.g., '/lib -> /usr/lib')
+		if parentDir, parent := ResolveFinalPath(objects, filepath.Dir(name)); parent != nil {
+			name = filepath.Join(parentDir, filepath.Base(name))
+		} else {
+			//the resolved parent directory is not always included in the layer data
+			name, _ = ResolveFinalPath(objects, name)
+		}
+
+		if _, ok := objects[name]; !ok {
+			return nil, ErrPathNotFound
+		}
+	}
+
+	layerObjects := map[int]map[string]struct{}{}
+	for objectName, object := range objects {
+		if objectName != name && !strings.HasPrefix(objectName, name+"/") {
+			continue
+		}
+
+		if _, ok := layerObjects[object.LayerIndex]; !ok {
+			layerObjects[object.LayerIndex] = map[string]struct{}{}
+		}
+
+		layerObjects[object.LayerIndex][objectName] = struct{}{}
+	}
+
+	result := &ExtractResult{}
+	for idx, selected := range layerObjects {
+		layerPath := p.Layers[idx].Path
+		hardlinks := map[string][]string{}
+		err := readLayerObjects(archivePath, layerPath, func(hdr *tar.Header, objectName string, reader io.Reader) error {
+			if _, ok := selected[objectName]; !ok {
+				return nil
+			}
+
+			if hdr.Typeflag == tar.TypeLink {
+				//the hardlink data is copied from the target version in the same layer
+				target := objectPath(hdr.Linkname)
+				hardlinks[target] = append(hardlinks[target], objectName)
+				return nil
+			}
+
+			return extractObject(hdr, reader, outputDir, objectName, result)
+		})
+		if err != nil {
+			log.Errorf("dockerimage.Package.ExtractFinalPath: error extracting layer(%v) - %v", layerPath, err)
+			return nil, err
+		}
+
+		if len(hardlinks) == 0 {
+			continue
+		}
+
+		err = readLayerObjects(archivePath, layerPath, func(hdr *tar.Header, objectName string, reader io.Reader) error {
+			links, ok := hardlinks[objectName]
+			if !ok || hdr.Typeflag != tar.TypeReg {
+				return nil
+			}
+
+			delete(hardlinks, objectName)
+			for idx, link := range links {
+				if idx > 0 {
+					//the same layer object is read only once
+					if err := linkOutputObject(outputDir, links[0], link, result); err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				if err := extractObject(hdr, reader, outputDir, link, result); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			log.Errorf("dockerimage.Package.ExtractFinalPath: error extracting hardlinks from layer(%v) - %v", layerPath, err)
+			return nil, err
+		}
+
+		for _, links := range hardlinks {
+			result.Skipped += len(links)
+		}
+	}
+
+	if err := restoreDirModes(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ExportLayer saves the image layer data as a tar file (if the output path has the '.tar' extension)
+// or it extracts the layer objects to the output directory (without the whiteouts)
+func ExportLayer(archivePath, imageID, layerID, output string) (*Layer, *ExtractResult, error) {
+	layer, err := LoadLayer(archivePath, imageID, layerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &ExtractResult{}
+	if strings.HasSuffix(output, tarFileExt) {
+		if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+			return nil, nil, err
+		}
+
+		layerReader, err := FileReaderFromTar(archivePath, layer.Path)
+		if err != nil {
+			log.Errorf("dockerimage.ExportLayer: error reading layer from archive(%v/%v) - %v", archivePath, layer.Path, err)
+			return nil, nil, err
+		}
+
+		defer layerReader.Close()
+
+		ofile, err := os.Create(output)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		size, err := io.Copy(ofile, layerReader)
+		ofile.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		result.Objects = len(layer.Objects)
+		result.Size = uint64(size)
+		return layer, result, nil
+	}
+
+	err = readLayerObjects(archivePath, layer.Path, func(hdr *tar.Header, objectName string, reader io.Reader) error {
+		if strings.HasPrefix(filepath.Base(objectName), WhiteoutPrefix) {
+			result.Whiteouts++
+			return nil
+		}
+
+		if hdr.Typeflag == tar.TypeLink {
+			//the hardlink targets are always before the hardlinks in the layer data
+			err := linkOutputObject(output, objectPath(hdr.Linkname), objectName, result)
+			if err == errMissingLinkDst {
+				result.Skipped++
+				return nil
+			}
+
+			return err
+		}
+
+		return extractObject(hdr, reader, output, objectName, result)
+	})
+	if err != nil {
+		log.Errorf("dockerimage.ExportLayer: error extracting layer(%v) - %v", layer.Path, err)
+		return nil, nil, err
+	}
+
+	if err := restoreDirModes(result); err != nil {
+		return nil, nil, err
+	}
+
+	return layer, result, nil
+}
+
+// ExtractTarFile extracts the objects from the tar file to the output directory
+func ExtractTarFile(tarPath, outputDir string) (*ExtractResult, error) {
+	tfile, err := os.Open(tarPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tfile.Close()
+
+	result, err := ExtractTar(tfile, outputDir)
+	if err != nil {
+		log.Errorf("dockerimage.ExtractTarFile: error extracting tar file(%v) - %v", tarPath, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ExtractTar extracts the objects from the tar data to the output directory
+// (the hardlinks are extracted if their targets are in the tar data)
+func ExtractTar(reader io.Reader, outputDir string) (*ExtractResult, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, err
+	}
+
+	result := &ExtractResult{}
+	err := readTarObjects(reader, func(hdr *tar.Header, objectName string, reader io.Reader) error {
+		if hdr.Typeflag == tar.TypeLink {
+			err := linkOutputObject(outputDir, objectPath(hdr.Linkname), objectName, result)
+			if err == errMissingLinkDst {
+				result.Skipped++
+				return nil
+			}
+
+			return err
+		}
+
+		return extractObject(hdr, reader, outputDir, objectName, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := restoreDirModes(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+type layerObjectHandler func(hdr *tar.Header, name string, reader io.Reader) error
+
+func readLayerObjects(archivePath, layerPath string, handler layerObjectHandler) error {
+	layerReader, err := FileReaderFromTar(archivePath, layerPath)
+	if err != nil {
+		return err
+	}
+
+	defer layerReader.Close()
+	return readTarObjects(layerReader, handler)
+}
+
+func readTarObjects(reader io.Reader, handler layerObjectHandler) error {
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if hdr == nil || hdr.Name == "" {
+			continue
+		}
+
+		name := objectPath(hdr.Name)
+		if name == "" {
+			continue
+		}
+
+		if err := handler(hdr, name, tr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func extractObject(hdr *tar.Header, reader io.Reader, outputDir, name string, result *ExtractResult) error {
+	outputPath, err := outputObjectPath(outputDir, name)
+	if err != nil {
+		return err
+	}
+
+	if err := writeOutputObject(hdr, reader, outputPath); err != nil {
+		if err == errSkippedOutput {
+			result.Skipped++
+			return nil
+		}
+
+		return err
+	}
+
+	result.Objects++
+	switch hdr.Typeflag {
+	case tar.TypeReg:
+		result.Size += uint64(hdr.Size)
+	case tar.TypeDir:
+		if result.dirModes == nil {
+			result.dirModes = map[string]os.FileMode{}
+		}
+
+		result.dirModes[outputPath] = outputObjectMode(hdr)
+	}
+
+	return nil
+}
+
+// restoreDirModes sets the extracted directory modes
+// (the deepest directories first, so the parent directory modes don't block the changes)
+func restoreDirModes(result *ExtractResult) error {
+	var dirs []string
+	for dir := range result.dirModes {
+		dirs = append(dirs, dir)
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		if info, err := os.Lstat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+
+		if err := os.Chmod(dir, result.dirModes[dir]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func linkOutputObject(outputDir, target, name string, result *ExtractResult) error {
+	targetPath, err := outputObjectPath(outputDir, target)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(targetPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return errMissingLinkDst
+	}
+
+	outputPath, err := outputObjectPath(outputDir, name)
+	if err != nil {
+		return err
+	}
+
+	os.Remove(outputPath)
+	if err := os.Link(targetPath, outputPath); err != nil {
+		return err
+	}
+
+	result.Objects++
+	result.Size += uint64(info.Size())
+	return nil
+}
+
+// outputObjectPath returns the output path for the object
+// (the objects outside of the output directory and the symlinked parent directories are not allowed)
+func outputObjectPath(outputDir, name string) (string, error) {
+	outputDir = filepath.Clean(outputDir)
+	name = filepath.Clean(name)
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return "", ErrUnsafeOutput
+	}
+
+	outputPath := filepath.Join(outputDir, name)
+	for dir := filepath.Dir(outputPath); dir != outputDir && strings.HasPrefix(dir, outputDir); dir = filepath.Dir(dir) {
+		info, err := os.Lstat(dir)
+		if err != nil {
+			continue
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("%v - %s", ErrUnsafeOutput, outputPath)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return "", err
+	}
+
+	return outputPath, nil
+}
+
+// outputObjectMode returns the object permissions with the setuid, setgid and sticky bits
+func outputObjectMode(hdr *tar.Header) os.FileMode {
+	return hdr.FileInfo().Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+}
+
+func writeOutputObject(hdr *tar.Header, reader io.Reader, outputPath string) error {
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if info, err := os.Lstat(outputPath); err == nil && !info.IsDir() {
+			os.Remove(outputPath)
+		}
+
+		if err := os.MkdirAll(outputPath, 0755); err != nil {
+			return err
+		}
+
+		//the directories need to stay writable to extract their objects
+		//(the directory modes are restored after all objects are extracted)
+		return os.Chmod(outputPath, 0700)
+	case tar.TypeReg:
+		os.Remove(outputPath)
+		ofile, err := os.OpenFile(outputPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(ofile, reader)
+		ofile.Close()
+		if err != nil {
+			return err
+		}
+
+		//the mode is set after the file data is written (the writes clear the setuid and setgid bits)
+		if err := os.Chmod(outputPath, outputObjectMode(hdr)); err != nil {
+			return err
+		}
+
+		return os.Chtimes(outputPath, hdr.ModTime, hdr.ModTime)
+	case tar.TypeSymlink:
+		os.Remove(outputPath)
+		return os.Symlink(hdr.Linkname, outputPath)
+	default:
+		return errSkippedOutput
+	}
+}
@@ -0,0 +1,259 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const maxSymlinkDepth = 40
+
+// normalized name of the opaque dir whiteout (see NormalizeFileObjectLayerPath)
+var whiteoutOpaqueDirMarker = WhiteoutOpaqueDir[len(WhiteoutPrefix):]
+
+// FSObject is an object in the final image filesystem (after all layers are applied)
+type FSObject struct {
+	*ObjectMetadata
+	LayerIndex int //index of the layer with the object version in the final filesystem
+}
+
+// FinalObjects returns the objects in the final image filesystem
+// (the object names are relative to the filesystem root, the whiteouts are applied)
+func (p *Package) FinalObjects() map[string]*FSObject {
+	objects := map[string]*FSObject{}
+
+	//the layers are processed starting from the top layer,
+	//so the first object version we see is the one we keep
+	nonDirs := map[string]struct{}{}
+	deleted := map[string]struct{}{}
+	opaque := map[string]struct{}{}
+
+	for idx := len(p.Layers) - 1; idx >= 0; idx-- {
+		layer := p.Layers[idx]
+		layerDeleted := map[string]struct{}{}
+		layerOpaque := map[string]struct{}{}
+
+		for _, object := range layer.Objects {
+			name := objectPath(object.Name)
+			if name == "" {
+				continue
+			}
+
+			if object.Change == ChangeDelete {
+				objectBase := filepath.Base(name)
+				switch {
+				case objectBase == whiteoutOpaqueDirMarker:
+					layerOpaque[filepath.Dir(name)] = struct{}{}
+				case strings.HasPrefix(objectBase, WhiteoutPrefix):
+					//other whiteout meta objects
+				default:
+					layerDeleted[name] = struct{}{}
+				}
+
+				continue
+			}
+
+			if _, ok := objects[name]; ok {
+				continue
+			}
+
+			if isHiddenObject(name, nonDirs, deleted, opaque) {
+				continue
+			}
+
+			objects[name] = &FSObject{
+				ObjectMetadata: object,
+				LayerIndex:     idx,
+			}
+
+			if !object.Mode.IsDir() {
+				nonDirs[name] = struct{}{}
+			}
+		}
+
+		//whiteouts only hide the objects from the lower layers
+		for name := range layerDeleted {
+			deleted[name] = struct{}{}
+		}
+
+		for name := range layerOpaque {
+			opaque[name] = struct{}{}
+		}
+	}
+
+	return objects
+}
+
+// ResolveFinalPath follows the symlinks (including the symlinks in the parent directories)
+// to find the object in the final image filesystem. It returns the resolved object path
+// (relative to the filesystem root) and nil if the object doesn't exist.
+func ResolveFinalPath(objects map[string]*FSObject, name string) (string, *FSObject) {
+	parts := strings.Split(objectPath(name), "/")
+	var resolved []string
+	linkCount := 0
+	for len(parts) > 0 {
+		part := parts[0]
+		parts = parts[1:]
+
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) > 0 {
+				resolved = resolved[:len(resolved)-1]
+			}
+
+			continue
+		}
+
+		current := strings.Join(append(resolved, part), "/")
+		object, ok := objects[current]
+		if !ok {
+			if len(parts) == 0 {
+				return "", nil
+			}
+
+			//the parent directories are not always included in the layer data
+			resolved = append(resolved, part)
+			continue
+		}
+
+		if object.Mode&os.ModeSymlink == 0 {
+			resolved = append(resolved, part)
+			continue
+		}
+
+		linkCount++
+		if linkCount > maxSymlinkDepth {
+			return "", nil
+		}
+
+		if strings.HasPrefix(object.LinkTarget, "/") {
+			resolved = nil
+		}
+
+		parts = append(strings.Split(object.LinkTarget, "/"), parts...)
+	}
+
+	name = strings.Join(resolved, "/")
+	if name == "" {
+		return "", nil
+	}
+
+	return name, objects[name]
+}
+
+// FinalFilesData reads the data for the selected regular files in the final image filesystem
+// (the missing and non-regular files are ignored; each layer is read only once)
+func (p *Package) FinalFilesData(archivePath string,
+	objects map[string]*FSObject,
+	names []string) (map[string][]byte, error) {
+	filesData := map[string][]byte{}
+	err := p.ReadFinalFiles(archivePath, objects, names,
+		func(name string, reader io.Reader) error {
+			data, err := ioutil.ReadAll(reader)
+			if err != nil {
+				return err
+			}
+
+			filesData[name] = data
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return filesData, nil
+}
+
+// FinalFileHandler processes the file data from the final image filesystem
+type FinalFileHandler func(name string, reader io.Reader) error
+
+// ReadFinalFiles calls the handler for each selected regular file in the final image filesystem
+// (the missing and non-regular files are ignored; each layer is read only once)
+func (p *Package) ReadFinalFiles(archivePath string,
+	objects map[string]*FSObject,
+	names []string,
+	handler FinalFileHandler) error {
+	layerFiles := map[int]map[string]struct{}{}
+	for _, name := range names {
+		name = objectPath(name)
+		object, ok := objects[name]
+		if !ok || !object.Mode.IsRegular() {
+			continue
+		}
+
+		if _, ok := layerFiles[object.LayerIndex]; !ok {
+			layerFiles[object.LayerIndex] = map[string]struct{}{}
+		}
+
+		layerFiles[object.LayerIndex][name] = struct{}{}
+	}
+
+	for idx, files := range layerFiles {
+		if idx < 0 || idx >= len(p.Layers) {
+			continue
+		}
+
+		layerPath := p.Layers[idx].Path
+		layerReader, err := FileReaderFromTar(archivePath, layerPath)
+		if err != nil {
+			log.Errorf("dockerimage.Package.ReadFinalFiles: error reading layer from archive(%v/%v) - %v", archivePath, layerPath, err)
+			return err
+		}
+
+		err = readLayerFiles(tar.NewReader(layerReader), files, handler)
+		layerReader.Close()
+		if err != nil {
+			log.Errorf("dockerimage.Package.ReadFinalFiles: error processing layer(%v) - %v", layerPath, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readLayerFiles(tr *tar.Reader, files map[string]struct{}, handler FinalFileHandler) error {
+	for len(files) > 0 {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if hdr == nil || hdr.Name == "" || hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := objectPath(hdr.Name)
+		if _, ok := files[name]; !ok {
+			continue
+		}
+
+		delete(files, name)
+		if err := handler(name, tr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// objectPath returns the object path relative to the filesystem root
+// (empty for the root itself)
+func objectPath(name string) string {
+	name = strings.TrimPrefix(filepath.Clean(name), "/")
+	if name == "." {
+		return ""
+	}
+
+	return name
+}
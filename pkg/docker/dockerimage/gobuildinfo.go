@@ -0,0 +1,206 @@
+package dockerimage
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	ErrNoGoBuildInfo = errors.New("no Go build info")
+)
+
+const (
+	goBuildInfoSectionName = ".go.buildinfo"
+	goBuildInfoMagic       = "\xff Go buildinf:"
+	goBuildInfoAlign       = 16
+	goBuildInfoHeaderSize  = 32
+	goBuildInfoMaxSize     = 64 * 1024
+	goBuildInfoFlagsBE     = 0x1
+	goBuildInfoFlagsInline = 0x2
+	goModInfoSentinelSize  = 16
+	goMaxStringSize        = 1024 * 1024
+)
+
+// GoBuildInfo contains the module info embedded in Go binaries
+type GoBuildInfo struct {
+	GoVersion string
+	Main      GoModule
+	Deps      []GoModule
+}
+
+// GoModule is a Go module used to build a Go binary
+type GoModule struct {
+	Path    string
+	Version string
+	Sum     string
+}
+
+// ReadGoBuildInfo reads the build info from a Go ELF binary
+// (same as 'debug/buildinfo', which is not available in older Go versions)
+func ReadGoBuildInfo(data []byte) (*GoBuildInfo, error) {
+	return readGoBuildInfo(bytes.NewReader(data))
+}
+
+// readGoBuildInfo reads the build info from a Go ELF binary
+// (only the build info section and the data it references are read)
+func readGoBuildInfo(reader io.ReaderAt) (*GoBuildInfo, error) {
+	ef, err := elf.NewFile(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ef.Close()
+
+	section := ef.Section(goBuildInfoSectionName)
+	if section == nil {
+		return nil, ErrNoGoBuildInfo
+	}
+
+	sectionData, err := section.Data()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sectionData) > goBuildInfoMaxSize {
+		sectionData = sectionData[:goBuildInfoMaxSize]
+	}
+
+	var header []byte
+	for offset := 0; offset+goBuildInfoHeaderSize <= len(sectionData); offset += goBuildInfoAlign {
+		if string(sectionData[offset:offset+len(goBuildInfoMagic)]) == goBuildInfoMagic {
+			header = sectionData[offset:]
+			break
+		}
+	}
+
+	if header == nil {
+		return nil, ErrNoGoBuildInfo
+	}
+
+	ptrSize := int(header[14])
+	flags := header[15]
+
+	var version, modInfo string
+	if flags&goBuildInfoFlagsInline != 0 {
+		var rest []byte
+		version, rest = goInlineString(header[goBuildInfoHeaderSize:])
+		modInfo, _ = goInlineString(rest)
+	} else {
+		if ptrSize != 4 && ptrSize != 8 {
+			return nil, ErrNoGoBuildInfo
+		}
+
+		var order binary.ByteOrder = binary.LittleEndian
+		if flags&goBuildInfoFlagsBE != 0 {
+			order = binary.BigEndian
+		}
+
+		version = goPointerString(ef, order, ptrSize, goReadPtr(order, ptrSize, header[16:]))
+		modInfo = goPointerString(ef, order, ptrSize, goReadPtr(order, ptrSize, header[16+ptrSize:]))
+	}
+
+	if version == "" {
+		return nil, ErrNoGoBuildInfo
+	}
+
+	info := &GoBuildInfo{GoVersion: version}
+
+	//the module info is wrapped with sentinel values
+	if len(modInfo) >= 2*goModInfoSentinelSize+1 && modInfo[len(modInfo)-goModInfoSentinelSize-1] == '\n' {
+		parseGoModInfo(modInfo[goModInfoSentinelSize:len(modInfo)-goModInfoSentinelSize], info)
+	}
+
+	return info, nil
+}
+
+func parseGoModInfo(modInfo string, info *GoBuildInfo) {
+	var last *GoModule
+	for _, line := range strings.Split(modInfo, "\n") {
+		fields := strings.Split(line, "\t")
+		switch {
+		case len(fields) >= 3 && fields[0] == "mod":
+			info.Main = newGoModule(fields[1:])
+			last = &info.Main
+		case len(fields) >= 3 && fields[0] == "dep":
+			info.Deps = append(info.Deps, newGoModule(fields[1:]))
+			last = &info.Deps[len(info.Deps)-1]
+		case len(fields) >= 3 && fields[0] == "=>" && last != nil:
+			//replaced module
+			*last = newGoModule(fields[1:])
+			last = nil
+		}
+	}
+}
+
+func newGoModule(fields []string) GoModule {
+	module := GoModule{
+		Path:    fields[0],
+		Version: fields[1],
+	}
+
+	if len(fields) > 2 {
+		module.Sum = fields[2]
+	}
+
+	return module
+}
+
+func goInlineString(data []byte) (string, []byte) {
+	size, n := binary.Uvarint(data)
+	if n <= 0 || size > uint64(len(data)-n) {
+		return "", nil
+	}
+
+	return string(data[n : n+int(size)]), data[n+int(size):]
+}
+
+func goReadPtr(order binary.ByteOrder, ptrSize int, data []byte) uint64 {
+	if len(data) < ptrSize {
+		return 0
+	}
+
+	if ptrSize == 4 {
+		return uint64(order.Uint32(data))
+	}
+
+	return order.Uint64(data)
+}
+
+// goPointerString reads the Go string (pointer and length) at the virtual address
+func goPointerString(ef *elf.File, order binary.ByteOrder, ptrSize int, addr uint64) string {
+	header := goReadMemory(ef, addr, uint64(2*ptrSize))
+	if header == nil {
+		return ""
+	}
+
+	dataAddr := goReadPtr(order, ptrSize, header)
+	dataLen := goReadPtr(order, ptrSize, header[ptrSize:])
+	if dataLen == 0 || dataLen > goMaxStringSize {
+		return ""
+	}
+
+	return string(goReadMemory(ef, dataAddr, dataLen))
+}
+
+func goReadMemory(ef *elf.File, addr, size uint64) []byte {
+	for _, prog := range ef.Progs {
+		if prog.Type != elf.PT_LOAD ||
+			addr < prog.Vaddr ||
+			addr+size > prog.Vaddr+prog.Filesz {
+			continue
+		}
+
+		data := make([]byte, size)
+		if _, err := prog.ReadAt(data, int64(addr-prog.Vaddr)); err != nil {
+			return nil
+		}
+
+		return data
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package dockerimage
+
+//consts and data structures from https://github.com/opencontainers/image-spec/tree/master/specs-go
+
+const (
+	OCILayoutFileName     = "oci-layout"
+	OCIIndexFileName      = "index.json"
+	OCIBlobsDirName       = "blobs"
+	OCIImageLayoutVersion = "1.0.0"
+)
+
+const (
+	MediaTypeOCIIndex    = "application/vnd.oci.image.index.v1+json"
+	MediaTypeOCIManifest = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIConfig   = "application/vnd.oci.image.config.v1+json"
+	MediaTypeOCILayer    = "application/vnd.oci.image.layer.v1.tar"
+)
+
+// AnnotationOCIRefName is the annotation key for the name of the reference for the target
+const AnnotationOCIRefName = "org.opencontainers.image.ref.name"
+
+// OCILayout is the structure in the "oci-layout" file
+type OCILayout struct {
+	Version string `json:"imageLayoutVersion"`
+}
+
+// OCIPlatform describes the platform which the image in the manifest runs on
+type OCIPlatform struct {
+	Architecture string   `json:"architecture"`
+	OS           string   `json:"os"`
+	OSVersion    string   `json:"os.version,omitempty"`
+	OSFeatures   []string `json:"os.features,omitempty"`
+	Variant      string   `json:"variant,omitempty"`
+}
+
+// OCIDescriptor describes the disposition of targeted content
+type OCIDescriptor struct {
+	MediaType   string            `json:"mediaType,omitempty"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *OCIPlatform      `json:"platform,omitempty"`
+}
+
+// OCIManifest provides the OCI image manifest
+type OCIManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Config        OCIDescriptor     `json:"config"`
+	Layers        []OCIDescriptor   `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// OCIIndex references manifests for multiple platforms
+type OCIIndex struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Manifests     []OCIDescriptor   `json:"manifests"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
@@ -0,0 +1,697 @@
+package dockerimage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Software package types (same as the package URL types)
+const (
+	PackageTypeDeb    = "deb"
+	PackageTypeApk    = "apk"
+	PackageTypeRpm    = "rpm"
+	PackageTypePython = "pypi"
+	PackageTypeNode   = "npm"
+	PackageTypeGo     = "golang"
+)
+
+const (
+	osReleasePath       = "etc/os-release"
+	osReleaseAltPath    = "usr/lib/os-release"
+	dpkgStatusPath      = "var/lib/dpkg/status"
+	dpkgStatusDirPath   = "var/lib/dpkg/status.d"
+	dpkgInfoDirPath     = "var/lib/dpkg/info"
+	dpkgFileListSuffix  = ".list"
+	dpkgStatusInstalled = "installed"
+	apkInstalledDBPath  = "lib/apk/db/installed"
+	rpmDBPath           = "var/lib/rpm/Packages"
+	rpmSqliteDBName     = "rpmdb.sqlite"
+	rpmNdbDBName        = "Packages.db"
+	rpmDBDirPath        = "var/lib/rpm"
+	rpmSysimageDirPath  = "usr/lib/sysimage/rpm"
+	pythonDistInfoExt   = ".dist-info"
+	pythonEggInfoExt    = ".egg-info"
+	pythonMetadataFile  = "METADATA"
+	pythonPkgInfoFile   = "PKG-INFO"
+	nodeModulesDirName  = "node_modules"
+	nodePackageFile     = "package.json"
+	elfMagic            = "\x7fELF"
+)
+
+// OSRelease contains the OS identification data (from 'os-release')
+type OSRelease struct {
+	ID         string `json:"id"`
+	VersionID  string `json:"version_id,omitempty"`
+	PrettyName string `json:"pretty_name,omitempty"`
+}
+
+// SoftwarePackage describes a software package installed in the image
+type SoftwarePackage struct {
+	Type         string   `json:"type"`
+	Name         string   `json:"name"`
+	Version      string   `json:"version,omitempty"`
+	Architecture string   `json:"architecture,omitempty"`
+	License      string   `json:"license,omitempty"`
+	Distro       string   `json:"distro,omitempty"`   //OS ID for the OS packages
+	Location     string   `json:"location,omitempty"` //package metadata location in the image
+	Files        []string `json:"-"`                  //package files (relative to the filesystem root)
+}
+
+// ID returns the package identifier used to match packages across images
+func (p *SoftwarePackage) ID() string {
+	return p.Type + ":" + p.Name
+}
+
+// PURL returns the package URL for the package
+func (p *SoftwarePackage) PURL() string {
+	var namespace, name string
+	qualifiers := url.Values{}
+	version := p.Version
+	switch p.Type {
+	case PackageTypeDeb, PackageTypeApk, PackageTypeRpm:
+		namespace = p.Distro
+		name = p.Name
+		if p.Architecture != "" {
+			qualifiers.Set("arch", p.Architecture)
+		}
+
+		if p.Type == PackageTypeRpm {
+			if parts := strings.SplitN(version, ":", 2); len(parts) == 2 {
+				qualifiers.Set("epoch", parts[0])
+				version = parts[1]
+			}
+		}
+	case PackageTypePython:
+		name = strings.ToLower(strings.Replace(p.Name, "_", "-", -1))
+	case PackageTypeNode:
+		name = p.Name
+		if strings.HasPrefix(name, "@") {
+			if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+				namespace = parts[0]
+				name = parts[1]
+			}
+		}
+	case PackageTypeGo:
+		namespace = filepath.Dir(p.Name)
+		name = filepath.Base(p.Name)
+		if namespace == "." {
+			namespace = ""
+		}
+	default:
+		name = p.Name
+	}
+
+	var purl strings.Builder
+	purl.WriteString("pkg:")
+	purl.WriteString(p.Type)
+	purl.WriteString("/")
+	if namespace != "" {
+		for _, segment := range strings.Split(namespace, "/") {
+			purl.WriteString(url.PathEscape(segment))
+			purl.WriteString("/")
+		}
+	}
+
+	purl.WriteString(url.PathEscape(name))
+	if version != "" {
+		purl.WriteString("@")
+		purl.WriteString(url.PathEscape(version))
+	}
+
+	if len(qualifiers) > 0 {
+		purl.WriteString("?")
+		purl.WriteString(qualifiers.Encode())
+	}
+
+	return purl.String()
+}
+
+// SoftwareInventory contains the software packages installed in the image
+type SoftwareInventory struct {
+	OS       *OSRelease         `json:"os,omitempty"`
+	Packages []*SoftwarePackage `json:"packages,omitempty"`
+	Warnings []string           `json:"warnings,omitempty"` //the package metadata that couldn't be processed
+}
+
+// LoadSoftwareInventory discovers the OS packages (dpkg, apk, rpm) and the application packages
+// (Python, Node.js, Go binaries) in the final image filesystem and saves them in the image package
+// (the image archive is the 'docker save' archive the package was loaded from)
+func (p *Package) LoadSoftwareInventory(archivePath string, objects map[string]*FSObject) (*SoftwareInventory, error) {
+	if objects == nil {
+		objects = p.FinalObjects()
+	}
+
+	osRelease, packages, warnings, err := p.loadSystemPackages(archivePath, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	appPackages, appWarnings, err := p.loadAppPackages(archivePath, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := &SoftwareInventory{
+		OS:       osRelease,
+		Packages: append(packages, appPackages...),
+		Warnings: append(warnings, appWarnings...),
+	}
+
+	sortPackages(inventory.Packages)
+	p.Software = inventory
+	return inventory, nil
+}
+
+// LoadSystemPackages returns the OS packages (dpkg, apk and rpm) installed in the final image filesystem
+// (the image archive is the 'docker save' archive the package was loaded from)
+func (p *Package) LoadSystemPackages(archivePath string, objects map[string]*FSObject) ([]*SoftwarePackage, error) {
+	if objects == nil {
+		objects = p.FinalObjects()
+	}
+
+	_, packages, warnings, err := p.loadSystemPackages(archivePath, objects)
+	for _, warning := range warnings {
+		log.Warnf("dockerimage.Package.LoadSystemPackages: %s", warning)
+	}
+
+	return packages, err
+}
+
+func (p *Package) loadSystemPackages(archivePath string,
+	objects map[string]*FSObject) (*OSRelease, []*SoftwarePackage, []string, error) {
+	names := []string{
+		osReleasePath,
+		osReleaseAltPath,
+		dpkgStatusPath,
+		apkInstalledDBPath,
+		rpmDBPath,
+	}
+
+	for name, object := range objects {
+		if !object.Mode.IsRegular() {
+			continue
+		}
+
+		switch filepath.Dir(name) {
+		case dpkgStatusDirPath:
+			names = append(names, name)
+		case dpkgInfoDirPath:
+			if strings.HasSuffix(name, dpkgFileListSuffix) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	filesData, err := p.FinalFilesData(archivePath, objects, names)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var warnings []string
+	var osRelease *OSRelease
+	if data, ok := filesData[osReleasePath]; ok {
+		osRelease = ParseOSRelease(data)
+	} else if data, ok := filesData[osReleaseAltPath]; ok {
+		osRelease = ParseOSRelease(data)
+	}
+
+	var packages []*SoftwarePackage
+	var debPackages []*SoftwarePackage
+	var statusFragments []string
+	for name := range filesData {
+		if filepath.Dir(name) == dpkgStatusDirPath {
+			statusFragments = append(statusFragments, name)
+		}
+	}
+
+	sort.Strings(statusFragments)
+	for _, name := range append([]string{dpkgStatusPath}, statusFragments...) {
+		data, ok := filesData[name]
+		if !ok {
+			continue
+		}
+
+		statusPackages, err := ParseDpkgStatus(data)
+		if err != nil {
+			log.Debugf("dockerimage.Package.LoadSystemPackages: error parsing dpkg status file - %v", err)
+			warnings = append(warnings, fmt.Sprintf("dpkg status file /%s not fully processed (%v)", name, err))
+		}
+
+		debPackages = append(debPackages, withLocation(statusPackages, name)...)
+	}
+
+	for _, pkg := range debPackages {
+		for _, listName := range []string{
+			pkg.Name + dpkgFileListSuffix,
+			pkg.Name + ":" + pkg.Architecture + dpkgFileListSuffix,
+		} {
+			if data, ok := filesData[filepath.Join(dpkgInfoDirPath, listName)]; ok {
+				pkg.Files = append(pkg.Files, parseFileList(data)...)
+			}
+		}
+	}
+
+	packages = append(packages, debPackages...)
+	if data, ok := filesData[apkInstalledDBPath]; ok {
+		apkPackages, err := ParseApkInstalledDB(data)
+		if err != nil {
+			log.Debugf("dockerimage.Package.LoadSystemPackages: error parsing apk database - %v", err)
+			warnings = append(warnings, fmt.Sprintf("apk database /%s not fully processed (%v)", apkInstalledDBPath, err))
+		}
+
+		packages = append(packages, withLocation(apkPackages, apkInstalledDBPath)...)
+	}
+
+	if data, ok := filesData[rpmDBPath]; ok {
+		rpmPackages, err := ParseRpmDB(data)
+		if err != nil {
+			log.Debugf("dockerimage.Package.LoadSystemPackages: error parsing rpm database - %v", err)
+			warnings = append(warnings, fmt.Sprintf("rpm database /%s not processed (%v)", rpmDBPath, err))
+		} else {
+			packages = append(packages, withLocation(rpmPackages, rpmDBPath)...)
+		}
+	}
+
+	warnings = append(warnings, unsupportedRpmDBWarnings(objects)...)
+
+	if osRelease != nil {
+		for _, pkg := range packages {
+			pkg.Distro = osRelease.ID
+		}
+	}
+
+	sortPackages(packages)
+	return osRelease, packages, warnings, nil
+}
+
+// unsupportedRpmDBWarnings returns the warnings for the rpm databases that can't be processed
+// (the rpm packages are not included in the inventory for these databases)
+func unsupportedRpmDBWarnings(objects map[string]*FSObject) []string {
+	var warnings []string
+	for _, dirPath := range []string{rpmDBDirPath, rpmSysimageDirPath} {
+		for _, dbInfo := range []struct {
+			name   string
+			format string
+		}{
+			{rpmSqliteDBName, "sqlite"},
+			{rpmNdbDBName, "ndb"},
+		} {
+			name := filepath.Join(dirPath, dbInfo.name)
+			if object, ok := objects[name]; ok && object.Mode.IsRegular() {
+				warnings = append(warnings,
+					fmt.Sprintf("unsupported %s rpm database /%s (the rpm packages are not included)", dbInfo.format, name))
+			}
+		}
+	}
+
+	return warnings
+}
+
+// LoadAppPackages returns the application packages (Python, Node.js and Go modules in Go binaries)
+// installed in the final image filesystem
+func (p *Package) LoadAppPackages(archivePath string, objects map[string]*FSObject) ([]*SoftwarePackage, error) {
+	if objects == nil {
+		objects = p.FinalObjects()
+	}
+
+	packages, warnings, err := p.loadAppPackages(archivePath, objects)
+	for _, warning := range warnings {
+		log.Warnf("dockerimage.Package.LoadAppPackages: %s", warning)
+	}
+
+	return packages, err
+}
+
+func (p *Package) loadAppPackages(archivePath string,
+	objects map[string]*FSObject) ([]*SoftwarePackage, []string, error) {
+	var names []string
+	for name, object := range objects {
+		if !object.Mode.IsRegular() {
+			continue
+		}
+
+		dirName := filepath.Dir(name)
+		baseName := filepath.Base(name)
+		switch {
+		case strings.HasSuffix(dirName, pythonDistInfoExt) && baseName == pythonMetadataFile,
+			strings.HasSuffix(dirName, pythonEggInfoExt) && baseName == pythonPkgInfoFile,
+			strings.HasSuffix(baseName, pythonEggInfoExt),
+			isNodePackageFile(name),
+			IsBinary(object.ObjectMetadata) && object.Size > int64(len(elfMagic)):
+			names = append(names, name)
+		}
+	}
+
+	var packages []*SoftwarePackage
+	var warnings []string
+	goModules := map[string]struct{}{}
+	err := p.ReadFinalFiles(archivePath, objects, names,
+		func(name string, reader io.Reader) error {
+			switch {
+			case strings.HasSuffix(name, pythonEggInfoExt) ||
+				strings.HasSuffix(filepath.Dir(name), pythonDistInfoExt) ||
+				strings.HasSuffix(filepath.Dir(name), pythonEggInfoExt):
+				data, err := ioutil.ReadAll(reader)
+				if err != nil {
+					return err
+				}
+
+				pkg, err := ParsePythonMetadata(data)
+				if err != nil {
+					log.Debugf("dockerimage.Package.LoadAppPackages: error parsing Python package metadata - %v", err)
+					warnings = append(warnings, fmt.Sprintf("Python package metadata /%s not fully processed (%v)", name, err))
+				}
+
+				if pkg != nil {
+					pkg.Location = name
+					packages = append(packages, pkg)
+				}
+			case isNodePackageFile(name):
+				data, err := ioutil.ReadAll(reader)
+				if err != nil {
+					return err
+				}
+
+				if pkg := ParseNodePackage(data); pkg != nil {
+					pkg.Location = name
+					packages = append(packages, pkg)
+				}
+			default:
+				magic := make([]byte, len(elfMagic))
+				if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != elfMagic {
+					return nil
+				}
+
+				var info *GoBuildInfo
+				err := withElfData(io.MultiReader(bytes.NewReader(magic), reader), objects[name].Size,
+					func(data io.ReaderAt) error {
+						info, _ = readGoBuildInfo(data)
+						return nil
+					})
+				if err != nil {
+					return err
+				}
+
+				if info == nil {
+					return nil
+				}
+
+				for _, module := range append([]GoModule{info.Main}, info.Deps...) {
+					if module.Path == "" {
+						continue
+					}
+
+					key := module.Path + "@" + module.Version
+					if _, ok := goModules[key]; ok {
+						continue
+					}
+
+					goModules[key] = struct{}{}
+					packages = append(packages, &SoftwarePackage{
+						Type:     PackageTypeGo,
+						Name:     module.Path,
+						Version:  module.Version,
+						Location: name,
+					})
+				}
+			}
+
+			return nil
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sortPackages(packages)
+	return packages, warnings, nil
+}
+
+// ParseOSRelease parses the 'os-release' file data
+func ParseOSRelease(data []byte) *OSRelease {
+	values := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		values[parts[0]] = strings.Trim(parts[1], `"'`)
+	}
+
+	if values["ID"] == "" {
+		return nil
+	}
+
+	return &OSRelease{
+		ID:         values["ID"],
+		VersionID:  values["VERSION_ID"],
+		PrettyName: values["PRETTY_NAME"],
+	}
+}
+
+// ParseDpkgStatus parses the dpkg status file data
+// (only the installed packages are returned; the packages before the error are returned with the error)
+func ParseDpkgStatus(data []byte) ([]*SoftwarePackage, error) {
+	records, err := parseRecords(data, ": ")
+	var packages []*SoftwarePackage
+	for _, record := range records {
+		name := record.first("Package")
+		if name == "" {
+			continue
+		}
+
+		//the status file fragments in 'status.d' (distroless) don't have the status field
+		if status := record.first("Status"); status != "" &&
+			!strings.HasSuffix(status, " "+dpkgStatusInstalled) {
+			continue
+		}
+
+		packages = append(packages, &SoftwarePackage{
+			Type:         PackageTypeDeb,
+			Name:         name,
+			Version:      record.first("Version"),
+			Architecture: record.first("Architecture"),
+		})
+	}
+
+	return packages, err
+}
+
+// ParseApkInstalledDB parses the apk installed package database data
+// (the packages before the error are returned with the error)
+func ParseApkInstalledDB(data []byte) ([]*SoftwarePackage, error) {
+	records, err := parseRecords(data, ":")
+	var packages []*SoftwarePackage
+	for _, record := range records {
+		name := record.first("P")
+		if name == "" {
+			continue
+		}
+
+		pkg := &SoftwarePackage{
+			Type:         PackageTypeApk,
+			Name:         name,
+			Version:      record.first("V"),
+			Architecture: record.first("A"),
+			License:      record.first("L"),
+		}
+
+		//the file records ('R') belong to the last directory record ('F')
+		var dir string
+		for _, field := range record {
+			switch field.key {
+			case "F":
+				dir = field.value
+			case "R":
+				pkg.Files = append(pkg.Files, filepath.Join(dir, field.value))
+			}
+		}
+
+		packages = append(packages, pkg)
+	}
+
+	return packages, err
+}
+
+// ParsePythonMetadata parses the Python package metadata ('METADATA' or 'PKG-INFO')
+// (the package is returned with the error if the metadata headers were parsed before the error)
+func ParsePythonMetadata(data []byte) (*SoftwarePackage, error) {
+	//the metadata body (package description) starts after the first empty line
+	records, err := parseRecords(data, ": ")
+	if len(records) == 0 {
+		return nil, err
+	}
+
+	name := records[0].first("Name")
+	if name == "" {
+		return nil, err
+	}
+
+	pkg := &SoftwarePackage{
+		Type:    PackageTypePython,
+		Name:    name,
+		Version: records[0].first("Version"),
+		License: records[0].first("License"),
+	}
+
+	return pkg, err
+}
+
+// ParseNodePackage parses the Node.js 'package.json' data
+func ParseNodePackage(data []byte) *SoftwarePackage {
+	var info struct {
+		Name    string          `json:"name"`
+		Version string          `json:"version"`
+		License json.RawMessage `json:"license"`
+	}
+
+	if err := json.Unmarshal(data, &info); err != nil || info.Name == "" {
+		return nil
+	}
+
+	pkg := &SoftwarePackage{
+		Type:    PackageTypeNode,
+		Name:    info.Name,
+		Version: info.Version,
+	}
+
+	//the license is a string or (in older packages) an object with the license type
+	var license string
+	var licenseInfo struct {
+		Type string `json:"type"`
+	}
+
+	if err := json.Unmarshal(info.License, &license); err == nil {
+		pkg.License = license
+	} else if err := json.Unmarshal(info.License, &licenseInfo); err == nil {
+		pkg.License = licenseInfo.Type
+	}
+
+	return pkg
+}
+
+// isNodePackageFile returns true for the 'node_modules/[@scope/]name/package.json' files
+func isNodePackageFile(name string) bool {
+	if filepath.Base(name) != nodePackageFile {
+		return false
+	}
+
+	parts := strings.Split(filepath.Dir(name), "/")
+	switch {
+	case len(parts) >= 2 && parts[len(parts)-2] == nodeModulesDirName:
+		return !strings.HasPrefix(parts[len(parts)-1], "@")
+	case len(parts) >= 3 && parts[len(parts)-3] == nodeModulesDirName:
+		return strings.HasPrefix(parts[len(parts)-2], "@")
+	}
+
+	return false
+}
+
+func withLocation(packages []*SoftwarePackage, location string) []*SoftwarePackage {
+	for _, pkg := range packages {
+		pkg.Location = location
+	}
+
+	return packages
+}
+
+func sortPackages(packages []*SoftwarePackage) {
+	sort.SliceStable(packages, func(i, j int) bool {
+		if packages[i].Type != packages[j].Type {
+			return packages[i].Type < packages[j].Type
+		}
+
+		if packages[i].Name != packages[j].Name {
+			return packages[i].Name < packages[j].Name
+		}
+
+		if packages[i].Version != packages[j].Version {
+			return packages[i].Version < packages[j].Version
+		}
+
+		return packages[i].Location < packages[j].Location
+	})
+}
+
+type recordField struct {
+	key   string
+	value string
+}
+
+type record []recordField
+
+func (r record) first(key string) string {
+	for _, field := range r {
+		if field.key == key {
+			return field.value
+		}
+	}
+
+	return ""
+}
+
+// parseRecords parses the 'key<separator>value' records separated with empty lines
+// (the continuation lines starting with a space are ignored; the complete records are returned with the error)
+func parseRecords(data []byte, separator string) ([]record, error) {
+	var records []record
+	var current record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				records = append(records, current)
+				current = nil
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		parts := strings.SplitN(line, separator, 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		current = append(current, recordField{
+			key:   strings.TrimSpace(parts[0]),
+			value: strings.TrimSpace(parts[1]),
+		})
+	}
+
+	//the record at the error is not complete
+	if err := scanner.Err(); err != nil {
+		return records, err
+	}
+
+	if len(current) > 0 {
+		records = append(records, current)
+	}
+
+	return records, nil
+}
+
+func parseFileList(data []byte) []string {
+	var files []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if name := objectPath(strings.TrimSpace(line)); name != "" {
+			files = append(files, name)
+		}
+	}
+
+	return files
+}
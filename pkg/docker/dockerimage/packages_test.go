@@ -0,0 +1,194 @@
+package dockerimage
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDpkgStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []*SoftwarePackage
+		wantErr bool
+	}{
+		{
+			name: "installed packages",
+			data: `Package: bash
+Status: install ok installed
+Architecture: amd64
+Version: 5.1-2+deb11u1
+Description: GNU Bourne Again SHell
+ Bash is an sh-compatible command language interpreter.
+ Version: 0.0 (continuation lines are ignored)
+
+Package: removed-pkg
+Status: deinstall ok config-files
+Architecture: amd64
+Version: 1.0
+
+Package: tzdata
+Status: install ok installed
+Architecture: all
+Version: 2021a-1+deb11u8
+`,
+			want: []*SoftwarePackage{
+				{Type: PackageTypeDeb, Name: "bash", Version: "5.1-2+deb11u1", Architecture: "amd64"},
+				{Type: PackageTypeDeb, Name: "tzdata", Version: "2021a-1+deb11u8", Architecture: "all"},
+			},
+		},
+		{
+			name: "status.d fragment without status",
+			data: `Package: base-files
+Version: 11.1+deb11u7
+Architecture: amd64
+`,
+			want: []*SoftwarePackage{
+				{Type: PackageTypeDeb, Name: "base-files", Version: "11.1+deb11u7", Architecture: "amd64"},
+			},
+		},
+		{
+			name: "no packages",
+			data: "Status: install ok installed\n\n\n",
+		},
+		{
+			name: "line too long",
+			data: "Package: bash\nStatus: install ok installed\n\nPackage: big\nDescription: " +
+				strings.Repeat("x", 2*1024*1024) + "\n",
+			want: []*SoftwarePackage{
+				{Type: PackageTypeDeb, Name: "bash"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			packages, err := ParseDpkgStatus([]byte(test.data))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseDpkgStatus() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(packages, test.want) {
+				t.Errorf("ParseDpkgStatus() = %+v, want %+v", packages, test.want)
+			}
+		})
+	}
+}
+
+func TestParseApkInstalledDB(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []*SoftwarePackage
+		wantErr bool
+	}{
+		{
+			name: "packages with files",
+			data: `C:Q1abc=
+P:musl
+V:1.2.3-r4
+A:x86_64
+L:MIT
+F:lib
+R:ld-musl-x86_64.so.1
+R:libc.musl-x86_64.so.1
+
+P:busybox
+V:1.35.0-r17
+A:x86_64
+L:GPL-2.0-only
+F:bin
+R:busybox
+F:etc
+R:securetty
+`,
+			want: []*SoftwarePackage{
+				{
+					Type:         PackageTypeApk,
+					Name:         "musl",
+					Version:      "1.2.3-r4",
+					Architecture: "x86_64",
+					License:      "MIT",
+					Files:        []string{"lib/ld-musl-x86_64.so.1", "lib/libc.musl-x86_64.so.1"},
+				},
+				{
+					Type:         PackageTypeApk,
+					Name:         "busybox",
+					Version:      "1.35.0-r17",
+					Architecture: "x86_64",
+					License:      "GPL-2.0-only",
+					Files:        []string{"bin/busybox", "etc/securetty"},
+				},
+			},
+		},
+		{
+			name: "record without package name",
+			data: "V:1.0\nA:x86_64\n",
+		},
+		{
+			name:    "line too long",
+			data:    "P:musl\nV:1.2.3-r4\n\nP:big\nT:" + strings.Repeat("x", 2*1024*1024) + "\n",
+			want:    []*SoftwarePackage{{Type: PackageTypeApk, Name: "musl", Version: "1.2.3-r4"}},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			packages, err := ParseApkInstalledDB([]byte(test.data))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseApkInstalledDB() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(packages, test.want) {
+				t.Errorf("ParseApkInstalledDB() = %+v, want %+v", packages, test.want)
+			}
+		})
+	}
+}
+
+func TestUnsupportedRpmDBWarnings(t *testing.T) {
+	file := &FSObject{ObjectMetadata: &ObjectMetadata{Mode: 0644}}
+	dir := &FSObject{ObjectMetadata: &ObjectMetadata{Mode: os.ModeDir | 0755}}
+
+	tests := []struct {
+		name    string
+		objects map[string]*FSObject
+		want    int
+	}{
+		{
+			name:    "BerkeleyDB database",
+			objects: map[string]*FSObject{"var/lib/rpm/Packages": file},
+		},
+		{
+			name:    "sqlite database",
+			objects: map[string]*FSObject{"var/lib/rpm/rpmdb.sqlite": file},
+			want:    1,
+		},
+		{
+			name:    "sqlite database in sysimage",
+			objects: map[string]*FSObject{"usr/lib/sysimage/rpm/rpmdb.sqlite": file},
+			want:    1,
+		},
+		{
+			name:    "ndb database",
+			objects: map[string]*FSObject{"var/lib/rpm/Packages.db": file},
+			want:    1,
+		},
+		{
+			name:    "not a file",
+			objects: map[string]*FSObject{"var/lib/rpm/rpmdb.sqlite": dir},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if warnings := unsupportedRpmDBWarnings(test.objects); len(warnings) != test.want {
+				t.Errorf("unsupportedRpmDBWarnings() = %q, want %d warnings", warnings, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package dockerimage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+)
+
+var ErrBadImagePolicy = errors.New("bad image policy")
+
+// Image policy rule names
+const (
+	PolicyRuleMaxSize          = "max_size"
+	PolicyRuleMaxLayers        = "max_layers"
+	PolicyRuleForbiddenPaths   = "forbidden_paths"
+	PolicyRuleRequiredLabels   = "required_labels"
+	PolicyRuleDisallowRootUser = "disallow_root_user"
+	PolicyRuleMaxSetuidCount   = "max_setuid_count"
+)
+
+const (
+	rootUserName         = "root"
+	policyMaxListedPaths = 20
+)
+
+// ImagePolicy is the image policy file structure (YAML or JSON)
+type ImagePolicy struct {
+	MaxSize          string   `json:"max_size,omitempty"`           //uncompressed image size (e.g., "200MB")
+	MaxLayers        *int     `json:"max_layers,omitempty"`         //layer count
+	ForbiddenPaths   []string `json:"forbidden_paths,omitempty"`    //path patterns (the matching directories can't have any objects either)
+	RequiredLabels   []string `json:"required_labels,omitempty"`    //label names or 'name=value' pairs
+	DisallowRootUser bool     `json:"disallow_root_user,omitempty"` //the image user can't be empty, 'root' or '0'
+	MaxSetuidCount   *int     `json:"max_setuid_count,omitempty"`   //setuid objects in the final image filesystem
+	maxSizeBytes     uint64
+}
+
+// PolicyViolation describes a failed image policy rule
+type PolicyViolation struct {
+	Rule    string   `json:"rule"`
+	Message string   `json:"message"`
+	Paths   []string `json:"paths,omitempty"`
+}
+
+// LoadImagePolicy loads and validates the image policy file (YAML or JSON)
+// (the unknown policy fields are errors, so misspelled rules don't get ignored)
+func LoadImagePolicy(filePath string) (*ImagePolicy, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		log.Errorf("dockerimage.LoadImagePolicy: error decoding policy file(%v) - %v", filePath, err)
+		return nil, fmt.Errorf("%v - %v", ErrBadImagePolicy, err)
+	}
+
+	var policy ImagePolicy
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&policy); err != nil {
+		log.Errorf("dockerimage.LoadImagePolicy: error decoding policy file(%v) - %v", filePath, err)
+		return nil, fmt.Errorf("%v - %v", ErrBadImagePolicy, err)
+	}
+
+	if policy.MaxSize != "" {
+		policy.maxSizeBytes, err = humanize.ParseBytes(policy.MaxSize)
+		if err != nil {
+			return nil, fmt.Errorf("%v - bad %s value (%v)", ErrBadImagePolicy, PolicyRuleMaxSize, err)
+		}
+	}
+
+	if policy.MaxLayers != nil && *policy.MaxLayers < 0 {
+		return nil, fmt.Errorf("%v - bad %s value (%d)", ErrBadImagePolicy, PolicyRuleMaxLayers, *policy.MaxLayers)
+	}
+
+	if policy.MaxSetuidCount != nil && *policy.MaxSetuidCount < 0 {
+		return nil, fmt.Errorf("%v - bad %s value (%d)", ErrBadImagePolicy, PolicyRuleMaxSetuidCount, *policy.MaxSetuidCount)
+	}
+
+	for _, pattern := range policy.ForbiddenPaths {
+		if _, err := filepath.Match(pattern, ""); err != nil || !filepath.IsAbs(pattern) {
+			return nil, fmt.Errorf("%v - bad %s pattern (%s)", ErrBadImagePolicy, PolicyRuleForbiddenPaths, pattern)
+		}
+	}
+
+	return &policy, nil
+}
+
+// CheckPolicy checks the image against the policy rules (returns the failed rules)
+func (p *Package) CheckPolicy(archivePath string, policy *ImagePolicy) ([]*PolicyViolation, error) {
+	var violations []*PolicyViolation
+	config := containerConfig(p)
+
+	if policy.maxSizeBytes > 0 {
+		var size uint64
+		for _, layer := range p.Layers {
+			size += layer.Stats.AllSize
+		}
+
+		if size > policy.maxSizeBytes {
+			violations = append(violations, &PolicyViolation{
+				Rule: PolicyRuleMaxSize,
+				Message: fmt.Sprintf("image size %s is bigger than %s",
+					humanize.Bytes(size), humanize.Bytes(policy.maxSizeBytes)),
+			})
+		}
+	}
+
+	if policy.MaxLayers != nil && len(p.Layers) > *policy.MaxLayers {
+		violations = append(violations, &PolicyViolation{
+			Rule:    PolicyRuleMaxLayers,
+			Message: fmt.Sprintf("image has %d layers (max: %d)", len(p.Layers), *policy.MaxLayers),
+		})
+	}
+
+	if len(policy.ForbiddenPaths) > 0 {
+		objects := p.FinalObjects()
+		for _, pattern := range policy.ForbiddenPaths {
+			var paths []string
+			for _, name := range sortedObjectNames(objects) {
+				if isForbiddenPath(pattern, "/"+name) {
+					paths = append(paths, "/"+name)
+				}
+			}
+
+			if len(paths) == 0 {
+				continue
+			}
+
+			violation := &PolicyViolation{
+				Rule:    PolicyRuleForbiddenPaths,
+				Message: fmt.Sprintf("forbidden path %s found (%d objects)", pattern, len(paths)),
+				Paths:   paths,
+			}
+
+			if len(violation.Paths) > policyMaxListedPaths {
+				violation.Paths = violation.Paths[:policyMaxListedPaths]
+			}
+
+			violations = append(violations, violation)
+		}
+	}
+
+	for _, label := range policy.RequiredLabels {
+		parts := strings.SplitN(label, "=", 2)
+		value, ok := config.Labels[parts[0]]
+		switch {
+		case !ok:
+			violations = append(violations, &PolicyViolation{
+				Rule:    PolicyRuleRequiredLabels,
+				Message: fmt.Sprintf("missing label %s", parts[0]),
+			})
+		case len(parts) == 2 && value != parts[1]:
+			violations = append(violations, &PolicyViolation{
+				Rule:    PolicyRuleRequiredLabels,
+				Message: fmt.Sprintf("label %s has value %s (expected: %s)", parts[0], value, parts[1]),
+			})
+		}
+	}
+
+	if policy.DisallowRootUser {
+		userName := strings.SplitN(config.User, ":", 2)[0]
+		if userName == "" || userName == rootUserName || userName == "0" {
+			user := config.User
+			if user == "" {
+				user = "not set"
+			}
+
+			violations = append(violations, &PolicyViolation{
+				Rule:    PolicyRuleDisallowRootUser,
+				Message: fmt.Sprintf("image runs as root (user: %s)", user),
+			})
+		}
+	}
+
+	if policy.MaxSetuidCount != nil {
+		security, err := p.AnalyzeFileSecurity(archivePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(security.Setuid) > *policy.MaxSetuidCount {
+			violation := &PolicyViolation{
+				Rule: PolicyRuleMaxSetuidCount,
+				Message: fmt.Sprintf("image has %d setuid objects (max: %d)",
+					len(security.Setuid), *policy.MaxSetuidCount),
+			}
+
+			for _, object := range security.Setuid {
+				violation.Paths = append(violation.Paths, object.Path)
+			}
+
+			if len(violation.Paths) > policyMaxListedPaths {
+				violation.Paths = violation.Paths[:policyMaxListedPaths]
+			}
+
+			violations = append(violations, violation)
+		}
+	}
+
+	return violations, nil
+}
+
+// isForbiddenPath checks if the path or any of its parent directories match the pattern
+func isForbiddenPath(pattern, name string) bool {
+	for ; name != "/" && name != "."; name = filepath.Dir(name) {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,237 @@
+package dockerimage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testPolicyFile(t *testing.T, dir, data string) string {
+	filePath := filepath.Join(dir, "policy.yaml")
+	if err := ioutil.WriteFile(filePath, []byte(data), 0644); err != nil {
+		t.Fatalf("ioutil.WriteFile() error = %v", err)
+	}
+
+	return filePath
+}
+
+func testPolicyDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "docker-slim-policy-")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir() error = %v", err)
+	}
+
+	return dir
+}
+
+func TestLoadImagePolicy(t *testing.T) {
+	dir := testPolicyDir(t)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "all rules",
+			data: `max_size: 200MB
+max_layers: 20
+forbidden_paths:
+  - /root/.ssh
+required_labels:
+  - maintainer
+disallow_root_user: true
+max_setuid_count: 2
+`,
+		},
+		{
+			name: "json policy",
+			data: `{"max_layers": 5}`,
+		},
+		{
+			name: "empty policy",
+			data: "",
+		},
+		{
+			name:    "unknown rule",
+			data:    "max_layer: 20\n",
+			wantErr: true,
+		},
+		{
+			name:    "bad max size",
+			data:    "max_size: big\n",
+			wantErr: true,
+		},
+		{
+			name:    "negative max layers",
+			data:    "max_layers: -1\n",
+			wantErr: true,
+		},
+		{
+			name:    "negative max setuid count",
+			data:    "max_setuid_count: -1\n",
+			wantErr: true,
+		},
+		{
+			name:    "relative forbidden path",
+			data:    "forbidden_paths:\n  - root/.ssh\n",
+			wantErr: true,
+		},
+		{
+			name:    "bad value type",
+			data:    "max_layers: many\n",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, err := LoadImagePolicy(testPolicyFile(t, dir, test.data))
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("LoadImagePolicy() error = nil, want error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("LoadImagePolicy() error = %v", err)
+			}
+
+			if policy == nil {
+				t.Fatalf("LoadImagePolicy() = nil, want policy")
+			}
+		})
+	}
+}
+
+func TestCheckPolicy(t *testing.T) {
+	dir := testPolicyDir(t)
+	defer os.RemoveAll(dir)
+
+	pkg := &Package{
+		Config: &ConfigObject{
+			V1ConfigObject: V1ConfigObject{
+				Config: &ContainerConfig{
+					User:   "root",
+					Labels: map[string]string{"version": "1.0"},
+				},
+			},
+		},
+		Layers: []*Layer{
+			{
+				Index: 0,
+				Stats: LayerStats{AllSize: 3000000},
+				Objects: []*ObjectMetadata{
+					{Name: "bin/su", Mode: os.ModeSetuid | 0755},
+					{Name: "root/.ssh", Mode: os.ModeDir | 0700},
+					{Name: "root/.ssh/id_rsa", Mode: 0600},
+				},
+			},
+			{
+				Index: 1,
+				Stats: LayerStats{AllSize: 1000000},
+				Objects: []*ObjectMetadata{
+					{Name: "usr/bin/passwd", Mode: os.ModeSetuid | 0755},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		policy string
+		want   []*PolicyViolation
+	}{
+		{
+			name: "no violations",
+			policy: `max_size: 10MB
+max_layers: 2
+forbidden_paths:
+  - /etc/ssl/private
+required_labels:
+  - version=1.0
+max_setuid_count: 2
+`,
+		},
+		{
+			name:   "max size",
+			policy: "max_size: 3MB\n",
+			want: []*PolicyViolation{
+				{Rule: PolicyRuleMaxSize, Message: "image size 4.0 MB is bigger than 3.0 MB"},
+			},
+		},
+		{
+			name:   "max layers",
+			policy: "max_layers: 1\n",
+			want: []*PolicyViolation{
+				{Rule: PolicyRuleMaxLayers, Message: "image has 2 layers (max: 1)"},
+			},
+		},
+		{
+			name:   "forbidden paths",
+			policy: "forbidden_paths:\n  - /root/.ssh\n",
+			want: []*PolicyViolation{
+				{
+					Rule:    PolicyRuleForbiddenPaths,
+					Message: "forbidden path /root/.ssh found (2 objects)",
+					Paths:   []string{"/root/.ssh", "/root/.ssh/id_rsa"},
+				},
+			},
+		},
+		{
+			name:   "missing required label",
+			policy: "required_labels:\n  - maintainer\n",
+			want: []*PolicyViolation{
+				{Rule: PolicyRuleRequiredLabels, Message: "missing label maintainer"},
+			},
+		},
+		{
+			name:   "required label value",
+			policy: "required_labels:\n  - version=2.0\n",
+			want: []*PolicyViolation{
+				{Rule: PolicyRuleRequiredLabels, Message: "label version has value 1.0 (expected: 2.0)"},
+			},
+		},
+		{
+			name:   "disallow root user",
+			policy: "disallow_root_user: true\n",
+			want: []*PolicyViolation{
+				{Rule: PolicyRuleDisallowRootUser, Message: "image runs as root (user: root)"},
+			},
+		},
+		{
+			name:   "max setuid count",
+			policy: "max_setuid_count: 1\n",
+			want: []*PolicyViolation{
+				{
+					Rule:    PolicyRuleMaxSetuidCount,
+					Message: "image has 2 setuid objects (max: 1)",
+					Paths:   []string{"/bin/su", "/usr/bin/passwd"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, err := LoadImagePolicy(testPolicyFile(t, dir, test.policy))
+			if err != nil {
+				t.Fatalf("LoadImagePolicy() error = %v", err)
+			}
+
+			//the test package has no image archive (the '/etc/passwd' file is not there)
+			violations, err := pkg.CheckPolicy("", policy)
+			if err != nil {
+				t.Fatalf("CheckPolicy() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(violations, test.want) {
+				t.Errorf("CheckPolicy() = %+v, want %+v", violations, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package dockerimage
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Reclaimable space categories
+const (
+	ReclaimAptLists    = "apt-lists"
+	ReclaimAptArchives = "apt-archives"
+	ReclaimApkCache    = "apk-cache"
+	ReclaimPipCache    = "pip-cache"
+	ReclaimNpmCache    = "npm-cache"
+	ReclaimYarnCache   = "yarn-cache"
+	ReclaimGoCache     = "go-cache"
+	ReclaimTmp         = "tmp"
+	ReclaimManPages    = "man-pages"
+	ReclaimDocs        = "docs"
+	ReclaimLocales     = "locales"
+)
+
+// ReclaimableFixes are the suggested fixes for the reclaimable space categories
+// (the fixes need to be applied in the instruction that added the data)
+var ReclaimableFixes = map[string]string{
+	ReclaimAptLists:    "rm -rf /var/lib/apt/lists/*",
+	ReclaimAptArchives: "apt-get clean",
+	ReclaimApkCache:    "apk add --no-cache",
+	ReclaimPipCache:    "pip install --no-cache-dir",
+	ReclaimNpmCache:    "npm cache clean --force",
+	ReclaimYarnCache:   "yarn cache clean",
+	ReclaimGoCache:     "go clean -cache -modcache",
+	ReclaimTmp:         "rm -rf /tmp/* /var/tmp/*",
+	ReclaimManPages:    "rm -rf /usr/share/man/*",
+	ReclaimDocs:        "rm -rf /usr/share/doc/* /usr/share/info/*",
+	ReclaimLocales:     "rm -rf /usr/share/locale/*",
+}
+
+// the directories with the reclaimable data
+// (the '*' path components match the user home directory names)
+var reclaimablePaths = []struct {
+	category string
+	path     string
+}{
+	{ReclaimAptLists, "var/lib/apt/lists"},
+	{ReclaimAptArchives, "var/cache/apt"},
+	{ReclaimApkCache, "var/cache/apk"},
+	{ReclaimApkCache, "etc/apk/cache"},
+	{ReclaimPipCache, "root/.cache/pip"},
+	{ReclaimPipCache, "home/*/.cache/pip"},
+	{ReclaimNpmCache, "root/.npm"},
+	{ReclaimNpmCache, "home/*/.npm"},
+	{ReclaimYarnCache, "usr/local/share/.cache/yarn"},
+	{ReclaimYarnCache, "root/.cache/yarn"},
+	{ReclaimYarnCache, "home/*/.cache/yarn"},
+	{ReclaimGoCache, "root/.cache/go-build"},
+	{ReclaimGoCache, "home/*/.cache/go-build"},
+	{ReclaimGoCache, "go/pkg/mod"},
+	{ReclaimGoCache, "root/go/pkg/mod"},
+	{ReclaimGoCache, "home/*/go/pkg/mod"},
+	{ReclaimTmp, "tmp"},
+	{ReclaimTmp, "var/tmp"},
+	{ReclaimManPages, "usr/share/man"},
+	{ReclaimManPages, "usr/local/share/man"},
+	{ReclaimDocs, "usr/share/doc"},
+	{ReclaimDocs, "usr/local/share/doc"},
+	{ReclaimDocs, "usr/share/info"},
+	{ReclaimLocales, "usr/share/locale"},
+}
+
+// ReclaimableSpace describes the image data that is usually not needed at runtime
+// (the package manager caches, the temporary files, the man pages, docs and locales)
+type ReclaimableSpace struct {
+	Size       uint64                 `json:"size"`
+	Count      int                    `json:"count"`
+	Categories []*ReclaimableCategory `json:"categories,omitempty"`
+	Layers     []*ReclaimableLayer    `json:"layers,omitempty"`
+}
+
+// ReclaimableCategory is the reclaimable data for one category
+type ReclaimableCategory struct {
+	Name  string `json:"name"`
+	Size  uint64 `json:"size"`
+	Count int    `json:"count"`
+	Fix   string `json:"fix"`
+}
+
+// ReclaimableLayer is the reclaimable data added by the layer
+type ReclaimableLayer struct {
+	Index       int                    `json:"index"`
+	ID          string                 `json:"id"`
+	Instruction string                 `json:"instruction,omitempty"` //the instruction that created the layer (set by the caller)
+	Size        uint64                 `json:"size"`
+	Count       int                    `json:"count"`
+	Categories  []*ReclaimableCategory `json:"categories"`
+}
+
+// AnalyzeReclaimableSpace finds the files in the known cache, temporary and documentation
+// directories added by each layer. The reclaimable size includes the files deleted or replaced
+// by the later layers because they can be removed only in the layer that added them.
+func (p *Package) AnalyzeReclaimableSpace() *ReclaimableSpace {
+	result := &ReclaimableSpace{}
+	totals := map[string]*ReclaimableCategory{}
+	for _, layer := range p.Layers {
+		layerTotals := map[string]*ReclaimableCategory{}
+		for _, object := range layer.Objects {
+			if object.Change == ChangeDelete || !object.Mode.IsRegular() {
+				continue
+			}
+
+			category := reclaimableCategory(object.Name)
+			if category == "" {
+				continue
+			}
+
+			size := uint64(0)
+			if object.Size > 0 {
+				size = uint64(object.Size)
+			}
+
+			addReclaimable(layerTotals, category, size)
+			addReclaimable(totals, category, size)
+			result.Size += size
+			result.Count++
+		}
+
+		if len(layerTotals) == 0 {
+			continue
+		}
+
+		info := &ReclaimableLayer{
+			Index:      layer.Index,
+			ID:         layer.ID,
+			Categories: sortedReclaimable(layerTotals),
+		}
+
+		for _, category := range info.Categories {
+			info.Size += category.Size
+			info.Count += category.Count
+		}
+
+		result.Layers = append(result.Layers, info)
+	}
+
+	result.Categories = sortedReclaimable(totals)
+	return result
+}
+
+func addReclaimable(totals map[string]*ReclaimableCategory, category string, size uint64) {
+	info, ok := totals[category]
+	if !ok {
+		info = &ReclaimableCategory{
+			Name: category,
+			Fix:  ReclaimableFixes[category],
+		}
+
+		totals[category] = info
+	}
+
+	info.Size += size
+	info.Count++
+}
+
+func sortedReclaimable(totals map[string]*ReclaimableCategory) []*ReclaimableCategory {
+	var categories []*ReclaimableCategory
+	for _, info := range totals {
+		categories = append(categories, info)
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Size != categories[j].Size {
+			return categories[i].Size > categories[j].Size
+		}
+
+		return categories[i].Name < categories[j].Name
+	})
+
+	return categories
+}
+
+// reclaimableCategory returns the reclaimable space category for the object
+// (the objects need to be inside of the reclaimable directories)
+func reclaimableCategory(name string) string {
+	parts := strings.Split(strings.Trim(filepath.Clean(name), "/"), "/")
+	for _, info := range reclaimablePaths {
+		pathParts := strings.Split(info.path, "/")
+		if len(parts) <= len(pathParts) {
+			continue
+		}
+
+		matched := true
+		for idx, pathPart := range pathParts {
+			if ok, _ := filepath.Match(pathPart, parts[idx]); !ok {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return info.category
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,317 @@
+package dockerimage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+//the rpm database reader supports the BerkeleyDB hash databases ('var/lib/rpm/Packages');
+//the sqlite ('rpmdb.sqlite') and ndb ('Packages.db') databases are not supported
+//(they are reported in the software inventory warnings)
+
+var (
+	ErrBadRpmDB     = errors.New("bad rpm database")
+	ErrBadRpmHeader = errors.New("bad rpm header")
+)
+
+const (
+	bdbHashMagic           = 0x061561
+	bdbMetaMagicOffset     = 12
+	bdbMetaPageSizeOffset  = 20
+	bdbMetaLastPageOffset  = 32
+	bdbMetaSize            = 72
+	bdbPageHeaderSize      = 26
+	bdbPageNextOffset      = 16
+	bdbPageEntriesOffset   = 20
+	bdbPageFreeOffset      = 22
+	bdbPageTypeOffset      = 25
+	bdbHashOffPageSize     = 12
+	bdbPageTypeHashUnsort  = 2
+	bdbPageTypeHash        = 13
+	bdbItemTypeHashOffPage = 3
+	bdbMaxPageSize         = 64 * 1024
+)
+
+const (
+	rpmHeaderEntrySize   = 16
+	rpmHeaderMaxEntries  = 0xffff
+	rpmHeaderMaxDataSize = 256 * 1024 * 1024
+)
+
+// rpm header tags
+const (
+	rpmTagName        = 1000
+	rpmTagVersion     = 1001
+	rpmTagRelease     = 1002
+	rpmTagEpoch       = 1003
+	rpmTagLicense     = 1014
+	rpmTagArch        = 1022
+	rpmTagOldFileName = 1027
+	rpmTagDirIndexes  = 1116
+	rpmTagBaseNames   = 1117
+	rpmTagDirNames    = 1118
+)
+
+// rpm header tag types
+const (
+	rpmTypeInt32       = 4
+	rpmTypeString      = 6
+	rpmTypeStringArray = 8
+	rpmTypeI18NString  = 9
+)
+
+// ParseRpmDB parses the BerkeleyDB rpm database data
+func ParseRpmDB(data []byte) ([]*SoftwarePackage, error) {
+	blobs, err := bdbHashValues(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []*SoftwarePackage
+	for _, blob := range blobs {
+		pkg, err := parseRpmHeader(blob)
+		if err != nil {
+			//skipping the records that are not package headers
+			continue
+		}
+
+		packages = append(packages, pkg)
+	}
+
+	return packages, nil
+}
+
+// bdbHashValues returns the overflow page values from a BerkeleyDB hash database
+// (the rpm package headers are always stored in the overflow pages)
+func bdbHashValues(data []byte) ([][]byte, error) {
+	if len(data) < bdbMetaSize {
+		return nil, ErrBadRpmDB
+	}
+
+	var order binary.ByteOrder
+	switch {
+	case binary.LittleEndian.Uint32(data[bdbMetaMagicOffset:]) == bdbHashMagic:
+		order = binary.LittleEndian
+	case binary.BigEndian.Uint32(data[bdbMetaMagicOffset:]) == bdbHashMagic:
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("%v - not a hash database", ErrBadRpmDB)
+	}
+
+	pageSize := int(order.Uint32(data[bdbMetaPageSizeOffset:]))
+	lastPage := int(order.Uint32(data[bdbMetaLastPageOffset:]))
+	if pageSize < bdbMetaSize || pageSize > bdbMaxPageSize {
+		return nil, fmt.Errorf("%v - bad page size (%d)", ErrBadRpmDB, pageSize)
+	}
+
+	page := func(pageNo int) []byte {
+		start := pageNo * pageSize
+		if pageNo < 0 || start+pageSize > len(data) {
+			return nil
+		}
+
+		return data[start : start+pageSize]
+	}
+
+	var values [][]byte
+	for pageNo := 1; pageNo <= lastPage; pageNo++ {
+		pageData := page(pageNo)
+		if pageData == nil {
+			break
+		}
+
+		pageType := pageData[bdbPageTypeOffset]
+		if pageType != bdbPageTypeHash && pageType != bdbPageTypeHashUnsort {
+			continue
+		}
+
+		//the keys and the values are stored one after the other
+		entryCount := int(order.Uint16(pageData[bdbPageEntriesOffset:]))
+		for idx := 1; idx < entryCount; idx += 2 {
+			indexOffset := bdbPageHeaderSize + idx*2
+			if indexOffset+2 > len(pageData) {
+				break
+			}
+
+			itemOffset := int(order.Uint16(pageData[indexOffset:]))
+			if itemOffset+bdbHashOffPageSize > len(pageData) ||
+				pageData[itemOffset] != bdbItemTypeHashOffPage {
+				continue
+			}
+
+			nextPage := int(order.Uint32(pageData[itemOffset+4:]))
+			valueSize := int(order.Uint32(pageData[itemOffset+8:]))
+			var value []byte
+			for visited := 0; nextPage != 0 && visited <= lastPage; visited++ {
+				overflowPage := page(nextPage)
+				if overflowPage == nil {
+					return nil, fmt.Errorf("%v - bad overflow page (%d)", ErrBadRpmDB, nextPage)
+				}
+
+				nextPage = int(order.Uint32(overflowPage[bdbPageNextOffset:]))
+				dataEnd := len(overflowPage)
+				if nextPage == 0 {
+					//the last page stores the data size in the free area offset field
+					dataEnd = bdbPageHeaderSize + int(order.Uint16(overflowPage[bdbPageFreeOffset:]))
+					if dataEnd > len(overflowPage) {
+						dataEnd = len(overflowPage)
+					}
+				}
+
+				value = append(value, overflowPage[bdbPageHeaderSize:dataEnd]...)
+			}
+
+			if len(value) > valueSize {
+				value = value[:valueSize]
+			}
+
+			values = append(values, value)
+		}
+	}
+
+	return values, nil
+}
+
+type rpmHeaderEntry struct {
+	tag    int32
+	typ    uint32
+	offset int32
+	count  uint32
+}
+
+// parseRpmHeader parses the rpm header blob ('headerImport' format, big endian)
+func parseRpmHeader(blob []byte) (*SoftwarePackage, error) {
+	if len(blob) < 8 {
+		return nil, ErrBadRpmHeader
+	}
+
+	entryCount := int(binary.BigEndian.Uint32(blob[0:]))
+	dataSize := int(binary.BigEndian.Uint32(blob[4:]))
+	if entryCount <= 0 || entryCount > rpmHeaderMaxEntries ||
+		dataSize < 0 || dataSize > rpmHeaderMaxDataSize {
+		return nil, ErrBadRpmHeader
+	}
+
+	dataStart := 8 + entryCount*rpmHeaderEntrySize
+	if dataStart+dataSize > len(blob) {
+		return nil, ErrBadRpmHeader
+	}
+
+	store := blob[dataStart : dataStart+dataSize]
+	entries := map[int32]rpmHeaderEntry{}
+	for idx := 0; idx < entryCount; idx++ {
+		raw := blob[8+idx*rpmHeaderEntrySize:]
+		entry := rpmHeaderEntry{
+			tag:    int32(binary.BigEndian.Uint32(raw[0:])),
+			typ:    binary.BigEndian.Uint32(raw[4:]),
+			offset: int32(binary.BigEndian.Uint32(raw[8:])),
+			count:  binary.BigEndian.Uint32(raw[12:]),
+		}
+
+		if entry.offset < 0 || int(entry.offset) > len(store) {
+			continue
+		}
+
+		entries[entry.tag] = entry
+	}
+
+	name := rpmString(store, entries, rpmTagName)
+	if name == "" {
+		return nil, ErrBadRpmHeader
+	}
+
+	pkg := &SoftwarePackage{
+		Type:         PackageTypeRpm,
+		Name:         name,
+		Version:      rpmString(store, entries, rpmTagVersion),
+		Architecture: rpmString(store, entries, rpmTagArch),
+		License:      rpmString(store, entries, rpmTagLicense),
+	}
+
+	if release := rpmString(store, entries, rpmTagRelease); release != "" {
+		pkg.Version = fmt.Sprintf("%s-%s", pkg.Version, release)
+	}
+
+	if epoch := rpmInt32s(store, entries, rpmTagEpoch); len(epoch) > 0 && epoch[0] > 0 {
+		pkg.Version = fmt.Sprintf("%d:%s", epoch[0], pkg.Version)
+	}
+
+	baseNames := rpmStrings(store, entries, rpmTagBaseNames)
+	dirNames := rpmStrings(store, entries, rpmTagDirNames)
+	dirIndexes := rpmInt32s(store, entries, rpmTagDirIndexes)
+	if len(baseNames) > 0 && len(baseNames) == len(dirIndexes) {
+		for idx, baseName := range baseNames {
+			dirIdx := int(dirIndexes[idx])
+			if dirIdx < 0 || dirIdx >= len(dirNames) {
+				continue
+			}
+
+			if name := objectPath(filepath.Join(dirNames[dirIdx], baseName)); name != "" {
+				pkg.Files = append(pkg.Files, name)
+			}
+		}
+	} else {
+		for _, fileName := range rpmStrings(store, entries, rpmTagOldFileName) {
+			if name := objectPath(fileName); name != "" {
+				pkg.Files = append(pkg.Files, name)
+			}
+		}
+	}
+
+	return pkg, nil
+}
+
+func rpmString(store []byte, entries map[int32]rpmHeaderEntry, tag int32) string {
+	entry, ok := entries[tag]
+	if !ok {
+		return ""
+	}
+
+	switch entry.typ {
+	case rpmTypeString, rpmTypeStringArray, rpmTypeI18NString:
+		if values := rpmCStrings(store[entry.offset:], 1); len(values) > 0 {
+			return values[0]
+		}
+	}
+
+	return ""
+}
+
+func rpmStrings(store []byte, entries map[int32]rpmHeaderEntry, tag int32) []string {
+	entry, ok := entries[tag]
+	if !ok || entry.typ != rpmTypeStringArray {
+		return nil
+	}
+
+	return rpmCStrings(store[entry.offset:], int(entry.count))
+}
+
+func rpmInt32s(store []byte, entries map[int32]rpmHeaderEntry, tag int32) []int32 {
+	entry, ok := entries[tag]
+	if !ok || entry.typ != rpmTypeInt32 {
+		return nil
+	}
+
+	var values []int32
+	data := store[entry.offset:]
+	for idx := 0; idx < int(entry.count) && (idx+1)*4 <= len(data); idx++ {
+		values = append(values, int32(binary.BigEndian.Uint32(data[idx*4:])))
+	}
+
+	return values
+}
+
+func rpmCStrings(data []byte, count int) []string {
+	var values []string
+	start := 0
+	for idx := 0; idx < len(data) && len(values) < count; idx++ {
+		if data[idx] == 0 {
+			values = append(values, string(data[start:idx]))
+			start = idx + 1
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,281 @@
+package dockerimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+type testRpmTag struct {
+	tag   int32
+	typ   uint32
+	value interface{} //string, []string or []int32
+}
+
+// testRpmHeader creates an rpm header blob ('headerImport' format)
+func testRpmHeader(tags ...testRpmTag) []byte {
+	var index, store bytes.Buffer
+	for _, t := range tags {
+		offset := store.Len()
+		count := 1
+		switch value := t.value.(type) {
+		case string:
+			store.WriteString(value)
+			store.WriteByte(0)
+		case []string:
+			count = len(value)
+			for _, s := range value {
+				store.WriteString(s)
+				store.WriteByte(0)
+			}
+		case []int32:
+			//the int32 values are aligned
+			for store.Len()%4 != 0 {
+				store.WriteByte(0)
+			}
+
+			offset = store.Len()
+			count = len(value)
+			binary.Write(&store, binary.BigEndian, value)
+		}
+
+		binary.Write(&index, binary.BigEndian, []uint32{uint32(t.tag), t.typ, uint32(offset), uint32(count)})
+	}
+
+	var blob bytes.Buffer
+	binary.Write(&blob, binary.BigEndian, []uint32{uint32(len(tags)), uint32(store.Len())})
+	blob.Write(index.Bytes())
+	blob.Write(store.Bytes())
+	return blob.Bytes()
+}
+
+// testBdbHashDB creates a BerkeleyDB hash database with one hash page (page 1)
+// and the values stored in the overflow pages (starting with page 2)
+func testBdbHashDB(order binary.ByteOrder, pageSize int, values ...[]byte) []byte {
+	dataSize := pageSize - bdbPageHeaderSize
+	pageCount := 2
+	for _, value := range values {
+		pageCount += (len(value) + dataSize - 1) / dataSize
+	}
+
+	data := make([]byte, pageCount*pageSize)
+	order.PutUint32(data[bdbMetaMagicOffset:], bdbHashMagic)
+	order.PutUint32(data[bdbMetaPageSizeOffset:], uint32(pageSize))
+	order.PutUint32(data[bdbMetaLastPageOffset:], uint32(pageCount-1))
+
+	hashPage := data[pageSize : 2*pageSize]
+	hashPage[bdbPageTypeOffset] = bdbPageTypeHash
+	order.PutUint16(hashPage[bdbPageEntriesOffset:], uint16(len(values)*2))
+
+	nextPage := 2
+	itemOffset := pageSize
+	for idx, value := range values {
+		//the key item (not used)
+		itemOffset -= 8
+		order.PutUint16(hashPage[bdbPageHeaderSize+idx*4:], uint16(itemOffset))
+		hashPage[itemOffset] = 1
+
+		//the off-page value item
+		itemOffset -= bdbHashOffPageSize
+		order.PutUint16(hashPage[bdbPageHeaderSize+idx*4+2:], uint16(itemOffset))
+		hashPage[itemOffset] = bdbItemTypeHashOffPage
+		order.PutUint32(hashPage[itemOffset+4:], uint32(nextPage))
+		order.PutUint32(hashPage[itemOffset+8:], uint32(len(value)))
+
+		for remaining := value; len(remaining) > 0; nextPage++ {
+			page := data[nextPage*pageSize : (nextPage+1)*pageSize]
+			size := len(remaining)
+			if size > dataSize {
+				size = dataSize
+				order.PutUint32(page[bdbPageNextOffset:], uint32(nextPage+1))
+			} else {
+				order.PutUint16(page[bdbPageFreeOffset:], uint16(size))
+			}
+
+			copy(page[bdbPageHeaderSize:], remaining[:size])
+			remaining = remaining[size:]
+		}
+	}
+
+	return data
+}
+
+func TestBdbHashValues(t *testing.T) {
+	small := []byte("small value")
+	large := bytes.Repeat([]byte("0123456789"), 60)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    [][]byte
+		wantErr bool
+	}{
+		{
+			name: "one page value",
+			data: testBdbHashDB(binary.LittleEndian, 512, small),
+			want: [][]byte{small},
+		},
+		{
+			name: "multi page value",
+			data: testBdbHashDB(binary.LittleEndian, 256, small, large),
+			want: [][]byte{small, large},
+		},
+		{
+			name: "big endian",
+			data: testBdbHashDB(binary.BigEndian, 512, small),
+			want: [][]byte{small},
+		},
+		{
+			name:    "too small",
+			data:    make([]byte, bdbMetaSize-1),
+			wantErr: true,
+		},
+		{
+			name:    "not a hash database",
+			data:    make([]byte, 512),
+			wantErr: true,
+		},
+		{
+			name: "bad page size",
+			data: func() []byte {
+				data := testBdbHashDB(binary.LittleEndian, 512, small)
+				binary.LittleEndian.PutUint32(data[bdbMetaPageSizeOffset:], 16)
+				return data
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "missing overflow page",
+			data: func() []byte {
+				data := testBdbHashDB(binary.LittleEndian, 256, large)
+				return data[:3*256]
+			}(),
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, err := bdbHashValues(test.data)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("bdbHashValues() error = nil, want error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("bdbHashValues() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(values, test.want) {
+				t.Errorf("bdbHashValues() = %q, want %q", values, test.want)
+			}
+		})
+	}
+}
+
+func TestParseRpmHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		blob    []byte
+		want    *SoftwarePackage
+		wantErr bool
+	}{
+		{
+			name: "full header",
+			blob: testRpmHeader(
+				testRpmTag{rpmTagName, rpmTypeString, "bash"},
+				testRpmTag{rpmTagVersion, rpmTypeString, "5.1.8"},
+				testRpmTag{rpmTagRelease, rpmTypeString, "6.el9"},
+				testRpmTag{rpmTagEpoch, rpmTypeInt32, []int32{1}},
+				testRpmTag{rpmTagArch, rpmTypeString, "x86_64"},
+				testRpmTag{rpmTagLicense, rpmTypeString, "GPLv3+"},
+				testRpmTag{rpmTagBaseNames, rpmTypeStringArray, []string{"bash", "sh", "bashrc"}},
+				testRpmTag{rpmTagDirNames, rpmTypeStringArray, []string{"/usr/bin/", "/etc/skel/"}},
+				testRpmTag{rpmTagDirIndexes, rpmTypeInt32, []int32{0, 0, 1}},
+			),
+			want: &SoftwarePackage{
+				Type:         PackageTypeRpm,
+				Name:         "bash",
+				Version:      "1:5.1.8-6.el9",
+				Architecture: "x86_64",
+				License:      "GPLv3+",
+				Files:        []string{"usr/bin/bash", "usr/bin/sh", "etc/skel/bashrc"},
+			},
+		},
+		{
+			name: "old file names",
+			blob: testRpmHeader(
+				testRpmTag{rpmTagName, rpmTypeString, "tzdata"},
+				testRpmTag{rpmTagVersion, rpmTypeString, "2021a"},
+				testRpmTag{rpmTagOldFileName, rpmTypeStringArray, []string{"/usr/share/zoneinfo/UTC"}},
+			),
+			want: &SoftwarePackage{
+				Type:    PackageTypeRpm,
+				Name:    "tzdata",
+				Version: "2021a",
+				Files:   []string{"usr/share/zoneinfo/UTC"},
+			},
+		},
+		{
+			name: "no name",
+			blob: testRpmHeader(
+				testRpmTag{rpmTagVersion, rpmTypeString, "1.0"},
+			),
+			wantErr: true,
+		},
+		{
+			name: "truncated data",
+			blob: func() []byte {
+				blob := testRpmHeader(testRpmTag{rpmTagName, rpmTypeString, "bash"})
+				return blob[:len(blob)-2]
+			}(),
+			wantErr: true,
+		},
+		{
+			name:    "too small",
+			blob:    []byte{0, 0, 0, 1},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pkg, err := parseRpmHeader(test.blob)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("parseRpmHeader() error = nil, want error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseRpmHeader() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(pkg, test.want) {
+				t.Errorf("parseRpmHeader() = %+v, want %+v", pkg, test.want)
+			}
+		})
+	}
+}
+
+func TestParseRpmDB(t *testing.T) {
+	header := testRpmHeader(
+		testRpmTag{rpmTagName, rpmTypeString, "bash"},
+		testRpmTag{rpmTagVersion, rpmTypeString, "5.1.8"},
+	)
+
+	//the records that are not package headers are skipped
+	data := testBdbHashDB(binary.LittleEndian, 256, header, []byte("not a header"))
+	packages, err := ParseRpmDB(data)
+	if err != nil {
+		t.Fatalf("ParseRpmDB() error = %v", err)
+	}
+
+	if len(packages) != 1 || packages[0].Name != "bash" || packages[0].Version != "5.1.8" {
+		t.Errorf("ParseRpmDB() = %+v, want the bash package", packages)
+	}
+}
@@ -0,0 +1,365 @@
+package dockerimage
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	v "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+var (
+	ErrUnknownSBOMFormat = errors.New("unknown SBOM format")
+)
+
+// Supported SBOM formats
+const (
+	SBOMFormatCycloneDX = "cyclonedx"
+	SBOMFormatSPDX      = "spdx"
+)
+
+const (
+	sbomToolName           = "docker-slim"
+	sbomFilePrefix         = "sbom"
+	cdxFileExtension       = "cdx.json"
+	spdxFileExtension      = "spdx.json"
+	cdxBOMFormat           = "CycloneDX"
+	cdxSpecVersion         = "1.4"
+	cdxTypeContainer       = "container"
+	cdxTypeOS              = "operating-system"
+	cdxTypeLibrary         = "library"
+	cdxPropertyPrefix      = "docker-slim:package:"
+	cdxPropertyWarning     = "docker-slim:warning"
+	spdxVersion            = "SPDX-2.3"
+	spdxDataLicense        = "CC0-1.0"
+	spdxDocumentID         = "SPDXRef-DOCUMENT"
+	spdxImageID            = "SPDXRef-Image"
+	spdxPackageIDPrefix    = "SPDXRef-Package-"
+	spdxNoAssertion        = "NOASSERTION"
+	spdxNamespacePrefix    = "https://dockersl.im/spdx/"
+	spdxRefCategoryPackage = "PACKAGE-MANAGER"
+	spdxRefTypePURL        = "purl"
+	spdxRelDescribes       = "DESCRIBES"
+	spdxRelContains        = "CONTAINS"
+)
+
+var spdxIDInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// SBOMFormats returns the list of the supported SBOM formats
+func SBOMFormats() []string {
+	return []string{
+		SBOMFormatCycloneDX,
+		SBOMFormatSPDX,
+	}
+}
+
+// IsSBOMFormat returns true if the SBOM format is supported
+func IsSBOMFormat(format string) bool {
+	for _, name := range SBOMFormats() {
+		if name == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SBOMFileName returns the default SBOM file name for the SBOM format
+func SBOMFileName(format string) string {
+	switch format {
+	case SBOMFormatSPDX:
+		return fmt.Sprintf("%s.%s", sbomFilePrefix, spdxFileExtension)
+	default:
+		return fmt.Sprintf("%s.%s", sbomFilePrefix, cdxFileExtension)
+	}
+}
+
+// SBOMTarget describes the image the SBOM is created for
+type SBOMTarget struct {
+	Name string
+	ID   string
+}
+
+// FormatSBOM renders the image software inventory in the selected SBOM format (JSON)
+func FormatSBOM(inventory *SoftwareInventory, format string, target SBOMTarget) ([]byte, error) {
+	if inventory == nil {
+		inventory = &SoftwareInventory{}
+	}
+
+	if target.Name == "" {
+		target.Name = target.ID
+	}
+
+	var doc interface{}
+	switch format {
+	case SBOMFormatCycloneDX:
+		doc = newCycloneDX(inventory, target)
+	case SBOMFormatSPDX:
+		doc = newSPDX(inventory, target)
+	default:
+		return nil, ErrUnknownSBOMFormat
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type cdxDocument struct {
+	BOMFormat    string         `json:"bomFormat"`
+	SpecVersion  string         `json:"specVersion"`
+	SerialNumber string         `json:"serialNumber"`
+	Version      int            `json:"version"`
+	Metadata     cdxMetadata    `json:"metadata"`
+	Components   []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp  string        `json:"timestamp"`
+	Tools      []cdxTool     `json:"tools"`
+	Component  *cdxComponent `json:"component,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxTool struct {
+	Vendor  string `json:"vendor"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type cdxComponent struct {
+	BOMRef     string        `json:"bom-ref,omitempty"`
+	Type       string        `json:"type"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version,omitempty"`
+	PURL       string        `json:"purl,omitempty"`
+	Licenses   []cdxLicense  `json:"licenses,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxLicense struct {
+	License cdxLicenseInfo `json:"license"`
+}
+
+type cdxLicenseInfo struct {
+	Name string `json:"name"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func newCycloneDX(inventory *SoftwareInventory, target SBOMTarget) *cdxDocument {
+	doc := &cdxDocument{
+		BOMFormat:    cdxBOMFormat,
+		SpecVersion:  cdxSpecVersion,
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools: []cdxTool{
+				{
+					Vendor:  sbomToolName,
+					Name:    sbomToolName,
+					Version: v.Tag(),
+				},
+			},
+			Component: &cdxComponent{
+				BOMRef:  target.ID,
+				Type:    cdxTypeContainer,
+				Name:    target.Name,
+				Version: target.ID,
+			},
+		},
+		Components: []cdxComponent{},
+	}
+
+	//the inventory warnings are saved in the metadata (the SBOM is incomplete)
+	for _, warning := range inventory.Warnings {
+		doc.Metadata.Properties = append(doc.Metadata.Properties, cdxProperty{
+			Name:  cdxPropertyWarning,
+			Value: warning,
+		})
+	}
+
+	if inventory.OS != nil {
+		doc.Components = append(doc.Components, cdxComponent{
+			BOMRef:  "os:" + inventory.OS.ID,
+			Type:    cdxTypeOS,
+			Name:    inventory.OS.ID,
+			Version: inventory.OS.VersionID,
+		})
+	}
+
+	refs := map[string]int{}
+	for _, pkg := range inventory.Packages {
+		component := cdxComponent{
+			Type:    cdxTypeLibrary,
+			Name:    pkg.Name,
+			Version: pkg.Version,
+			PURL:    pkg.PURL(),
+		}
+
+		//the bom refs must be unique (the same package can be installed in different locations)
+		component.BOMRef = component.PURL
+		if count := refs[component.PURL]; count > 0 {
+			component.BOMRef = fmt.Sprintf("%s#%d", component.PURL, count)
+		}
+
+		refs[component.PURL]++
+
+		if pkg.License != "" {
+			component.Licenses = []cdxLicense{{License: cdxLicenseInfo{Name: pkg.License}}}
+		}
+
+		component.Properties = append(component.Properties, cdxProperty{
+			Name:  cdxPropertyPrefix + "type",
+			Value: pkg.Type,
+		})
+
+		if pkg.Location != "" {
+			component.Properties = append(component.Properties, cdxProperty{
+				Name:  cdxPropertyPrefix + "location",
+				Value: "/" + pkg.Location,
+			})
+		}
+
+		doc.Components = append(doc.Components, component)
+	}
+
+	return doc
+}
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	DocumentDescribes []string           `json:"documentDescribes"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+	Comment  string   `json:"comment,omitempty"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	LicenseComments  string            `json:"licenseComments,omitempty"`
+	CopyrightText    string            `json:"copyrightText"`
+	SourceInfo       string            `json:"sourceInfo,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func newSPDX(inventory *SoftwareInventory, target SBOMTarget) *spdxDocument {
+	doc := &spdxDocument{
+		SPDXVersion:       spdxVersion,
+		DataLicense:       spdxDataLicense,
+		SPDXID:            spdxDocumentID,
+		Name:              target.Name,
+		DocumentNamespace: spdxNamespacePrefix + spdxIDInvalidChars.ReplaceAllString(target.Name, "-") + "-" + newUUID(),
+		CreationInfo: spdxCreationInfo{
+			Created:  time.Now().UTC().Format(time.RFC3339),
+			Creators: []string{fmt.Sprintf("Tool: %s-%s", sbomToolName, v.Tag())},
+		},
+		DocumentDescribes: []string{spdxImageID},
+		Packages: []spdxPackage{
+			{
+				SPDXID:           spdxImageID,
+				Name:             target.Name,
+				VersionInfo:      target.ID,
+				DownloadLocation: spdxNoAssertion,
+				LicenseConcluded: spdxNoAssertion,
+				LicenseDeclared:  spdxNoAssertion,
+				CopyrightText:    spdxNoAssertion,
+			},
+		},
+		Relationships: []spdxRelationship{
+			{
+				SPDXElementID:      spdxDocumentID,
+				RelationshipType:   spdxRelDescribes,
+				RelatedSPDXElement: spdxImageID,
+			},
+		},
+	}
+
+	//the inventory warnings are saved in the creation info comment (the SBOM is incomplete)
+	if len(inventory.Warnings) > 0 {
+		doc.CreationInfo.Comment = "Warnings: " + strings.Join(inventory.Warnings, "; ")
+	}
+
+	for idx, pkg := range inventory.Packages {
+		//the package license values are not always valid SPDX license expressions
+		spdxPkg := spdxPackage{
+			SPDXID:           fmt.Sprintf("%s%s-%d", spdxPackageIDPrefix, spdxIDInvalidChars.ReplaceAllString(pkg.Name, "-"), idx),
+			Name:             pkg.Name,
+			VersionInfo:      pkg.Version,
+			DownloadLocation: spdxNoAssertion,
+			LicenseConcluded: spdxNoAssertion,
+			LicenseDeclared:  spdxNoAssertion,
+			CopyrightText:    spdxNoAssertion,
+			ExternalRefs: []spdxExternalRef{
+				{
+					ReferenceCategory: spdxRefCategoryPackage,
+					ReferenceType:     spdxRefTypePURL,
+					ReferenceLocator:  pkg.PURL(),
+				},
+			},
+		}
+
+		if pkg.License != "" {
+			spdxPkg.LicenseComments = fmt.Sprintf("declared license: %s", pkg.License)
+		}
+
+		if pkg.Location != "" {
+			spdxPkg.SourceInfo = fmt.Sprintf("%s package metadata: /%s", pkg.Type, pkg.Location)
+		}
+
+		doc.Packages = append(doc.Packages, spdxPkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID:      spdxImageID,
+			RelationshipType:   spdxRelContains,
+			RelatedSPDXElement: spdxPkg.SPDXID,
+		})
+	}
+
+	return doc
+}
+
+// newUUID creates a random (version 4) UUID
+func newUUID() string {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return fmt.Sprintf("%08x-0000-4000-8000-000000000000", time.Now().UnixNano()&0xffffffff)
+	}
+
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
@@ -0,0 +1,378 @@
+package dockerimage
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	secretScanMaxFileSize = 2 * 1024 * 1024
+	secretBinaryCheckSize = 8 * 1024
+	secretMatchPrefixSize = 4
+	secretMatchMaxSize    = 64
+	gitDirName            = ".git"
+	envFileName           = ".env"
+)
+
+// Secret finding types
+const (
+	SecretTypePrivateKey        = "private-key"
+	SecretTypeAWSAccessKey      = "aws-access-key"
+	SecretTypeAWSSecretKey      = "aws-secret-key"
+	SecretTypeGCPServiceAccount = "gcp-service-account"
+	SecretTypeGoogleAPIKey      = "google-api-key"
+	SecretTypeAzureStorageKey   = "azure-storage-key"
+	SecretTypeGitHubToken       = "github-token"
+	SecretTypeSlackToken        = "slack-token"
+	SecretTypeStripeKey         = "stripe-key"
+	SecretTypeNpmToken          = "npm-token"
+	SecretTypePypiPassword      = "pypi-password"
+	SecretTypeDockerAuth        = "docker-auth"
+	SecretTypeGitDir            = "git-dir"
+	SecretTypeEnvFile           = "env-file"
+)
+
+// SecretFinding is a potential secret or credential in an image layer
+type SecretFinding struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	LayerIndex  int    `json:"layer_index"`
+	LayerID     string `json:"layer_id"`
+	Path        string `json:"path"`
+	Line        int    `json:"line,omitempty"`
+	Match       string `json:"match,omitempty"` //redacted
+	Hidden      bool   `json:"hidden"`          //not visible in the final image filesystem (deleted or replaced in a later layer)
+}
+
+type secretRule struct {
+	Type        string
+	Description string
+	Files       []string //base file names the rule is limited to (all files if empty)
+	Pattern     *regexp.Regexp
+}
+
+var secretRules = []*secretRule{
+	{
+		Type:        SecretTypePrivateKey,
+		Description: "Private key",
+		Pattern:     regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`),
+	},
+	{
+		Type:        SecretTypeAWSAccessKey,
+		Description: "AWS access key ID",
+		Pattern:     regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`),
+	},
+	{
+		Type:        SecretTypeAWSSecretKey,
+		Description: "AWS secret access key",
+		Pattern:     regexp.MustCompile(`(?i)aws_secret_access_key["']?\s*[=:]\s*["']?[A-Za-z0-9/+=]{40}\b`),
+	},
+	{
+		Type:        SecretTypeGCPServiceAccount,
+		Description: "GCP service account key",
+		Pattern:     regexp.MustCompile(`"private_key_id"\s*:\s*"[0-9a-f]{40}"`),
+	},
+	{
+		Type:        SecretTypeGoogleAPIKey,
+		Description: "Google API key",
+		Pattern:     regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`),
+	},
+	{
+		Type:        SecretTypeAzureStorageKey,
+		Description: "Azure storage account key",
+		Pattern:     regexp.MustCompile(`AccountKey=[A-Za-z0-9+/]{86}==`),
+	},
+	{
+		Type:        SecretTypeGitHubToken,
+		Description: "GitHub token",
+		Pattern:     regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	},
+	{
+		Type:        SecretTypeSlackToken,
+		Description: "Slack token",
+		Pattern:     regexp.MustCompile(`\bxox[abprs]-[0-9A-Za-z\-]{10,}`),
+	},
+	{
+		Type:        SecretTypeStripeKey,
+		Description: "Stripe secret key",
+		Pattern:     regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}\b`),
+	},
+	{
+		Type:        SecretTypeNpmToken,
+		Description: "npm registry credentials",
+		Files:       []string{".npmrc"},
+		Pattern:     regexp.MustCompile(`(?m)(_authToken|_auth|_password)\s*=\s*\S+`),
+	},
+	{
+		Type:        SecretTypePypiPassword,
+		Description: "PyPI registry password",
+		Files:       []string{".pypirc"},
+		Pattern:     regexp.MustCompile(`(?m)^\s*password\s*[=:]\s*\S+`),
+	},
+	{
+		Type:        SecretTypeDockerAuth,
+		Description: "Docker registry credentials",
+		Files:       []string{"config.json", ".dockercfg"},
+		Pattern:     regexp.MustCompile(`"auth"\s*:\s*"[A-Za-z0-9+/=]{8,}"`),
+	},
+}
+
+// the sample env files usually don't have real values
+var envFileSampleSuffixes = []string{
+	".example",
+	".sample",
+	".template",
+	".dist",
+}
+
+// ScanSecrets scans all files in all image layers for secrets and credentials
+// (including the files deleted or replaced in the later layers; the archive is read only once
+// except for the layers stored as symlinks to other layers)
+func (p *Package) ScanSecrets(archivePath string) ([]*SecretFinding, error) {
+	finalObjects := p.FinalObjects()
+	layerIndexes := map[string]int{}
+	for idx, layer := range p.Layers {
+		layerIndexes[layer.Path] = idx
+	}
+
+	var findings []*SecretFinding
+	scanned := map[int]struct{}{}
+
+	afile, err := os.Open(archivePath)
+	if err != nil {
+		log.Errorf("dockerimage.Package.ScanSecrets: os.Open error - %v", err)
+		return nil, err
+	}
+
+	defer afile.Close()
+
+	tr := tar.NewReader(afile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			log.Errorf("dockerimage.Package.ScanSecrets: error reading archive(%v) - %v", archivePath, err)
+			return nil, err
+		}
+
+		if hdr == nil || hdr.Name == "" || hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		idx, ok := layerIndexes[filepath.Clean(hdr.Name)]
+		if !ok {
+			continue
+		}
+
+		layerFindings, err := scanLayerSecrets(tar.NewReader(tr), p.Layers[idx], finalObjects)
+		if err != nil {
+			log.Errorf("dockerimage.Package.ScanSecrets: error scanning layer(%v) - %v", hdr.Name, err)
+			return nil, err
+		}
+
+		findings = append(findings, layerFindings...)
+		scanned[idx] = struct{}{}
+	}
+
+	for idx, layer := range p.Layers {
+		if _, ok := scanned[idx]; ok {
+			continue
+		}
+
+		layerReader, err := FileReaderFromTar(archivePath, layer.Path)
+		if err != nil {
+			log.Errorf("dockerimage.Package.ScanSecrets: error reading layer from archive(%v/%v) - %v", archivePath, layer.Path, err)
+			return nil, err
+		}
+
+		layerFindings, err := scanLayerSecrets(tar.NewReader(layerReader), layer, finalObjects)
+		layerReader.Close()
+		if err != nil {
+			log.Errorf("dockerimage.Package.ScanSecrets: error scanning layer(%v) - %v", layer.Path, err)
+			return nil, err
+		}
+
+		findings = append(findings, layerFindings...)
+	}
+
+	return findings, nil
+}
+
+func scanLayerSecrets(tr *tar.Reader, layer *Layer, finalObjects map[string]*FSObject) ([]*SecretFinding, error) {
+	var findings []*SecretFinding
+	gitDirs := map[string]struct{}{}
+
+	newFinding := func(name, findingType, description string) *SecretFinding {
+		finding := &SecretFinding{
+			Type:        findingType,
+			Description: description,
+			LayerIndex:  layer.Index,
+			LayerID:     layer.ID,
+			Path:        "/" + name,
+			Hidden:      true,
+		}
+
+		if object, ok := finalObjects[name]; ok &&
+			(object.LayerIndex == layer.Index || object.Mode.IsDir()) {
+			finding.Hidden = false
+		}
+
+		return finding
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr == nil || hdr.Name == "" {
+			continue
+		}
+
+		name := objectPath(hdr.Name)
+		if name == "" || IsDeletedFileObject(name) {
+			continue
+		}
+
+		if gitDir := gitDirPath(name); gitDir != "" {
+			if _, ok := gitDirs[gitDir]; !ok {
+				gitDirs[gitDir] = struct{}{}
+				findings = append(findings, newFinding(gitDir, SecretTypeGitDir, "Git repository directory"))
+			}
+
+			//the git objects are not scanned
+			continue
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		baseName := filepath.Base(name)
+		if isEnvFile(baseName) {
+			findings = append(findings, newFinding(name, SecretTypeEnvFile, "Environment variable file"))
+		}
+
+		if hdr.Size <= 0 || hdr.Size > secretScanMaxFileSize {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		checkSize := len(data)
+		if checkSize > secretBinaryCheckSize {
+			checkSize = secretBinaryCheckSize
+		}
+
+		if bytes.IndexByte(data[:checkSize], 0) != -1 {
+			//skipping binary files
+			continue
+		}
+
+		for _, rule := range secretRules {
+			if !rule.matchesFile(baseName) {
+				continue
+			}
+
+			loc := rule.Pattern.FindIndex(data)
+			if loc == nil {
+				continue
+			}
+
+			finding := newFinding(name, rule.Type, rule.Description)
+			finding.Line = bytes.Count(data[:loc[0]], []byte("\n")) + 1
+			finding.Match = redactSecret(string(data[loc[0]:loc[1]]))
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings, nil
+}
+
+func (r *secretRule) matchesFile(baseName string) bool {
+	if len(r.Files) == 0 {
+		return true
+	}
+
+	for _, name := range r.Files {
+		if name == baseName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// gitDirPath returns the path of the git repository directory the object belongs to
+func gitDirPath(name string) string {
+	parts := strings.Split(name, "/")
+	for idx, part := range parts {
+		if part == gitDirName {
+			return strings.Join(parts[:idx+1], "/")
+		}
+	}
+
+	return ""
+}
+
+func isEnvFile(baseName string) bool {
+	if baseName != envFileName && !strings.HasPrefix(baseName, envFileName+".") {
+		return false
+	}
+
+	for _, suffix := range envFileSampleSuffixes {
+		if strings.HasSuffix(baseName, suffix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func redactSecret(match string) string {
+	match = strings.TrimSpace(match)
+	if strings.HasPrefix(match, "-----BEGIN") {
+		//the private key headers are not secret
+		return match
+	}
+
+	if len(match) > secretMatchMaxSize {
+		match = match[:secretMatchMaxSize]
+	}
+
+	//keeping the key names for the 'key=value' matches
+	var key string
+	if idx := strings.IndexAny(match, "=:"); idx != -1 {
+		key = match[:idx+1]
+		match = match[idx+1:]
+		for len(match) > 0 && strings.ContainsAny(match[:1], " \t\"'") {
+			key += match[:1]
+			match = match[1:]
+		}
+	}
+
+	if len(match) <= secretMatchPrefixSize {
+		return key + strings.Repeat("*", len(match))
+	}
+
+	return key + match[:secretMatchPrefixSize] + strings.Repeat("*", len(match)-secretMatchPrefixSize)
+}
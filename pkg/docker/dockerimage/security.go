@@ -0,0 +1,221 @@
+package dockerimage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/syndtr/gocapability/capability"
+)
+
+const (
+	paxXattrPrefix       = "SCHILY.xattr."
+	xattrCapability      = "security.capability"
+	passwdFilePath       = "etc/passwd"
+	rootUID              = 0
+	vfsCapRevisionMask   = 0xFF000000
+	vfsCapFlagsEffective = 0x000001
+	vfsCapRevision1      = 0x01000000
+	vfsCapRevision2      = 0x02000000
+	vfsCapRevision3      = 0x03000000
+	vfsCapU32Revision1   = 1
+	vfsCapU32Revision2   = 2
+	vfsCapHeaderSize     = 4
+	vfsCapDataSize       = 8
+	capabilityNamePrefix = "cap_"
+	capabilityFlagsEP    = "=ep"
+	capabilityFlagsP     = "=p"
+	capabilityFlagsI     = "+i"
+)
+
+// FileSecurity lists the security relevant objects in the final image filesystem
+type FileSecurity struct {
+	ExpectedUIDs    []int             `json:"expected_uids"`
+	Setuid          []*SecurityObject `json:"setuid,omitempty"`
+	Setgid          []*SecurityObject `json:"setgid,omitempty"`
+	WorldWritable   []*SecurityObject `json:"world_writable,omitempty"`
+	UnexpectedOwner []*SecurityObject `json:"unexpected_owner,omitempty"`
+	Capabilities    []*SecurityObject `json:"capabilities,omitempty"`
+}
+
+// SecurityObject is a security relevant object in the final image filesystem
+type SecurityObject struct {
+	Path         string      `json:"path"`
+	LayerIndex   int         `json:"layer_index"`
+	Mode         os.FileMode `json:"mode"`
+	ModeHuman    string      `json:"mode_human"`
+	UID          int         `json:"uid"`
+	GID          int         `json:"gid"`
+	Capabilities string      `json:"capabilities,omitempty"`
+}
+
+// AnalyzeFileSecurity finds the setuid/setgid objects, the world-writable files and directories
+// (without the sticky bit), the objects owned by unexpected UIDs (not root and not defined in '/etc/passwd')
+// and the files with capabilities in the final image filesystem
+func (p *Package) AnalyzeFileSecurity(archivePath string) (*FileSecurity, error) {
+	objects := p.FinalObjects()
+
+	expectedUIDs := map[int]struct{}{rootUID: {}}
+	filesData, err := p.FinalFilesData(archivePath, objects, []string{passwdFilePath})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, uid := range parsePasswdUIDs(filesData[passwdFilePath]) {
+		expectedUIDs[uid] = struct{}{}
+	}
+
+	//the numeric image user is also expected
+	if p.Config != nil && p.Config.Config != nil && p.Config.Config.User != "" {
+		userName := strings.SplitN(p.Config.Config.User, ":", 2)[0]
+		if uid, err := strconv.Atoi(userName); err == nil {
+			expectedUIDs[uid] = struct{}{}
+		}
+	}
+
+	result := &FileSecurity{}
+	for uid := range expectedUIDs {
+		result.ExpectedUIDs = append(result.ExpectedUIDs, uid)
+	}
+
+	sort.Ints(result.ExpectedUIDs)
+
+	var names []string
+	for name := range objects {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		object := objects[name]
+		mode := object.Mode
+		if mode&os.ModeSymlink != 0 {
+			continue
+		}
+
+		info := &SecurityObject{
+			Path:         "/" + name,
+			LayerIndex:   object.LayerIndex,
+			Mode:         mode,
+			ModeHuman:    mode.String(),
+			UID:          object.UID,
+			GID:          object.GID,
+			Capabilities: object.Capabilities,
+		}
+
+		if mode&os.ModeSetuid != 0 {
+			result.Setuid = append(result.Setuid, info)
+		}
+
+		if mode&os.ModeSetgid != 0 {
+			result.Setgid = append(result.Setgid, info)
+		}
+
+		if mode.Perm()&0002 != 0 &&
+			(mode.IsRegular() || (mode.IsDir() && mode&os.ModeSticky == 0)) {
+			result.WorldWritable = append(result.WorldWritable, info)
+		}
+
+		if _, ok := expectedUIDs[object.UID]; !ok {
+			result.UnexpectedOwner = append(result.UnexpectedOwner, info)
+		}
+
+		if object.Capabilities != "" {
+			result.Capabilities = append(result.Capabilities, info)
+		}
+	}
+
+	return result, nil
+}
+
+func parsePasswdUIDs(data []byte) []int {
+	var uids []int
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 {
+			continue
+		}
+
+		if uid, err := strconv.Atoi(fields[2]); err == nil {
+			uids = append(uids, uid)
+		}
+	}
+
+	return uids
+}
+
+// DecodeFileCapabilities decodes the 'security.capability' xattr value
+// (using the 'getcap' format, e.g., 'cap_net_bind_service=ep')
+func DecodeFileCapabilities(data []byte) string {
+	if len(data) < vfsCapHeaderSize+vfsCapDataSize {
+		return ""
+	}
+
+	magic := binary.LittleEndian.Uint32(data)
+	var count int
+	switch magic & vfsCapRevisionMask {
+	case vfsCapRevision1:
+		count = vfsCapU32Revision1
+	case vfsCapRevision2, vfsCapRevision3:
+		count = vfsCapU32Revision2
+	default:
+		return ""
+	}
+
+	if len(data) < vfsCapHeaderSize+count*vfsCapDataSize {
+		return ""
+	}
+
+	var permitted, inheritable uint64
+	for idx := 0; idx < count; idx++ {
+		offset := vfsCapHeaderSize + idx*vfsCapDataSize
+		permitted |= uint64(binary.LittleEndian.Uint32(data[offset:])) << (32 * uint(idx))
+		inheritable |= uint64(binary.LittleEndian.Uint32(data[offset+4:])) << (32 * uint(idx))
+	}
+
+	flags := capabilityFlagsP
+	if magic&vfsCapFlagsEffective != 0 {
+		flags = capabilityFlagsEP
+	}
+
+	var parts []string
+	if permitted != 0 {
+		parts = append(parts, capabilityNames(permitted)+flags)
+	}
+
+	if inheritable != 0 {
+		parts = append(parts, capabilityNames(inheritable)+capabilityFlagsI)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func capabilityNames(bits uint64) string {
+	var names []string
+	for bit := uint(0); bit < 64; bit++ {
+		if bits&(1<<bit) == 0 {
+			continue
+		}
+
+		name := capability.Cap(bit).String()
+		if name == "unknown" {
+			name = fmt.Sprintf("%d", bit)
+		}
+
+		names = append(names, capabilityNamePrefix+name)
+	}
+
+	return strings.Join(names, ",")
+}
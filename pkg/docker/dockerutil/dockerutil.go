@@ -253,6 +253,44 @@ func SaveImage(dclient *dockerapi.Client, imageRef, local string, extract, remov
 	return nil
 }
 
+func LoadImage(dclient *dockerapi.Client, local string) error {
+	if local == "" {
+		return ErrBadParam
+	}
+
+	var err error
+	if dclient == nil {
+		dclient, err = dockerapi.NewClient(dockerHost)
+		if err != nil {
+			log.Errorf("dockerutil.LoadImage: dockerapi.NewClient() error = %v", err)
+			return err
+		}
+	}
+
+	afile, err := os.Open(local)
+	if err != nil {
+		log.Errorf("dockerutil.LoadImage: os.Open error - %v", err)
+		return err
+	}
+
+	defer afile.Close()
+
+	var output bytes.Buffer
+	options := dockerapi.LoadImageOptions{
+		InputStream:  afile,
+		OutputStream: &output,
+	}
+
+	err = dclient.LoadImage(options)
+	if err != nil {
+		log.Errorf("dockerutil.LoadImage: dclient.LoadImage() error = %v", err)
+		return err
+	}
+
+	log.Debugf("dockerutil.LoadImage: output = %s", output.String())
+	return nil
+}
+
 func HasVolume(dclient *dockerapi.Client, name string) error {
 	if name == "" {
 		return ErrBadParam
@@ -43,6 +43,8 @@ type Field struct {
 	IsOnBuild   bool     `json:"is_onbuild,omitempty"`
 	IsValid     bool     `json:"is_valid"`
 	Errors      []string `json:"errors,omitempty"`
+	//check IDs from the ignore comments before the instruction ("*" for all checks)
+	IgnoredChecks []string `json:"ignored_checks,omitempty"`
 }
 
 type Format struct {
@@ -2,6 +2,9 @@
 package check
 
 import (
+	docker "github.com/fsouza/go-dockerclient"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/reverse"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/spec"
 	"github.com/docker-slim/docker-slim/pkg/docker/dockerignore"
 	"github.com/docker-slim/docker-slim/pkg/docker/instruction"
@@ -12,6 +15,15 @@ type Context struct {
 	Dockerfile      *spec.Dockerfile
 	BuildContextDir string
 	Dockerignore    *dockerignore.Matcher
+	Image           *ImageInfo //only for the image targets
+}
+
+// ImageInfo provides the target container image data for the image checks
+type ImageInfo struct {
+	ID      string
+	Name    string
+	Config  *docker.Config
+	History *reverse.Dockerfile
 }
 
 type Options struct {
@@ -53,11 +65,12 @@ const (
 	ScopeData         = "data"
 	ScopeApp          = "app"
 	ScopeShell        = "shell"
+	ScopeImage        = "image"
 )
 
 //Possible labels:
 //"level" -> "info", "warn", "error", "style"
-//"scope" -> "app", "shell", "instruction", "stage", "dockerfile", "all", "dockerignore", "data"
+//"scope" -> "app", "shell", "instruction", "stage", "dockerfile", "all", "dockerignore", "data", "image"
 //"instruction" -> "list,of,instructions" (negative with !instruction)
 //"app" -> "list,of,app names"
 //"shell" -> "general or specific shell name"
@@ -85,9 +98,6 @@ type Runner interface {
 	Run(opts *Options, ctx *Context) (*Result, error)
 }
 
-//regex-based (with rules)
-//type PolicyCheck struct {
-//	Info
-//}
+//regex-based (with rules): see PolicyCheck
 
 var AllChecks = []Runner{}
@@ -0,0 +1,198 @@
+// Package check contains the linter checks
+package check
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/google/shlex"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/spec"
+	"github.com/docker-slim/docker-slim/pkg/docker/instruction"
+)
+
+// Fix describes a Dockerfile change that fixes a check match
+// (the original lines from StartLine to EndLine are replaced with the new lines)
+type Fix struct {
+	CheckID   string   `json:"check_id"`
+	StartLine int      `json:"start_line"`
+	EndLine   int      `json:"end_line"`
+	Lines     []string `json:"lines"`
+}
+
+// Fixer is implemented by the checks that can safely fix their matches
+// (Fix returns nil if there's no safe fix for the match)
+type Fixer interface {
+	Fix(ctx *Context, match *Match) (*Fix, error)
+}
+
+const (
+	defaultEscapeToken = '\\'
+	mergeCmdSeparator  = " && "
+	mergeCmdIndent     = "\t"
+)
+
+// shell characters that make the shell form to exec form conversion unsafe
+const shellMetaChars = "$;&|<>*?(){}[]~`\\\n"
+
+// shell commands that change the state for the commands that follow them
+var shellStateCommands = map[string]struct{}{
+	"cd":     {},
+	"pushd":  {},
+	"popd":   {},
+	"export": {},
+	"unset":  {},
+	"source": {},
+	".":      {},
+	"set":    {},
+	"umask":  {},
+	"ulimit": {},
+	"alias":  {},
+	"shopt":  {},
+	"trap":   {},
+	"exec":   {},
+	"exit":   {},
+}
+
+// instructionKeyword returns the instruction name as it's written in the Dockerfile
+func instructionKeyword(inst *instruction.Field) string {
+	if len(inst.RawLines) > 0 {
+		if fields := strings.Fields(inst.RawLines[0]); len(fields) > 0 &&
+			strings.ToLower(fields[0]) == inst.Name {
+			return fields[0]
+		}
+	}
+
+	return strings.ToUpper(inst.Name)
+}
+
+func isFixable(df *spec.Dockerfile, inst *instruction.Field) bool {
+	return df != nil &&
+		inst != nil &&
+		inst.IsValid &&
+		!inst.IsOnBuild &&
+		inst.StartLine > 0 &&
+		inst.EndLine >= inst.StartLine &&
+		inst.EndLine <= len(df.Lines) &&
+		len(inst.RawLines) == (inst.EndLine-inst.StartLine+1)
+}
+
+// execFormArgs returns the exec/JSON form args for the shell form instruction
+// (empty if the instruction uses shell features and it can't be converted safely)
+func execFormArgs(inst *instruction.Field) string {
+	if inst.IsJSONForm ||
+		inst.ArgsRaw == "" ||
+		strings.ContainsAny(inst.ArgsRaw, shellMetaChars) {
+		return ""
+	}
+
+	args, err := shlex.Split(inst.ArgsRaw)
+	if err != nil || len(args) == 0 {
+		return ""
+	}
+
+	return jsonArgs(args)
+}
+
+// jsonArgs returns the exec/JSON form args for the instruction args
+// (the shell characters are not escaped, so they stay readable in the Dockerfile)
+func jsonArgs(args []string) string {
+	var quotedArgs []string
+	for _, arg := range args {
+		var data strings.Builder
+		encoder := json.NewEncoder(&data)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(arg); err != nil {
+			return ""
+		}
+
+		quotedArgs = append(quotedArgs, strings.TrimSuffix(data.String(), "\n"))
+	}
+
+	return "[" + strings.Join(quotedArgs, ", ") + "]"
+}
+
+// mergeRunFix creates a fix that merges the RUN instruction with the previous RUN instruction
+func mergeRunFix(checkID string, df *spec.Dockerfile, prevInst, inst *instruction.Field) *Fix {
+	if !isFixable(df, prevInst) ||
+		!isFixable(df, inst) ||
+		prevInst.Name != instruction.Run ||
+		inst.Name != instruction.Run ||
+		prevInst.IsJSONForm ||
+		inst.IsJSONForm ||
+		len(prevInst.Flags) > 0 ||
+		len(inst.Flags) > 0 ||
+		inst.StartLine != prevInst.EndLine+1 ||
+		(df.EscapeToken != 0 && df.EscapeToken != defaultEscapeToken) {
+		return nil
+	}
+
+	prevArgs := strings.TrimSpace(prevInst.ArgsRaw)
+	if prevArgs == "" ||
+		strings.HasSuffix(prevArgs, "&") ||
+		strings.HasSuffix(prevArgs, "|") ||
+		strings.HasSuffix(prevArgs, ";") ||
+		changesShellState(prevArgs) {
+		return nil
+	}
+
+	firstLine := strings.TrimSpace(inst.RawLines[0])
+	keyword := instructionKeyword(inst)
+	if !strings.HasPrefix(firstLine, keyword) {
+		return nil
+	}
+
+	fix := &Fix{
+		CheckID:   checkID,
+		StartLine: prevInst.StartLine,
+		EndLine:   inst.EndLine,
+	}
+
+	fix.Lines = append(fix.Lines, prevInst.RawLines...)
+	lastIdx := len(fix.Lines) - 1
+	fix.Lines[lastIdx] = strings.TrimRight(fix.Lines[lastIdx], " \t") + mergeCmdSeparator + "\\"
+
+	//using the same indentation as the continuation lines in the previous instruction
+	indent := mergeCmdIndent
+	if len(prevInst.RawLines) > 1 {
+		line := prevInst.RawLines[1]
+		indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	}
+
+	fix.Lines = append(fix.Lines, indent+strings.TrimSpace(strings.TrimPrefix(firstLine, keyword)))
+	fix.Lines = append(fix.Lines, inst.RawLines[1:]...)
+	return fix
+}
+
+// previousInstruction returns the instruction before the matched instruction in its stage
+func previousInstruction(match *Match) *instruction.Field {
+	if match.Stage == nil || match.Instruction == nil {
+		return nil
+	}
+
+	var prevInst *instruction.Field
+	for _, inst := range match.Stage.AllInstructions {
+		if inst == match.Instruction {
+			return prevInst
+		}
+
+		prevInst = inst
+	}
+
+	return nil
+}
+
+func changesShellState(cmds string) bool {
+	tokens, err := shlex.Split(cmds)
+	if err != nil {
+		return true
+	}
+
+	for _, token := range tokens {
+		if _, ok := shellStateCommands[token]; ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,261 @@
+package check
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/parser"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/spec"
+	"github.com/docker-slim/docker-slim/pkg/docker/instruction"
+)
+
+func testDockerfile(t *testing.T, data string) *spec.Dockerfile {
+	df, err := parser.FromReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parser.FromReader() error = %v", err)
+	}
+
+	return df
+}
+
+// testLastMatch returns the match for the last selected instruction in the last stage
+func testLastMatch(t *testing.T, df *spec.Dockerfile, names ...string) *Match {
+	if len(df.Stages) == 0 {
+		t.Fatalf("no stages")
+	}
+
+	stage := df.Stages[len(df.Stages)-1]
+	for idx := len(stage.AllInstructions) - 1; idx >= 0; idx-- {
+		inst := stage.AllInstructions[idx]
+		for _, name := range names {
+			if inst.Name == name {
+				return &Match{Stage: stage, Instruction: inst}
+			}
+		}
+	}
+
+	t.Fatalf("no %v instructions", names)
+	return nil
+}
+
+func TestMergeRunFix(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want *Fix
+	}{
+		{
+			name: "single lines",
+			data: "FROM alpine\nRUN apk update\nRUN apk add curl\n",
+			want: &Fix{
+				CheckID:   "ID.TEST",
+				StartLine: 2,
+				EndLine:   3,
+				Lines:     []string{"RUN apk update && \\", "\tapk add curl"},
+			},
+		},
+		{
+			name: "continuation lines",
+			data: "FROM alpine\nrun apk update && \\\n    apk add git\nrun apk add curl \\\n    wget\n",
+			want: &Fix{
+				CheckID:   "ID.TEST",
+				StartLine: 2,
+				EndLine:   5,
+				Lines: []string{
+					"run apk update && \\",
+					"    apk add git && \\",
+					"    apk add curl \\",
+					"    wget",
+				},
+			},
+		},
+		{
+			name: "previous command in background",
+			data: "FROM alpine\nRUN sleep 10 &\nRUN apk add curl\n",
+		},
+		{
+			name: "previous command changes directory",
+			data: "FROM alpine\nRUN cd /tmp\nRUN apk add curl\n",
+		},
+		{
+			name: "lines between instructions",
+			data: "FROM alpine\nRUN apk update\n# packages\nRUN apk add curl\n",
+		},
+		{
+			name: "exec form",
+			data: "FROM alpine\nRUN apk update\nRUN [\"apk\", \"add\", \"curl\"]\n",
+		},
+		{
+			name: "instruction flags",
+			data: "FROM alpine\nRUN apk update\nRUN --network=none apk add curl\n",
+		},
+		{
+			name: "escape directive",
+			data: "# escape=`\nFROM alpine\nRUN apk update\nRUN apk add curl\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			df := testDockerfile(t, test.data)
+			match := testLastMatch(t, df, instruction.Run)
+			fix := mergeRunFix("ID.TEST", df, previousInstruction(match), match.Instruction)
+			if !reflect.DeepEqual(fix, test.want) {
+				t.Errorf("mergeRunFix() = %+v, want %+v", fix, test.want)
+			}
+		})
+	}
+}
+
+func TestRelativeWorkdirFix(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "absolute workdir before",
+			data: "FROM alpine\nWORKDIR /app\nWORKDIR src\n",
+			want: []string{"WORKDIR /app/src"},
+		},
+		{
+			name: "relative workdirs after absolute workdir",
+			data: "FROM alpine\nWORKDIR /app\nWORKDIR src\nworkdir ../build\n",
+			want: []string{"workdir /app/build"},
+		},
+		{
+			name: "scratch base image",
+			data: "FROM scratch\nWORKDIR app\n",
+			want: []string{"WORKDIR /app"},
+		},
+		{
+			name: "unknown base image workdir",
+			data: "FROM alpine\nWORKDIR app\n",
+		},
+		{
+			name: "variable in earlier workdir",
+			data: "FROM alpine\nWORKDIR $HOME\nWORKDIR app\n",
+		},
+		{
+			name: "variable in workdir",
+			data: "FROM scratch\nWORKDIR $APP\n",
+		},
+		{
+			name: "quoted workdir",
+			data: "FROM scratch\nWORKDIR \"my app\"\n",
+		},
+	}
+
+	c := &RelativeWorkdir{Info: Info{ID: "ID.20015"}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			df := testDockerfile(t, test.data)
+			match := testLastMatch(t, df, instruction.Workdir)
+			fix, err := c.Fix(&Context{Dockerfile: df}, match)
+			if err != nil {
+				t.Fatalf("Fix() error = %v", err)
+			}
+
+			var got []string
+			if fix != nil {
+				got = fix.Lines
+				if fix.StartLine != match.Instruction.StartLine || fix.EndLine != match.Instruction.EndLine {
+					t.Errorf("Fix() lines = %d-%d, want %d-%d",
+						fix.StartLine, fix.EndLine, match.Instruction.StartLine, match.Instruction.EndLine)
+				}
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Fix() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEntrypointCmdShellFormFix(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "scratch without counterpart",
+			data: "FROM scratch\nCMD /app --port 8080\n",
+			want: []string{`CMD ["/app", "--port", "8080"]`},
+		},
+		{
+			name: "scratch parent stage without counterpart",
+			data: "FROM scratch AS base\nCOPY app /app\nFROM base\nentrypoint /app 'a b'\n",
+			want: []string{`entrypoint ["/app", "a b"]`},
+		},
+		{
+			name: "unknown base image",
+			data: "FROM node:16\nCMD node server.js\n",
+			want: []string{`CMD ["/bin/sh", "-c", "node server.js"]`},
+		},
+		{
+			name: "counterpart instruction",
+			data: "FROM scratch\nENTRYPOINT [\"/app\"]\nCMD serve --port 8080\n",
+			want: []string{`CMD ["/bin/sh", "-c", "serve --port 8080"]`},
+		},
+		{
+			name: "shell features",
+			data: "FROM scratch\nCMD /app > /tmp/log 2>&1\n",
+			want: []string{`CMD ["/bin/sh", "-c", "/app > /tmp/log 2>&1"]`},
+		},
+		{
+			name: "continuation lines",
+			data: "FROM node:16\nCMD node \\\n  server.js\n",
+			want: []string{`CMD ["/bin/sh", "-c", "node   server.js"]`},
+		},
+		{
+			name: "shell instruction",
+			data: "FROM alpine\nSHELL [\"/bin/bash\", \"-c\"]\nCMD echo $HOME\n",
+			want: []string{`CMD ["/bin/bash", "-c", "echo $HOME"]`},
+		},
+		{
+			name: "shell instruction after",
+			data: "FROM alpine\nCMD echo $HOME\nSHELL [\"/bin/bash\", \"-c\"]\n",
+			want: []string{`CMD ["/bin/sh", "-c", "echo $HOME"]`},
+		},
+		{
+			name: "parent stage shell instruction",
+			data: "FROM alpine AS base\nSHELL [\"/bin/ash\", \"-eo\", \"pipefail\", \"-c\"]\nFROM base\nCMD echo $HOME\n",
+			want: []string{`CMD ["/bin/ash", "-eo", "pipefail", "-c", "echo $HOME"]`},
+		},
+		{
+			name: "exec form",
+			data: "FROM alpine\nCMD [\"/app\"]\n",
+		},
+		{
+			name: "instruction flags",
+			data: "FROM scratch\nENTRYPOINT [\"/app\"]\nCMD --port 8080\n",
+		},
+		{
+			name: "onbuild instruction",
+			data: "FROM alpine\nONBUILD CMD /app\n",
+		},
+	}
+
+	c := &EntrypointCmdShellForm{Info: Info{ID: "ID.20012"}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			df := testDockerfile(t, test.data)
+			match := testLastMatch(t, df, instruction.Entrypoint, instruction.Cmd)
+			fix, err := c.Fix(&Context{Dockerfile: df}, match)
+			if err != nil {
+				t.Fatalf("Fix() error = %v", err)
+			}
+
+			var got []string
+			if fix != nil {
+				got = fix.Lines
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Fix() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -3,12 +3,18 @@ package check
 
 import (
 	"fmt"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/spec"
 	"github.com/docker-slim/docker-slim/pkg/docker/instruction"
 )
 
+const maxParentStageDepth = 100
+
+var defaultShell = []string{"/bin/sh", "-c"}
+
 func init() {
 	check := &EntrypointCmdShellForm{
 		Info: Info{
@@ -76,3 +82,102 @@ func (c *EntrypointCmdShellForm) Run(opts *Options, ctx *Context) (*Result, erro
 
 	return result, nil
 }
+
+func (c *EntrypointCmdShellForm) Fix(ctx *Context, match *Match) (*Fix, error) {
+	inst := match.Instruction
+	if !isFixable(ctx.Dockerfile, inst) ||
+		match.Stage == nil ||
+		inst.IsJSONForm ||
+		len(inst.Flags) > 0 {
+		return nil, nil
+	}
+
+	//the plain exec form changes the runtime behavior if there's a CMD/ENTRYPOINT counterpart
+	//(the shell form ENTRYPOINT ignores CMD and the shell form CMD args are passed to ENTRYPOINT as-is)
+	//or if the shell is not the default shell ('/bin/sh -c'), so it's only used
+	//when the stage and its parent stages have no counterpart and no SHELL and the base image is 'scratch'
+	counterpart := instruction.Entrypoint
+	if inst.Name == instruction.Entrypoint {
+		counterpart = instruction.Cmd
+	}
+
+	args := ""
+	if hasNoStageInstructions(match.Stage, counterpart, instruction.Shell) {
+		args = execFormArgs(inst)
+	}
+
+	//otherwise the shell is used explicitly (the same exec form Docker creates for the shell form)
+	if args == "" {
+		args = shellExecFormArgs(match.Stage, inst)
+	}
+
+	if args == "" {
+		return nil, nil
+	}
+
+	fix := &Fix{
+		CheckID:   c.ID,
+		StartLine: inst.StartLine,
+		EndLine:   inst.EndLine,
+		Lines:     []string{instructionKeyword(inst) + " " + args},
+	}
+
+	return fix, nil
+}
+
+// hasNoStageInstructions checks that the stage and its parent stages don't have the selected instructions
+// (the base image instructions are unknown unless the base image is 'scratch')
+func hasNoStageInstructions(stage *spec.BuildStage, names ...string) bool {
+	for depth := 0; stage != nil && depth < maxParentStageDepth; depth++ {
+		for _, name := range names {
+			if len(stage.CurrentInstructionsByType[name]) > 0 {
+				return false
+			}
+		}
+
+		if stage.Parent.ParentStage == nil {
+			return stage.Parent.Name == "scratch"
+		}
+
+		stage = stage.Parent.ParentStage
+	}
+
+	return false
+}
+
+// shellExecFormArgs returns the exec/JSON form args that run the shell form instruction
+// with the shell from the last SHELL instruction before it (in the stage or its parent stages)
+// or with the default shell (empty if the SHELL instruction can't be used)
+func shellExecFormArgs(stage *spec.BuildStage, inst *instruction.Field) string {
+	cmd := strings.TrimSpace(inst.ArgsRaw)
+	if cmd == "" {
+		return ""
+	}
+
+	shell := defaultShell
+	before := inst
+	for depth := 0; stage != nil && depth < maxParentStageDepth; depth++ {
+		var shellInst *instruction.Field
+		for _, si := range stage.CurrentInstructionsByType[instruction.Shell] {
+			if before != nil && si.StageIndex > before.StageIndex {
+				break
+			}
+
+			shellInst = si
+		}
+
+		if shellInst != nil {
+			if !shellInst.IsJSONForm || len(shellInst.Args) == 0 {
+				return ""
+			}
+
+			shell = shellInst.Args
+			break
+		}
+
+		stage = stage.Parent.ParentStage
+		before = nil
+	}
+
+	return jsonArgs(append(append([]string{}, shell...), cmd))
+}
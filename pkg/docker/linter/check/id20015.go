@@ -3,6 +3,7 @@ package check
 
 import (
 	"fmt"
+	"path"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -90,3 +91,50 @@ func expandEnvVars(data string, vars map[string]string) string {
 
 	return data
 }
+
+func (c *RelativeWorkdir) Fix(ctx *Context, match *Match) (*Fix, error) {
+	inst := match.Instruction
+	stage := match.Stage
+	if !isFixable(ctx.Dockerfile, inst) ||
+		stage == nil ||
+		inst.ArgsRaw == "" ||
+		strings.HasPrefix(inst.ArgsRaw, "/") ||
+		strings.ContainsAny(inst.ArgsRaw, "$\"' \t") {
+		return nil, nil
+	}
+
+	//the base image WORKDIR is unknown (unless it's 'scratch'),
+	//so we need an absolute WORKDIR earlier in the stage
+	var workdir string
+	if stage.Parent.Name == "scratch" {
+		workdir = "/"
+	}
+
+	for _, wi := range stage.CurrentInstructionsByType[instruction.Workdir] {
+		if wi == inst {
+			break
+		}
+
+		switch {
+		case strings.Contains(wi.ArgsRaw, "$"):
+			return nil, nil
+		case strings.HasPrefix(wi.ArgsRaw, "/"):
+			workdir = wi.ArgsRaw
+		case workdir != "":
+			workdir = path.Join(workdir, wi.ArgsRaw)
+		}
+	}
+
+	if workdir == "" {
+		return nil, nil
+	}
+
+	fix := &Fix{
+		CheckID:   c.ID,
+		StartLine: inst.StartLine,
+		EndLine:   inst.EndLine,
+		Lines:     []string{instructionKeyword(inst) + " " + path.Join(workdir, inst.ArgsRaw)},
+	}
+
+	return fix, nil
+}
@@ -77,3 +77,7 @@ func (c *UnnecessaryLayer) Run(opts *Options, ctx *Context) (*Result, error) {
 
 	return result, nil
 }
+
+func (c *UnnecessaryLayer) Fix(ctx *Context, match *Match) (*Fix, error) {
+	return mergeRunFix(c.ID, ctx.Dockerfile, previousInstruction(match), match.Instruction), nil
+}
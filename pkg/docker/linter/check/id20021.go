@@ -87,3 +87,7 @@ func (c *SeparateRemove) Run(opts *Options, ctx *Context) (*Result, error) {
 
 	return result, nil
 }
+
+func (c *SeparateRemove) Fix(ctx *Context, match *Match) (*Fix, error) {
+	return mergeRunFix(c.ID, ctx.Dockerfile, previousInstruction(match), match.Instruction), nil
+}
@@ -0,0 +1,62 @@
+// Package check contains the linter checks
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func init() {
+	check := &ImageRootUser{
+		Info: Info{
+			ID:           "ID.30001",
+			Name:         "Image runs as root",
+			Description:  "Container image runs as root",
+			DetailsURL:   "https://lint.dockersl.im/check/ID.30001",
+			MainMessage:  "Container image runs as root",
+			MatchMessage: "Image: id=%s user='%s'",
+			Labels: map[string]string{
+				LabelLevel: LevelWarn,
+				LabelScope: ScopeImage,
+			},
+		},
+	}
+
+	AllChecks = append(AllChecks, check)
+}
+
+type ImageRootUser struct {
+	Info
+}
+
+func (c *ImageRootUser) Run(opts *Options, ctx *Context) (*Result, error) {
+	log.Debugf("linter.check[%s:'%s']", c.ID, c.Name)
+	result := &Result{
+		Source: &c.Info,
+	}
+
+	if ctx.Image == nil || ctx.Image.Config == nil {
+		return result, nil
+	}
+
+	//no USER means the container runs as root
+	user := strings.ToLower(strings.TrimSpace(ctx.Image.Config.User))
+	if user == "" ||
+		user == "0" ||
+		user == "root" ||
+		strings.HasPrefix(user, "0:") ||
+		strings.HasPrefix(user, "root:") {
+		result.Hit = true
+		result.Message = c.MainMessage
+
+		match := &Match{
+			Message: fmt.Sprintf(c.MatchMessage, ctx.Image.ID, ctx.Image.Config.User),
+		}
+
+		result.Matches = append(result.Matches, match)
+	}
+
+	return result, nil
+}
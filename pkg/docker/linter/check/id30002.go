@@ -0,0 +1,83 @@
+// Package check contains the linter checks
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func init() {
+	check := &ImageEnvSecrets{
+		Info: Info{
+			ID:           "ID.30002",
+			Name:         "Secrets in ENV",
+			Description:  "Possible secrets in image environment variables",
+			DetailsURL:   "https://lint.dockersl.im/check/ID.30002",
+			MainMessage:  "Possible secrets in image environment variables",
+			MatchMessage: "ENV: name=%s pattern=%s",
+			Labels: map[string]string{
+				LabelLevel: LevelError,
+				LabelScope: ScopeImage,
+			},
+		},
+		Patterns: []string{
+			"password",
+			"passwd",
+			"secret",
+			"token",
+			"api_key",
+			"apikey",
+			"access_key",
+			"private_key",
+			"credentials",
+		},
+	}
+
+	AllChecks = append(AllChecks, check)
+}
+
+type ImageEnvSecrets struct {
+	Info
+	Patterns []string
+}
+
+func (c *ImageEnvSecrets) Run(opts *Options, ctx *Context) (*Result, error) {
+	log.Debugf("linter.check[%s:'%s']", c.ID, c.Name)
+	result := &Result{
+		Source: &c.Info,
+	}
+
+	if ctx.Image == nil || ctx.Image.Config == nil {
+		return result, nil
+	}
+
+	for _, envInfo := range ctx.Image.Config.Env {
+		envParts := strings.SplitN(envInfo, "=", 2)
+		//the variables with empty values are ok (values are provided at runtime)
+		if len(envParts) != 2 || envParts[1] == "" {
+			continue
+		}
+
+		name := strings.ToLower(envParts[0])
+		for _, pattern := range c.Patterns {
+			if strings.Contains(name, pattern) {
+				if !result.Hit {
+					result.Hit = true
+					result.Message = c.MainMessage
+				}
+
+				//not including the value to avoid leaking the secret in the report
+				match := &Match{
+					Message: fmt.Sprintf(c.MatchMessage, envParts[0], pattern),
+				}
+
+				result.Matches = append(result.Matches, match)
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
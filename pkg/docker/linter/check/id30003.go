@@ -0,0 +1,71 @@
+// Package check contains the linter checks
+package check
+
+import (
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	maxLayerSize = 100 * 1024 * 1024
+)
+
+func init() {
+	check := &ImageHugeLayers{
+		Info: Info{
+			ID:           "ID.30003",
+			Name:         "Huge image layers",
+			Description:  "Huge image layers",
+			DetailsURL:   "https://lint.dockersl.im/check/ID.30003",
+			MainMessage:  "Huge image layers",
+			MatchMessage: "Layer: instruction=%d size=%s command='%s'",
+			Labels: map[string]string{
+				LabelLevel: LevelWarn,
+				LabelScope: ScopeImage,
+			},
+		},
+		MaxSize: maxLayerSize,
+	}
+
+	AllChecks = append(AllChecks, check)
+}
+
+type ImageHugeLayers struct {
+	Info
+	MaxSize int64
+}
+
+func (c *ImageHugeLayers) Run(opts *Options, ctx *Context) (*Result, error) {
+	log.Debugf("linter.check[%s:'%s']", c.ID, c.Name)
+	result := &Result{
+		Source: &c.Info,
+	}
+
+	if ctx.Image == nil || ctx.Image.History == nil {
+		return result, nil
+	}
+
+	for idx, inst := range ctx.Image.History.AllInstructions {
+		if inst.Size <= c.MaxSize {
+			continue
+		}
+
+		if !result.Hit {
+			result.Hit = true
+			result.Message = c.MainMessage
+		}
+
+		match := &Match{
+			Message: fmt.Sprintf(c.MatchMessage,
+				idx,
+				humanize.Bytes(uint64(inst.Size)),
+				inst.CommandSnippet),
+		}
+
+		result.Matches = append(result.Matches, match)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,233 @@
+// Package check contains the linter checks
+package check
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/spec"
+	"github.com/docker-slim/docker-slim/pkg/docker/instruction"
+)
+
+var (
+	ErrBadPolicyRule = errors.New("bad policy rule")
+)
+
+// Policy rule stage scope values
+const (
+	StageScopeAll  = "all"
+	StageScopeLast = "last"
+)
+
+const (
+	policyMatchMessage = "Instruction: start=%d end=%d global_index=%d stage_id=%d stage_index=%d"
+)
+
+// PolicyRules is the policy rules file structure (YAML or JSON)
+type PolicyRules struct {
+	Rules []*PolicyRule `json:"rules"`
+}
+
+// PolicyRule describes a user-defined instruction check
+type PolicyRule struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	DetailsURL  string            `json:"details_url,omitempty"`
+	Level       string            `json:"level,omitempty"`       //default: warn
+	Labels      map[string]string `json:"labels,omitempty"`      //extra check labels
+	Instruction string            `json:"instruction"`           //instruction name (e.g., "from")
+	Match       string            `json:"match,omitempty"`       //regex for the instruction args (any args, if empty)
+	Negate      bool              `json:"negate,omitempty"`      //hit when the instruction args don't match
+	StageScope  string            `json:"stage_scope,omitempty"` //"all" (default) or "last"
+}
+
+// PolicyCheck is a regex-based check created from a policy rule
+type PolicyCheck struct {
+	Info
+	Instruction string
+	Matcher     *regexp.Regexp
+	Negate      bool
+	StageScope  string
+}
+
+// LoadPolicyChecks creates the policy checks from the rules file (YAML or JSON)
+func LoadPolicyChecks(filePath string) ([]Runner, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules PolicyRules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		log.Errorf("check.LoadPolicyChecks: error decoding rules file(%v) - %v", filePath, err)
+		return nil, err
+	}
+
+	var checks []Runner
+	ids := map[string]struct{}{}
+	for _, check := range AllChecks {
+		ids[check.Get().ID] = struct{}{}
+	}
+
+	for idx, rule := range rules.Rules {
+		check, err := NewPolicyCheck(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule[%d]: %v", idx, err)
+		}
+
+		if _, ok := ids[check.ID]; ok {
+			return nil, fmt.Errorf("rule[%d]: %v - duplicate check ID (%s)", idx, ErrBadPolicyRule, check.ID)
+		}
+
+		ids[check.ID] = struct{}{}
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+// NewPolicyCheck creates a new policy check from the rule
+func NewPolicyCheck(rule *PolicyRule) (*PolicyCheck, error) {
+	if rule == nil || rule.ID == "" {
+		return nil, fmt.Errorf("%v - missing id", ErrBadPolicyRule)
+	}
+
+	instName := strings.ToLower(rule.Instruction)
+	if !instruction.IsKnown(instName) {
+		return nil, fmt.Errorf("%v - unknown instruction (%s)", ErrBadPolicyRule, rule.Instruction)
+	}
+
+	level := rule.Level
+	switch level {
+	case "":
+		level = LevelWarn
+	case LevelFatal, LevelError, LevelWarn, LevelInfo, LevelStyle:
+	default:
+		return nil, fmt.Errorf("%v - unknown level (%s)", ErrBadPolicyRule, rule.Level)
+	}
+
+	stageScope := rule.StageScope
+	switch stageScope {
+	case "":
+		stageScope = StageScopeAll
+	case StageScopeAll, StageScopeLast:
+	default:
+		return nil, fmt.Errorf("%v - unknown stage scope (%s)", ErrBadPolicyRule, rule.StageScope)
+	}
+
+	var matcher *regexp.Regexp
+	if rule.Match != "" {
+		var err error
+		matcher, err = regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("%v - bad match regex (%v)", ErrBadPolicyRule, err)
+		}
+	}
+
+	name := rule.Name
+	if name == "" {
+		name = rule.ID
+	}
+
+	description := rule.Description
+	if description == "" {
+		description = name
+	}
+
+	message := rule.Message
+	if message == "" {
+		message = name
+	}
+
+	labels := map[string]string{}
+	for k, v := range rule.Labels {
+		labels[k] = v
+	}
+
+	labels[LabelLevel] = level
+	labels[LabelScope] = ScopeStage
+	labels[LabelInstruction] = instName
+
+	check := &PolicyCheck{
+		Info: Info{
+			ID:           rule.ID,
+			Name:         name,
+			Description:  description,
+			DetailsURL:   rule.DetailsURL,
+			MainMessage:  message,
+			MatchMessage: policyMatchMessage,
+			Labels:       labels,
+		},
+		Instruction: instName,
+		Matcher:     matcher,
+		Negate:      rule.Negate,
+		StageScope:  stageScope,
+	}
+
+	return check, nil
+}
+
+func (c *PolicyCheck) Run(opts *Options, ctx *Context) (*Result, error) {
+	log.Debugf("linter.check[%s:'%s']", c.ID, c.Name)
+	result := &Result{
+		Source: &c.Info,
+	}
+
+	if ctx.Dockerfile == nil {
+		return result, nil
+	}
+
+	stages := ctx.Dockerfile.Stages
+	if c.StageScope == StageScopeLast && len(stages) > 0 {
+		stages = stages[len(stages)-1:]
+	}
+
+	for _, stage := range stages {
+		for _, inst := range stage.AllInstructions {
+			if inst.Name != c.Instruction || !c.isMatch(stage, inst) {
+				continue
+			}
+
+			if !result.Hit {
+				result.Hit = true
+				result.Message = c.MainMessage
+			}
+
+			match := &Match{
+				Stage:       stage,
+				Instruction: inst,
+				Message: fmt.Sprintf(c.MatchMessage,
+					inst.StartLine,
+					inst.EndLine,
+					inst.GlobalIndex,
+					inst.StageID,
+					inst.StageIndex),
+			}
+
+			result.Matches = append(result.Matches, match)
+		}
+	}
+
+	return result, nil
+}
+
+func (c *PolicyCheck) isMatch(stage *spec.BuildStage, inst *instruction.Field) bool {
+	//FROM instructions referencing the previous build stages are not checked
+	if inst.Name == instruction.From && stage.Parent.ParentStage != nil {
+		return false
+	}
+
+	if c.Matcher == nil {
+		return !c.Negate
+	}
+
+	return c.Matcher.MatchString(inst.ArgsRaw) != c.Negate
+}
@@ -0,0 +1,258 @@
+package linter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
+)
+
+const (
+	diffContextLines = 3
+)
+
+// FixReport contains the Dockerfile fixes for the lint check hits
+type FixReport struct {
+	Applied []*check.Fix //non-overlapping fixes (ordered by line)
+	Skipped []*check.Fix //fixes overlapping with the applied fixes
+	Lines   []string     //fixed Dockerfile lines
+	Diff    string       //unified diff between the original and the fixed Dockerfile
+}
+
+// Fix creates the Dockerfile fixes for the check hits in the lint report
+// (only the checks that implement check.Fixer provide the fixes)
+func Fix(report *Report, extraChecks ...check.Runner) (*FixReport, error) {
+	if report == nil || report.Dockerfile == nil {
+		return nil, ErrBadParams
+	}
+
+	df := report.Dockerfile
+	ctx := &check.Context{
+		Dockerfile:      df,
+		BuildContextDir: report.BuildContextDir,
+		Dockerignore:    report.Dockerignore,
+	}
+
+	fixers := map[string]check.Fixer{}
+	allChecks := append(append([]check.Runner{}, check.AllChecks...), extraChecks...)
+	for _, c := range allChecks {
+		if fixer, ok := c.(check.Fixer); ok {
+			fixers[c.Get().ID] = fixer
+		}
+	}
+
+	var ids []string
+	for id := range report.Hits {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	var fixes []*check.Fix
+	seen := map[int]struct{}{}
+	for _, id := range ids {
+		fixer, ok := fixers[id]
+		if !ok {
+			continue
+		}
+
+		for _, m := range report.Hits[id].Matches {
+			fix, err := fixer.Fix(ctx, m)
+			if err != nil {
+				log.Debugf("linter.Fix: check(%v) fix error - %v", id, err)
+				return nil, err
+			}
+
+			if fix == nil {
+				continue
+			}
+
+			//some checks report the same instruction more than once
+			if _, ok := seen[fix.StartLine]; ok {
+				continue
+			}
+
+			seen[fix.StartLine] = struct{}{}
+			fixes = append(fixes, fix)
+		}
+	}
+
+	sort.SliceStable(fixes, func(i, j int) bool {
+		return fixes[i].StartLine < fixes[j].StartLine
+	})
+
+	fixReport := &FixReport{}
+	lastLine := 0
+	for _, fix := range fixes {
+		if fix.StartLine <= lastLine {
+			fixReport.Skipped = append(fixReport.Skipped, fix)
+			continue
+		}
+
+		fixReport.Applied = append(fixReport.Applied, fix)
+		lastLine = fix.EndLine
+	}
+
+	nextLine := 1
+	for _, fix := range fixReport.Applied {
+		fixReport.Lines = append(fixReport.Lines, df.Lines[nextLine-1:fix.StartLine-1]...)
+		fixReport.Lines = append(fixReport.Lines, fix.Lines...)
+		nextLine = fix.EndLine + 1
+	}
+
+	if nextLine <= len(df.Lines) {
+		fixReport.Lines = append(fixReport.Lines, df.Lines[nextLine-1:]...)
+	}
+
+	if len(fixReport.Applied) > 0 {
+		fixReport.Diff = unifiedDiff(df.Name, df.Name, df.Lines, fixReport.Lines)
+	}
+
+	return fixReport, nil
+}
+
+type diffOp struct {
+	Kind byte //' ', '-' or '+'
+	Line string
+	A    int //line index in the original lines
+	B    int //line index in the new lines
+}
+
+// unifiedDiff creates a unified diff for the two sets of lines (LCS-based)
+func unifiedDiff(fromName, toName string, a, b []string) string {
+	ops := diffOps(a, b)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", fromName, toName)
+
+	for idx := 0; idx < len(ops); {
+		if ops[idx].Kind == ' ' {
+			idx++
+			continue
+		}
+
+		start := idx - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+
+		//extend the hunk while the next change is within the context range
+		end := idx
+		for end < len(ops) {
+			if ops[end].Kind != ' ' {
+				end++
+				continue
+			}
+
+			next := end
+			for next < len(ops) && ops[next].Kind == ' ' {
+				next++
+			}
+
+			if next == len(ops) || next-end > 2*diffContextLines {
+				end += diffContextLines
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+
+			end = next
+		}
+
+		hunk := ops[start:end]
+		aStart, aCount, bStart, bCount := hunkRange(hunk)
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, op := range hunk {
+			fmt.Fprintf(&out, "%c%s\n", op.Kind, op.Line)
+		}
+
+		idx = end
+	}
+
+	return out.String()
+}
+
+func hunkRange(hunk []diffOp) (int, int, int, int) {
+	aStart, bStart := -1, -1
+	var aCount, bCount int
+	for _, op := range hunk {
+		if op.Kind != '+' {
+			if aStart < 0 {
+				aStart = op.A
+			}
+			aCount++
+		}
+
+		if op.Kind != '-' {
+			if bStart < 0 {
+				bStart = op.B
+			}
+			bCount++
+		}
+	}
+
+	//the line numbers are 1-based (and 0 for empty ranges)
+	if aStart < 0 {
+		aStart = hunk[0].A
+	} else {
+		aStart++
+	}
+
+	if bStart < 0 {
+		bStart = hunk[0].B
+	} else {
+		bStart++
+	}
+
+	return aStart, aCount, bStart, bCount
+}
+
+func diffOps(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{Kind: ' ', Line: a[i], A: i, B: j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{Kind: '-', Line: a[i], A: i, B: j})
+			i++
+		default:
+			ops = append(ops, diffOp{Kind: '+', Line: b[j], A: i, B: j})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{Kind: '-', Line: a[i], A: i, B: j})
+	}
+
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{Kind: '+', Line: b[j], A: i, B: j})
+	}
+
+	return ops
+}
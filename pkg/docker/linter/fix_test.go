@@ -0,0 +1,143 @@
+package linter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/parser"
+	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
+)
+
+func TestFix(t *testing.T) {
+	data := `FROM alpine
+RUN apk update
+RUN apk add curl
+RUN apk add wget
+CMD node server.js
+`
+
+	df, err := parser.FromReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parser.FromReader() error = %v", err)
+	}
+
+	df.Name = "Dockerfile"
+	report := NewReport()
+	report.Dockerfile = df
+
+	//ID.20019 matches both RUN instructions after the first one (the merge fixes overlap)
+	ctx := &check.Context{Dockerfile: df}
+	for _, c := range check.AllChecks {
+		if id := c.Get().ID; id == "ID.20019" || id == "ID.20012" {
+			result, err := c.Run(&check.Options{}, ctx)
+			if err != nil {
+				t.Fatalf("check(%s).Run() error = %v", id, err)
+			}
+
+			if result.Hit {
+				report.Hits[id] = result
+			}
+		}
+	}
+
+	fixReport, err := Fix(report)
+	if err != nil {
+		t.Fatalf("Fix() error = %v", err)
+	}
+
+	wantLines := []string{
+		"FROM alpine",
+		"RUN apk update && \\",
+		"\tapk add curl",
+		"RUN apk add wget",
+		`CMD ["/bin/sh", "-c", "node server.js"]`,
+	}
+
+	if !reflect.DeepEqual(fixReport.Lines, wantLines) {
+		t.Errorf("Fix() lines = %q, want %q", fixReport.Lines, wantLines)
+	}
+
+	if len(fixReport.Applied) != 2 {
+		t.Errorf("Fix() applied = %d, want 2", len(fixReport.Applied))
+	}
+
+	if len(fixReport.Skipped) != 1 ||
+		fixReport.Skipped[0].StartLine != 3 ||
+		fixReport.Skipped[0].EndLine != 4 {
+		t.Errorf("Fix() skipped = %+v, want the 3-4 fix", fixReport.Skipped)
+	}
+
+	if !strings.HasPrefix(fixReport.Diff, "--- a/Dockerfile\n+++ b/Dockerfile\n@@ -1,5 +1,5 @@\n") {
+		t.Errorf("Fix() diff =\n%s", fixReport.Diff)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	const header = "--- a/Dockerfile\n+++ b/Dockerfile\n"
+
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want string
+	}{
+		{
+			name: "no changes",
+			a:    []string{"l1", "l2"},
+			b:    []string{"l1", "l2"},
+			want: header,
+		},
+		{
+			name: "changed line with context",
+			a:    []string{"l1", "l2", "l3", "l4", "l5", "l6", "l7"},
+			b:    []string{"l1", "l2", "l3", "L4", "l5", "l6", "l7"},
+			want: header +
+				"@@ -1,7 +1,7 @@\n l1\n l2\n l3\n-l4\n+L4\n l5\n l6\n l7\n",
+		},
+		{
+			name: "changed first line",
+			a:    []string{"l1", "l2", "l3"},
+			b:    []string{"X1", "l2", "l3"},
+			want: header +
+				"@@ -1,3 +1,3 @@\n-l1\n+X1\n l2\n l3\n",
+		},
+		{
+			name: "separate hunks",
+			a:    []string{"l1", "l2", "l3", "l4", "l5", "l6", "l7", "l8", "l9", "l10", "l11", "l12", "l13", "l14"},
+			b:    []string{"L1", "l2", "l3", "l4", "l5", "l6", "l7", "l8", "l9", "l10", "l11", "l12", "l13", "L14"},
+			want: header +
+				"@@ -1,4 +1,4 @@\n-l1\n+L1\n l2\n l3\n l4\n" +
+				"@@ -11,4 +11,4 @@\n l11\n l12\n l13\n-l14\n+L14\n",
+		},
+		{
+			name: "merged hunks",
+			a:    []string{"l1", "l2", "l3", "l4", "l5", "l6", "l7", "l8"},
+			b:    []string{"L1", "l2", "l3", "l4", "l5", "l6", "l7", "L8"},
+			want: header +
+				"@@ -1,8 +1,8 @@\n-l1\n+L1\n l2\n l3\n l4\n l5\n l6\n l7\n-l8\n+L8\n",
+		},
+		{
+			name: "added line",
+			a:    []string{"l1", "l2"},
+			b:    []string{"l1", "new", "l2"},
+			want: header +
+				"@@ -1,2 +1,3 @@\n l1\n+new\n l2\n",
+		},
+		{
+			name: "removed line",
+			a:    []string{"l1", "l2", "l3"},
+			b:    []string{"l1", "l3"},
+			want: header +
+				"@@ -1,3 +1,2 @@\n l1\n-l2\n l3\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := unifiedDiff("Dockerfile", "Dockerfile", test.a, test.b); diff != test.want {
+				t.Errorf("unifiedDiff() =\n%s\nwant:\n%s", diff, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package linter
+
+import (
+	"strings"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/parser"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/reverse"
+	"github.com/docker-slim/docker-slim/pkg/docker/dockerfile/spec"
+	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
+)
+
+const (
+	instFromScratch   = "FROM scratch"
+	instTypeFrom      = "FROM"
+	instTypeHealthchk = "HEALTHCHECK"
+)
+
+// DockerfileFromImage creates a Dockerfile spec from the reverse engineered image instructions
+func DockerfileFromImage(imageInfo *check.ImageInfo) (*spec.Dockerfile, error) {
+	if imageInfo == nil || imageInfo.History == nil {
+		return nil, ErrBadParams
+	}
+
+	lines := imageInstructionLines(imageInfo.History)
+	df, err := parser.FromReader(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		return nil, err
+	}
+
+	df.Name = imageInfo.Name
+	return df, nil
+}
+
+func imageInstructionLines(history *reverse.Dockerfile) []string {
+	//using the last known base image (if it's available locally),
+	//so only the target image instructions are linted
+	fromInst := instFromScratch
+	instructions := history.AllInstructions
+	baseInstCount := 0
+	for _, info := range history.ImageStack {
+		if info.IsTopImage {
+			break
+		}
+
+		baseInstCount += len(info.Instructions)
+		if info.FullName != "" && baseInstCount < len(history.AllInstructions) {
+			fromInst = instTypeFrom + " " + info.FullName
+			instructions = history.AllInstructions[baseInstCount:]
+		}
+	}
+
+	lines := []string{fromInst}
+	for _, inst := range instructions {
+		switch inst.Type {
+		case "", instTypeFrom:
+			continue
+		case instTypeHealthchk:
+			//the HEALTHCHECK instructions are not restored yet
+			continue
+		}
+
+		lines = append(lines, inst.CommandAll)
+	}
+
+	return lines
+}
@@ -1,8 +1,9 @@
-// Package linter implements a Dockerfile linter
+// Package linter implements a Dockerfile (and container image) linter
 package linter
 
 import (
 	"errors"
+	"strings"
 	"sync"
 	"time"
 
@@ -36,6 +37,8 @@ type Options struct {
 	Dockerignore     *dockerignore.Matcher
 	Selector         CheckSelector
 	Config           map[string]*check.Options
+	Image            *check.ImageInfo //set for the image targets (instead of the Dockerfile info)
+	ExtraChecks      []check.Runner   //user-defined checks (e.g., policy checks)
 }
 
 type CheckContext struct {
@@ -66,17 +69,20 @@ type Report struct {
 	BuildContextDir string
 	Dockerfile      *spec.Dockerfile
 	Dockerignore    *dockerignore.Matcher
+	TargetType      string
 	Hits            map[string]*check.Result
 	NoHits          map[string]*check.Result
+	Suppressed      map[string]*check.Result //check results with the matches suppressed by the ignore comments
 	Errors          map[string]error
 }
 
 func NewReport() *Report {
 	return &Report{
-		Status: StatusUnknown,
-		Hits:   map[string]*check.Result{},
-		NoHits: map[string]*check.Result{},
-		Errors: map[string]error{},
+		Status:     StatusUnknown,
+		Hits:       map[string]*check.Result{},
+		NoHits:     map[string]*check.Result{},
+		Suppressed: map[string]*check.Result{},
+		Errors:     map[string]error{},
 	}
 }
 
@@ -89,14 +95,26 @@ type CheckState struct {
 }
 
 func Execute(options Options) (*Report, error) {
+	targetType := DockerfileTargetType
+	if options.Image != nil {
+		targetType = ImageTargetType
+		//no build context or .dockerignore for the image targets
+		options.SkipBuildContext = true
+		options.SkipDockerignore = true
+	}
+
 	df := options.Dockerfile
 	if df == nil {
-		if options.DockerfilePath == "" {
+		var err error
+		switch {
+		case options.Image != nil:
+			df, err = DockerfileFromImage(options.Image)
+		case options.DockerfilePath != "":
+			df, err = parser.FromFile(options.DockerfilePath)
+		default:
 			return nil, ErrBadParams
 		}
 
-		var err error
-		df, err = parser.FromFile(options.DockerfilePath)
 		if err != nil {
 			return nil, err
 		}
@@ -126,11 +144,18 @@ func Execute(options Options) (*Report, error) {
 	report.BuildContextDir = options.BuildContextDir
 	report.Dockerfile = df
 	report.Dockerignore = di
+	report.TargetType = targetType
 
 	var selectedChecks []check.Runner
-	for _, check := range check.AllChecks {
+	allChecks := append(append([]check.Runner{}, check.AllChecks...), options.ExtraChecks...)
+	for _, check := range allChecks {
 		info := check.Get()
 
+		if !isApplicableCheck(info, targetType) {
+			log.Debugf("linter.Execute: skipping check - id=%v target.type=%v", info.ID, targetType)
+			continue
+		}
+
 		if len(options.Selector.IncludeCheckIDs) > 0 {
 			if _, ok := options.Selector.IncludeCheckIDs[info.ID]; ok {
 				selectedChecks = append(selectedChecks, check)
@@ -187,6 +212,7 @@ func Execute(options Options) (*Report, error) {
 		Dockerfile:      df,
 		BuildContextDir: options.BuildContextDir,
 		Dockerignore:    di,
+		Image:           options.Image,
 	}
 
 	stateCh := make(chan *CheckState, len(selectedChecks))
@@ -236,7 +262,14 @@ done:
 					report.Errors[info.ID] = checkState.Error
 				} else {
 					if checkState.Result.Hit {
-						report.Hits[info.ID] = checkState.Result
+						result, suppressed := splitSuppressedMatches(checkState.Result)
+						if suppressed != nil {
+							report.Suppressed[info.ID] = suppressed
+						}
+
+						if result != nil {
+							report.Hits[info.ID] = result
+						}
 					} else {
 						report.NoHits[info.ID] = checkState.Result
 					}
@@ -251,12 +284,87 @@ done:
 	return report, nil
 }
 
-func ListChecks() []*check.Info {
+// splitSuppressedMatches separates the check result matches suppressed by the ignore comments
+// (returns nil for the result if all matches are suppressed and nil for the suppressed result if nothing is suppressed)
+func splitSuppressedMatches(result *check.Result) (*check.Result, *check.Result) {
+	var matches []*check.Match
+	var suppressedMatches []*check.Match
+	for _, m := range result.Matches {
+		if isSuppressed(result.Source.ID, m) {
+			suppressedMatches = append(suppressedMatches, m)
+		} else {
+			matches = append(matches, m)
+		}
+	}
+
+	if len(suppressedMatches) == 0 {
+		return result, nil
+	}
+
+	suppressed := *result
+	suppressed.Matches = suppressedMatches
+	if len(matches) == 0 {
+		return nil, &suppressed
+	}
+
+	remaining := *result
+	remaining.Matches = matches
+	return &remaining, &suppressed
+}
+
+// isSuppressed returns true if the match instruction or its stage (FROM instruction)
+// has an ignore comment for the check
+func isSuppressed(checkID string, m *check.Match) bool {
+	if m.Instruction != nil && ignoresCheck(m.Instruction.IgnoredChecks, checkID) {
+		return true
+	}
+
+	if m.Stage != nil &&
+		m.Stage.FromInstruction != nil &&
+		ignoresCheck(m.Stage.FromInstruction.IgnoredChecks, checkID) {
+		return true
+	}
+
+	return false
+}
+
+func ignoresCheck(ignoredChecks []string, checkID string) bool {
+	id := normalizeCheckID(checkID)
+	for _, ignoredID := range ignoredChecks {
+		if ignoredID == parser.IgnoreAllChecks || normalizeCheckID(ignoredID) == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeCheckID makes it possible to use check IDs without the dot (e.g., "ID20012")
+func normalizeCheckID(id string) string {
+	return strings.ToUpper(strings.Replace(id, ".", "", -1))
+}
+
+func isApplicableCheck(info *check.Info, targetType string) bool {
+	switch info.Labels[check.LabelScope] {
+	case check.ScopeImage:
+		return targetType == ImageTargetType
+	case check.ScopeDockerignore:
+		return targetType == DockerfileTargetType
+	}
+
+	return true
+}
+
+func ListChecks(extraChecks ...check.Runner) []*check.Info {
 	var list []*check.Info
 	for _, check := range check.AllChecks {
 		info := check.Get()
 		list = append(list, info)
 	}
 
+	for _, check := range extraChecks {
+		list = append(list, check.Get())
+	}
+
 	return list
 }
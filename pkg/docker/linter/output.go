@@ -0,0 +1,445 @@
+package linter
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/docker-slim/docker-slim/pkg/docker/linter/check"
+	v "github.com/docker-slim/docker-slim/pkg/version"
+)
+
+var (
+	ErrUnknownOutputFormat = errors.New("unknown output format")
+)
+
+// Supported lint result output formats
+const (
+	OutputFormatSARIF      = "sarif"
+	OutputFormatJUnit      = "junit"
+	OutputFormatCheckstyle = "checkstyle"
+)
+
+const (
+	toolName           = "docker-slim"
+	toolInfoURI        = "https://dockersl.im"
+	sarifSchemaURI     = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion       = "2.1.0"
+	sarifLevelError    = "error"
+	sarifLevelWarning  = "warning"
+	sarifLevelNote     = "note"
+	checkstyleVersion  = "8.0"
+	checkstyleSevError = "error"
+	checkstyleSevWarn  = "warning"
+	checkstyleSevInfo  = "info"
+	junitSuiteName     = "docker-slim.lint"
+	junitFailureType   = "lint.check.hit"
+	junitErrorType     = "lint.check.error"
+	unknownMatchLine   = 0
+	outputFilePrefix   = "lint-results"
+	xmlOutputExtension = "xml"
+)
+
+// OutputFormats returns the list of the supported output formats
+func OutputFormats() []string {
+	return []string{
+		OutputFormatSARIF,
+		OutputFormatJUnit,
+		OutputFormatCheckstyle,
+	}
+}
+
+// IsOutputFormat returns true if the output format is supported
+func IsOutputFormat(format string) bool {
+	for _, name := range OutputFormats() {
+		if name == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OutputFileName returns the default output file name for the output format
+func OutputFileName(format string) string {
+	switch format {
+	case OutputFormatSARIF:
+		return fmt.Sprintf("%s.%s", outputFilePrefix, OutputFormatSARIF)
+	case OutputFormatCheckstyle:
+		return fmt.Sprintf("%s.%s.%s", outputFilePrefix, OutputFormatCheckstyle, xmlOutputExtension)
+	default:
+		return fmt.Sprintf("%s.%s", outputFilePrefix, xmlOutputExtension)
+	}
+}
+
+// FormatReport renders the lint results in the selected output format
+func FormatReport(report *Report, format string, targetRef string) ([]byte, error) {
+	switch format {
+	case OutputFormatSARIF:
+		return formatSARIF(report, targetRef)
+	case OutputFormatJUnit:
+		return formatJUnit(report, targetRef)
+	case OutputFormatCheckstyle:
+		return formatCheckstyle(report, targetRef)
+	default:
+		return nil, ErrUnknownOutputFormat
+	}
+}
+
+type matchLocation struct {
+	StartLine int
+	EndLine   int
+	Message   string
+}
+
+// resultLocations returns the match locations for the check result
+// (the results without matches get one location with the main result message)
+func resultLocations(result *check.Result) []matchLocation {
+	var locations []matchLocation
+	for _, m := range result.Matches {
+		location := matchLocation{
+			StartLine: unknownMatchLine,
+			EndLine:   unknownMatchLine,
+			Message:   m.Message,
+		}
+
+		switch {
+		case m.Instruction != nil && m.Instruction.StartLine > 0:
+			location.StartLine = m.Instruction.StartLine
+			location.EndLine = m.Instruction.EndLine
+		case m.Stage != nil && m.Stage.StartLine > 0:
+			location.StartLine = m.Stage.StartLine
+			location.EndLine = m.Stage.EndLine
+		}
+
+		if location.Message == "" {
+			location.Message = result.Message
+		}
+
+		locations = append(locations, location)
+	}
+
+	if len(locations) == 0 {
+		locations = append(locations, matchLocation{
+			StartLine: unknownMatchLine,
+			EndLine:   unknownMatchLine,
+			Message:   result.Message,
+		})
+	}
+
+	return locations
+}
+
+func sortedResultIDs(results map[string]*check.Result) []string {
+	var ids []string
+	for id := range results {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+func sortedErrorIDs(errs map[string]error) []string {
+	var ids []string
+	for id := range errs {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+//SARIF data structures (subset of the SARIF 2.1.0 spec)
+//https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name,omitempty"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	HelpURI              string             `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+func sarifLevel(level string) string {
+	switch level {
+	case check.LevelFatal, check.LevelError:
+		return sarifLevelError
+	case check.LevelWarn:
+		return sarifLevelWarning
+	default:
+		return sarifLevelNote
+	}
+}
+
+func formatSARIF(report *Report, targetRef string) ([]byte, error) {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           toolName,
+				Version:        v.Tag(),
+				InformationURI: toolInfoURI,
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	targetURI := filepath.ToSlash(targetRef)
+	for _, id := range sortedResultIDs(report.Hits) {
+		result := report.Hits[id]
+		level := sarifLevel(result.Source.Labels[check.LabelLevel])
+		ruleIndex := len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   id,
+			Name:                 result.Source.Name,
+			ShortDescription:     sarifMessage{Text: result.Source.Description},
+			HelpURI:              result.Source.DetailsURL,
+			DefaultConfiguration: sarifConfiguration{Level: level},
+		})
+
+		for _, location := range resultLocations(result) {
+			physicalLocation := sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: targetURI},
+			}
+
+			if location.StartLine > 0 {
+				physicalLocation.Region = &sarifRegion{
+					StartLine: location.StartLine,
+					EndLine:   location.EndLine,
+				}
+			}
+
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    id,
+				RuleIndex: ruleIndex,
+				Level:     level,
+				Message:   sarifMessage{Text: location.Message},
+				Locations: []sarifLocation{{PhysicalLocation: physicalLocation}},
+			})
+		}
+	}
+
+	output := sarifLog{
+		Schema:  sarifSchemaURI,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}
+
+	return json.MarshalIndent(&output, "", "  ")
+}
+
+//JUnit XML data structures (each selected check is a test case)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Details string `xml:",chardata"`
+}
+
+func formatJUnit(report *Report, targetRef string) ([]byte, error) {
+	suite := junitTestSuite{
+		Name: junitSuiteName,
+	}
+
+	for _, id := range sortedResultIDs(report.Hits) {
+		result := report.Hits[id]
+		var details string
+		for _, location := range resultLocations(result) {
+			if location.StartLine > 0 {
+				details += fmt.Sprintf("%s:%d: %s\n", targetRef, location.StartLine, location.Message)
+			} else {
+				details += fmt.Sprintf("%s: %s\n", targetRef, location.Message)
+			}
+		}
+
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      fmt.Sprintf("%s %s", id, result.Source.Name),
+			ClassName: targetRef,
+			Failure: &junitProblem{
+				Message: result.Message,
+				Type:    junitFailureType,
+				Details: details,
+			},
+		})
+		suite.Failures++
+	}
+
+	for _, id := range sortedResultIDs(report.NoHits) {
+		result := report.NoHits[id]
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      fmt.Sprintf("%s %s", id, result.Source.Name),
+			ClassName: targetRef,
+		})
+	}
+
+	for _, id := range sortedErrorIDs(report.Errors) {
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      id,
+			ClassName: targetRef,
+			Error: &junitProblem{
+				Message: report.Errors[id].Error(),
+				Type:    junitErrorType,
+			},
+		})
+		suite.Errors++
+	}
+
+	suite.Tests = len(suite.TestCases)
+	output := junitTestSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	return marshalXML(&output)
+}
+
+//Checkstyle XML data structures
+
+type checkstyleOutput struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+func checkstyleSeverity(level string) string {
+	switch level {
+	case check.LevelFatal, check.LevelError:
+		return checkstyleSevError
+	case check.LevelWarn:
+		return checkstyleSevWarn
+	default:
+		return checkstyleSevInfo
+	}
+}
+
+func formatCheckstyle(report *Report, targetRef string) ([]byte, error) {
+	file := checkstyleFile{
+		Name: targetRef,
+	}
+
+	for _, id := range sortedResultIDs(report.Hits) {
+		result := report.Hits[id]
+		severity := checkstyleSeverity(result.Source.Labels[check.LabelLevel])
+		for _, location := range resultLocations(result) {
+			file.Errors = append(file.Errors, checkstyleError{
+				Line:     location.StartLine,
+				Severity: severity,
+				Message:  location.Message,
+				Source:   id,
+			})
+		}
+	}
+
+	output := checkstyleOutput{
+		Version: checkstyleVersion,
+		Files:   []checkstyleFile{file},
+	}
+
+	return marshalXML(&output)
+}
+
+func marshalXML(data interface{}) ([]byte, error) {
+	raw, err := xml.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), raw...), nil
+}
@@ -54,19 +54,24 @@ type SystemMetadata struct {
 // BuildCommand is the 'build' command report data
 type BuildCommand struct {
 	Command
-	TargetReference        string               `json:"target_reference"`
-	System                 SystemMetadata       `json:"system"`
-	SourceImage            ImageMetadata        `json:"source_image"`
-	MinifiedImageSize      int64                `json:"minified_image_size"`
-	MinifiedImageSizeHuman string               `json:"minified_image_size_human"`
-	MinifiedImage          string               `json:"minified_image"`
-	MinifiedImageHasData   bool                 `json:"minified_image_has_data"`
-	MinifiedBy             float64              `json:"minified_by"`
-	ArtifactLocation       string               `json:"artifact_location"`
-	ContainerReportName    string               `json:"container_report_name"`
-	SeccompProfileName     string               `json:"seccomp_profile_name"`
-	AppArmorProfileName    string               `json:"apparmor_profile_name"`
-	ImageStack             []*reverse.ImageInfo `json:"image_stack"`
+	TargetReference        string                     `json:"target_reference"`
+	System                 SystemMetadata             `json:"system"`
+	SourceImage            ImageMetadata              `json:"source_image"`
+	MinifiedImageSize      int64                      `json:"minified_image_size"`
+	MinifiedImageSizeHuman string                     `json:"minified_image_size_human"`
+	MinifiedImage          string                     `json:"minified_image"`
+	MinifiedImageHasData   bool                       `json:"minified_image_has_data"`
+	MinifiedBy             float64                    `json:"minified_by"`
+	SourceImageBlobSizes   *dockerimage.BlobSizes     `json:"source_image_blob_sizes,omitempty"`
+	MinifiedImageBlobSizes *dockerimage.BlobSizes     `json:"minified_image_blob_sizes,omitempty"`
+	OutputLocation         string                     `json:"output_location,omitempty"` //the minified filesystem output (instead of the minified image)
+	OutputResult           *dockerimage.ExtractResult `json:"output_result,omitempty"`
+	ArtifactLocation       string                     `json:"artifact_location"`
+	ContainerReportName    string                     `json:"container_report_name"`
+	SeccompProfileName     string                     `json:"seccomp_profile_name"`
+	AppArmorProfileName    string                     `json:"apparmor_profile_name"`
+	FilesAuditName         string                     `json:"files_audit_name,omitempty"`
+	ImageStack             []*reverse.ImageInfo       `json:"image_stack"`
 }
 
 // ProfileCommand is the 'profile' command report data
@@ -86,17 +91,42 @@ type ProfileCommand struct {
 	AppArmorProfileName    string  `json:"apparmor_profile_name"`
 }
 
+// XrayExtractInfo describes the extracted image path or the exported image layer
+type XrayExtractInfo struct {
+	Path   string                     `json:"path,omitempty"`
+	Layer  string                     `json:"layer,omitempty"`
+	Output string                     `json:"output"`
+	Result *dockerimage.ExtractResult `json:"result"`
+}
+
 // XrayCommand is the 'xray' command report data
 type XrayCommand struct {
 	Command
-	TargetReference      string                      `json:"target_reference"`
-	SourceImage          ImageMetadata               `json:"source_image"`
-	ArtifactLocation     string                      `json:"artifact_location"`
-	ImageStack           []*reverse.ImageInfo        `json:"image_stack"`
-	ImageLayers          []*dockerimage.LayerReport  `json:"image_layers"`
-	ImageArchiveLocation string                      `json:"image_archive_location"`
-	RawImageManifest     *dockerimage.ManifestObject `json:"raw_image_manifest,omitempty"`
-	RawImageConfig       *dockerimage.ConfigObject   `json:"raw_image_config,omitempty"`
+	TargetReference      string                         `json:"target_reference"`
+	SourceImage          ImageMetadata                  `json:"source_image"`
+	ArtifactLocation     string                         `json:"artifact_location"`
+	ImageStack           []*reverse.ImageInfo           `json:"image_stack"`
+	ImageLayers          []*dockerimage.LayerReport     `json:"image_layers"`
+	ImageEfficiency      *dockerimage.ImageEfficiency   `json:"image_efficiency,omitempty"`
+	ReclaimableSpace     *dockerimage.ReclaimableSpace  `json:"reclaimable_space,omitempty"`
+	BlobSizes            *dockerimage.BlobSizes         `json:"blob_sizes,omitempty"`
+	FileSecurity         *dockerimage.FileSecurity      `json:"file_security,omitempty"`
+	Entrypoint           *dockerimage.EntrypointInfo    `json:"entrypoint,omitempty"`
+	ExtractedPaths       []*XrayExtractInfo             `json:"extracted_paths,omitempty"`
+	ExportedLayer        *XrayExtractInfo               `json:"exported_layer,omitempty"`
+	Duplicates           *dockerimage.DuplicateFiles    `json:"duplicates,omitempty"`
+	ElfInventory         *dockerimage.ElfInventory      `json:"elf_inventory,omitempty"`
+	CompareReference     string                         `json:"compare_reference,omitempty"`
+	Comparison           *dockerimage.ImageComparison   `json:"comparison,omitempty"`
+	Secrets              []*dockerimage.SecretFinding   `json:"secrets,omitempty"`
+	PolicyLocation       string                         `json:"policy_location,omitempty"`
+	PolicyViolations     []*dockerimage.PolicyViolation `json:"policy_violations,omitempty"`
+	ImageArchiveLocation string                         `json:"image_archive_location"`
+	RawImageManifest     *dockerimage.ManifestObject    `json:"raw_image_manifest,omitempty"`
+	RawImageConfig       *dockerimage.ConfigObject      `json:"raw_image_config,omitempty"`
+	Software             *dockerimage.SoftwareInventory `json:"software,omitempty"`
+	SBOMFormat           string                         `json:"sbom_format,omitempty"`
+	SBOMLocation         string                         `json:"sbom_location,omitempty"`
 }
 
 // LintCommand is the 'lint' command report data
@@ -108,23 +138,66 @@ type LintCommand struct {
 	HitsCount       int                      `json:"hits_count"`
 	NoHitsCount     int                      `json:"nohits_count"`
 	ErrorsCount     int                      `json:"errors_count"`
-	Hits            map[string]*check.Result `json:"hits,omitempty"`   //map[CHECK_ID]CHECK_RESULT
-	Errors          map[string]error         `json:"errors,omitempty"` //map[CHECK_ID]ERROR_INFO
+	SuppressedCount int                      `json:"suppressed_count"`
+	Hits            map[string]*check.Result `json:"hits,omitempty"`       //map[CHECK_ID]CHECK_RESULT
+	Suppressed      map[string]*check.Result `json:"suppressed,omitempty"` //map[CHECK_ID]CHECK_RESULT (suppressed matches)
+	Errors          map[string]error         `json:"errors,omitempty"`     //map[CHECK_ID]ERROR_INFO
+	OutputFormat    string                   `json:"output_format,omitempty"`
+	OutputLocation  string                   `json:"output_location,omitempty"`
+	FixesCount      int                      `json:"fixes_count,omitempty"`
+	Fixes           []*check.Fix             `json:"fixes,omitempty"`
+	FixDiff         string                   `json:"fix_diff,omitempty"` //unified diff
 }
 
 // ContainerizeCommand is the 'containerize' command report data
 type ContainerizeCommand struct {
 	Command
+	TargetReference  string           `json:"target_reference"`
+	TargetExecutable string           `json:"target_executable"`
+	Entrypoint       []string         `json:"entrypoint"`
+	Cmd              []string         `json:"cmd,omitempty"`
+	ImageName        string           `json:"image_name"`
+	ImageSize        int64            `json:"image_size"`
+	ImageSizeHuman   string           `json:"image_size_human"`
+	ArtifactLocation string           `json:"artifact_location"`
+	Files            []*ArtifactProps `json:"files"`
 }
 
 // ConvertCommand is the 'convert' command report data
 type ConvertCommand struct {
 	Command
+	TargetReference      string        `json:"target_reference"`
+	SourceImage          ImageMetadata `json:"source_image"`
+	OutputFormat         string        `json:"output_format"`
+	OutputLocation       string        `json:"output_location"`
+	ImageArchiveLocation string        `json:"image_archive_location,omitempty"`
 }
 
 // EditCommand is the 'edit' command report data
 type EditCommand struct {
 	Command
+	TargetReference      string            `json:"target_reference"`
+	SourceImage          ImageMetadata     `json:"source_image"`
+	EditedImage          string            `json:"edited_image"`
+	EditedImageID        string            `json:"edited_image_id"`
+	EditedImageSize      int64             `json:"edited_image_size"`
+	EditedImageSizeHuman string            `json:"edited_image_size_human"`
+	AddedFiles           map[string]string `json:"added_files,omitempty"` //map[IMAGE_PATH]LOCAL_PATH
+	RemovedFiles         []string          `json:"removed_files,omitempty"`
+	ArtifactLocation     string            `json:"artifact_location"`
+}
+
+// DiffCommand is the 'diff' command report data
+type DiffCommand struct {
+	Command
+	FromReference            string                 `json:"from_reference"`
+	ToReference              string                 `json:"to_reference"`
+	FromImage                ImageMetadata          `json:"from_image"`
+	ToImage                  ImageMetadata          `json:"to_image"`
+	Diff                     *dockerimage.ImageDiff `json:"diff,omitempty"`
+	OutputLocation           string                 `json:"output_location,omitempty"`
+	FromImageArchiveLocation string                 `json:"from_image_archive_location,omitempty"`
+	ToImageArchiveLocation   string                 `json:"to_image_archive_location,omitempty"`
 }
 
 func (cmd *Command) init(containerized bool) {
@@ -233,6 +306,20 @@ func NewEditCommand(reportLocation string, containerized bool) *EditCommand {
 	return cmd
 }
 
+// NewDiffCommand creates a new 'diff' command report
+func NewDiffCommand(reportLocation string, containerized bool) *DiffCommand {
+	cmd := &DiffCommand{
+		Command: Command{
+			reportLocation: reportLocation,
+			Type:           command.Diff,
+			State:          command.StateUnknown,
+		},
+	}
+
+	cmd.Command.init(containerized)
+	return cmd
+}
+
 func (p *Command) ReportLocation() string {
 	return p.reportLocation
 }
@@ -295,3 +382,23 @@ func (p *XrayCommand) Save() bool {
 func (p *LintCommand) Save() bool {
 	return p.saveInfo(p)
 }
+
+// Save saves the Containerize command report data to the configured location
+func (p *ContainerizeCommand) Save() bool {
+	return p.saveInfo(p)
+}
+
+// Save saves the Convert command report data to the configured location
+func (p *ConvertCommand) Save() bool {
+	return p.saveInfo(p)
+}
+
+// Save saves the Edit command report data to the configured location
+func (p *EditCommand) Save() bool {
+	return p.saveInfo(p)
+}
+
+// Save saves the Diff command report data to the configured location
+func (p *DiffCommand) Save() bool {
+	return p.saveInfo(p)
+}
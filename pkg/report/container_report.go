@@ -141,9 +141,30 @@ func (p *ArtifactProps) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// Artifact keep reasons (why the artifacts are in the minified image)
+const (
+	KeepReasonAccess        = "access"         //observed file access
+	KeepReasonSymlinkTarget = "symlink.target" //target of a kept symlink
+	KeepReasonIncludePath   = "include.path"   //'--include-path' (files and directories)
+	KeepReasonIncludeBin    = "include.bin"    //'--include-bin' (the binary and its dependencies)
+	KeepReasonIncludeExe    = "include.exe"    //'--include-exe' (the executable and its dependencies)
+	KeepReasonIncludeShell  = "include.shell"  //'--include-shell' (the shell and the shell commands)
+	KeepReasonAppUser       = "app.user"       //user info file for the app user
+	KeepReasonOther         = "other"          //other runtime support files (e.g., app package files)
+)
+
+// IncludedArtifact is an artifact saved without an observed file access
+// (the directory artifacts include all directory objects)
+type IncludedArtifact struct {
+	FilePath string `json:"file_path"`
+	Reason   string `json:"reason"`
+	IsDir    bool   `json:"is_dir,omitempty"`
+}
+
 // ImageReport contains image report fields
 type ImageReport struct {
-	Files []*ArtifactProps `json:"files"`
+	Files    []*ArtifactProps    `json:"files"`
+	Included []*IncludedArtifact `json:"included,omitempty"`
 }
 
 // MonitorReports contains monitoring report fields